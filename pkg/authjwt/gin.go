@@ -0,0 +1,85 @@
+package authjwt
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ginClaimsKey is where Middleware stores the claims in the gin context.
+const ginClaimsKey = "authjwt.claims"
+
+// Middleware requires a valid bearer token in the Authorization header. The
+// claims are stored both in the gin context, see Claims, and in the context
+// of the request, see FromContext. Failures are answered with the same
+// {"code", "message"} body the services use for their own errors.
+func Middleware(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c.GetHeader("Authorization"))
+		if token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="e-commerce"`)
+			abort(c, http.StatusUnauthorized, "требуется access токен")
+			return
+		}
+		claims, err := v.Verify(c.Request.Context(), token)
+		if errors.Is(err, ErrKeysUnavailable) {
+			_ = c.Error(err)
+			abort(c, http.StatusServiceUnavailable, "не удалось проверить токен")
+			return
+		}
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="e-commerce", error="invalid_token"`)
+			abort(c, http.StatusUnauthorized, "неверный токен")
+			return
+		}
+		c.Set(ginClaimsKey, claims)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), claims))
+		c.Next()
+	}
+}
+
+// RequireScope lets the request through only if the token checked by
+// Middleware carries one of the given scopes.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		if claims == nil {
+			abort(c, http.StatusUnauthorized, "требуется access токен")
+			return
+		}
+		for _, s := range scopes {
+			if claims.HasScope(s) {
+				c.Next()
+				return
+			}
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="e-commerce", error="insufficient_scope"`)
+		abort(c, http.StatusForbidden, "недостаточно прав")
+	}
+}
+
+// ClaimsFrom returns the claims stored by Middleware, or nil.
+func ClaimsFrom(c *gin.Context) *Claims {
+	v, ok := c.Get(ginClaimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := v.(*Claims)
+	return claims
+}
+
+func abort(c *gin.Context, code int, message string) {
+	c.AbortWithStatusJSON(code, gin.H{"code": code, "message": message})
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>"
+// header, or "" if there is none.
+func bearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
@@ -0,0 +1,175 @@
+package authjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+const (
+	defaultMaxAge          = time.Hour
+	defaultRefreshInterval = time.Minute
+	// maxJWKSSize bounds the response read from the JWKS endpoint.
+	maxJWKSSize = 1 << 20
+)
+
+var (
+	// ErrUnknownKey is returned for a kid the key set does not have, even
+	// after refreshing it.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrKeysUnavailable is returned when the key set could not be fetched
+	// and no cached key can be used instead.
+	ErrKeysUnavailable = errors.New("signing keys unavailable")
+)
+
+// KeySet is a cache of the public keys published at a JWKS URL. It is safe
+// for concurrent use.
+//
+// The set is fetched on first use and again once it is older than its max
+// age, so that retired keys stop being accepted. An unknown kid, usually a
+// key rotation at the issuer, refreshes it as well. Fetches are rate limited
+// to one per refresh interval, so that tokens with made-up kids cannot turn
+// the cache into a load generator against the issuer.
+type KeySet struct {
+	url             string
+	client          *http.Client
+	maxAge          time.Duration
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+
+	// fetchMu serializes fetches; lastFetch is guarded by it.
+	fetchMu   sync.Mutex
+	lastFetch time.Time
+}
+
+// publicKey is a key of the set with the algorithm it is published for, if
+// the JWK names one.
+type publicKey struct {
+	key any
+	alg string
+}
+
+// NewKeySet returns a cache of the keys at url. Zero durations select the
+// defaults of one hour and one minute, a nil client http.DefaultClient.
+func NewKeySet(url string, client *http.Client, maxAge, refreshInterval time.Duration) *KeySet {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if maxAge <= 0 {
+		maxAge = defaultMaxAge
+	}
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+	return &KeySet{
+		url:             url,
+		client:          client,
+		maxAge:          maxAge,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+// Key returns the public key with the given kid. While the issuer cannot be
+// reached, a cached key keeps being returned past the max age of the set.
+func (s *KeySet) Key(ctx context.Context, kid string) (any, error) {
+	k, err := s.lookup(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return k.key, nil
+}
+
+func (s *KeySet) lookup(ctx context.Context, kid string) (publicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := s.now().Sub(s.fetchedAt) > s.maxAge
+	s.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+		return publicKey{}, err
+	}
+	s.mu.RLock()
+	key, ok = s.keys[kid]
+	s.mu.RUnlock()
+	if !ok {
+		return publicKey{}, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// refresh fetches the key set unless that was tried less than the refresh
+// interval ago. Callers that waited for a concurrent fetch find it done and
+// do not fetch again.
+func (s *KeySet) refresh(ctx context.Context) error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	now := s.now()
+	if !s.lastFetch.IsZero() && now.Sub(s.lastFetch) < s.refreshInterval {
+		return nil
+	}
+	s.lastFetch = now
+
+	keys, err := s.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrKeysUnavailable, err)
+	}
+	s.mu.Lock()
+	s.keys, s.fetchedAt = keys, now
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("read jwks: %w", err)
+	}
+	set, err := jwk.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]publicKey, set.Len())
+	for i := 0; i < set.Len(); i++ {
+		k, _ := set.Key(i)
+		if k.KeyID() == "" || (k.KeyUsage() != "" && k.KeyUsage() != string(jwk.ForSignature)) {
+			continue
+		}
+		var raw any
+		if err := k.Raw(&raw); err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.KeyID(), err)
+		}
+		keys[k.KeyID()] = publicKey{key: raw, alg: k.Algorithm().String()}
+	}
+	return keys, nil
+}
@@ -0,0 +1,179 @@
+package authjwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issuer stands in for auth-svc: it serves a JWKS of its keys and counts how
+// often it was fetched.
+type issuer struct {
+	t       *testing.T
+	srv     *httptest.Server
+	fetches atomic.Int32
+
+	mu   sync.Mutex
+	keys map[string]crypto.Signer
+	down bool
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	is := &issuer{t: t, keys: map[string]crypto.Signer{}}
+	is.srv = httptest.NewServer(http.HandlerFunc(is.serveJWKS))
+	t.Cleanup(is.srv.Close)
+	return is
+}
+
+func (is *issuer) url() string {
+	return is.srv.URL + "/.well-known/jwks.json"
+}
+
+// addKey generates an RSA key with the given kid and publishes it.
+func (is *issuer) addKey(kid string) *rsa.PrivateKey {
+	is.t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(is.t, err)
+	is.publish(kid, key)
+	return key
+}
+
+// addEdKey is addKey for Ed25519 keys.
+func (is *issuer) addEdKey(kid string) ed25519.PrivateKey {
+	is.t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(is.t, err)
+	is.publish(kid, key)
+	return key
+}
+
+func (is *issuer) publish(kid string, key crypto.Signer) {
+	is.mu.Lock()
+	is.keys[kid] = key
+	is.mu.Unlock()
+}
+
+func (is *issuer) setDown(down bool) {
+	is.mu.Lock()
+	is.down = down
+	is.mu.Unlock()
+}
+
+// serveJWKS publishes the keys like keys.Ring does.
+func (is *issuer) serveJWKS(w http.ResponseWriter, _ *http.Request) {
+	is.fetches.Add(1)
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if is.down {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	set := jwk.NewSet()
+	for kid, key := range is.keys {
+		jwkKey, err := jwk.FromRaw(key.Public())
+		require.NoError(is.t, err)
+		_ = jwkKey.Set(jwk.KeyIDKey, kid)
+		alg := "RS256"
+		if _, ok := key.(ed25519.PrivateKey); ok {
+			alg = "EdDSA"
+		}
+		_ = jwkKey.Set(jwk.AlgorithmKey, alg)
+		_ = jwkKey.Set(jwk.KeyUsageKey, "sig")
+		_ = set.AddKey(jwkKey)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+// fakeClock is a time source tests move by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestKeySet_RefreshesOnUnknownKidWithRateLimit(t *testing.T) {
+	is := newIssuer(t)
+	first := is.addKey("first")
+	clock := &fakeClock{now: time.Now()}
+	set := NewKeySet(is.url(), nil, time.Hour, time.Minute)
+	set.now = clock.Now
+
+	ctx := context.Background()
+	key, err := set.Key(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, &first.PublicKey, key)
+	_, err = set.Key(ctx, "first")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, is.fetches.Load(), "известный ключ берется из кэша")
+
+	second := is.addKey("second")
+	_, err = set.Key(ctx, "second")
+	assert.ErrorIs(t, err, ErrUnknownKey, "до истечения интервала набор не перезапрашивается")
+	_, err = set.Key(ctx, "made-up")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.EqualValues(t, 1, is.fetches.Load(), "неизвестные kid не должны порождать запросы")
+
+	clock.Advance(time.Minute)
+	key, err = set.Key(ctx, "second")
+	require.NoError(t, err, "после ротации новый ключ подхватывается")
+	assert.Equal(t, &second.PublicKey, key)
+	assert.EqualValues(t, 2, is.fetches.Load())
+}
+
+func TestKeySet_KeepsKeysWhileIssuerIsDown(t *testing.T) {
+	is := newIssuer(t)
+	is.addKey("current")
+	clock := &fakeClock{now: time.Now()}
+	set := NewKeySet(is.url(), nil, time.Hour, time.Minute)
+	set.now = clock.Now
+
+	ctx := context.Background()
+	_, err := set.Key(ctx, "current")
+	require.NoError(t, err)
+
+	is.setDown(true)
+	clock.Advance(2 * time.Hour)
+	_, err = set.Key(ctx, "current")
+	assert.NoError(t, err, "устаревший ключ используется, пока издатель недоступен")
+	_, err = set.Key(ctx, "other")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	clock.Advance(time.Minute)
+	_, err = set.Key(ctx, "other")
+	assert.ErrorIs(t, err, ErrKeysUnavailable)
+}
+
+func TestKeySet_UnavailableBeforeFirstFetch(t *testing.T) {
+	is := newIssuer(t)
+	is.setDown(true)
+	set := NewKeySet(is.url(), nil, 0, 0)
+
+	_, err := set.Key(context.Background(), "current")
+	assert.ErrorIs(t, err, ErrKeysUnavailable)
+}
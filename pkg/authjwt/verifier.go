@@ -0,0 +1,194 @@
+// Package authjwt verifies the access tokens auth-svc issues, for the other
+// services of the shop. Keys are taken from the JWKS auth-svc publishes, so
+// key rotations need no redeploys, and the verified claims are put into the
+// request context by the gin middleware and gRPC interceptors of the package.
+package authjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token types a Verifier accepts, as found in the typ claim.
+const (
+	TypeAccess = "access"
+	TypeClient = "client"
+)
+
+// ErrInvalidToken is returned for tokens that fail verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config configures a Verifier.
+type Config struct {
+	// JWKSURL is where the issuer publishes its keys, e.g.
+	// "http://auth-svc:8080/.well-known/jwks.json".
+	JWKSURL string
+	// Issuer and Audience must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Algorithms are the signing algorithms accepted, RS256, ES256 and EdDSA
+	// if empty. A token must also use the algorithm its key is published
+	// for.
+	Algorithms []string
+	// Leeway is the clock skew allowed when checking exp and nbf.
+	Leeway time.Duration
+	// MaxAge is how long fetched keys are used before the set is fetched
+	// again, one hour if zero.
+	MaxAge time.Duration
+	// MinRefreshInterval is the least time between two fetches of the set,
+	// one minute if zero.
+	MinRefreshInterval time.Duration
+	// HTTPClient fetches the set, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Claims are the claims of a verified token.
+type Claims struct {
+	jwt.RegisteredClaims
+	// Type is "access" for tokens of users and "client" for those of OAuth
+	// clients acting for themselves.
+	Type          string   `json:"typ"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"perms,omitempty"`
+	// Scope is space separated, see Scopes.
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// SessionID is the session a user token was issued in.
+	SessionID string   `json:"sid,omitempty"`
+	AMR       []string `json:"amr,omitempty"`
+	// Actor is set on tokens a service obtained with the token exchange
+	// grant to call others on behalf of the user, who remains the subject.
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Actor is the party a token was delegated to, see RFC 8693 section 4.1.
+type Actor struct {
+	// Subject is the client ID of the service.
+	Subject string `json:"sub"`
+	// Actor is the party that delegated the token further, if it was
+	// exchanged more than once.
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Scopes returns the scopes the token was granted.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// IsDelegated tells whether a service obtained the token on behalf of its
+// subject, see Actor.
+func (c *Claims) IsDelegated() bool {
+	return c.Actor != nil
+}
+
+// IsClient tells whether the token was issued to an OAuth client rather than
+// a user.
+func (c *Claims) IsClient() bool {
+	return c.Type == TypeClient
+}
+
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+func (c *Claims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// Verifier checks the signature, algorithm, exp, nbf, iss, aud and typ of
+// tokens. It does not see revocations: services that must not accept a
+// revoked token before it expires should ask auth-svc to introspect it.
+type Verifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewVerifier returns a Verifier for cfg. The keys are fetched on first use.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	switch {
+	case cfg.JWKSURL == "":
+		return nil, errors.New("authjwt: JWKS URL is required")
+	case cfg.Issuer == "":
+		return nil, errors.New("authjwt: issuer is required")
+	case cfg.Audience == "":
+		return nil, errors.New("authjwt: audience is required")
+	}
+	algs := cfg.Algorithms
+	if len(algs) == 0 {
+		algs = []string{
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodES256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		}
+	}
+	return &Verifier{
+		keys: NewKeySet(cfg.JWKSURL, cfg.HTTPClient, cfg.MaxAge, cfg.MinRefreshInterval),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(algs),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}, nil
+}
+
+// Verify returns the claims of raw. Failures wrap ErrInvalidToken, or
+// ErrKeysUnavailable when the keys could not be fetched, which is not the
+// fault of the caller.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("kid header is missing")
+		}
+		k, err := v.keys.lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if k.alg != "" && k.alg != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q is for %s, not %s", kid, k.alg, token.Method.Alg())
+		}
+		return k.key, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrKeysUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Type != TypeAccess && claims.Type != TypeClient {
+		return nil, fmt.Errorf("%w: unexpected token type %q", ErrInvalidToken, claims.Type)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: subject is missing", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx carrying claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims stored by NewContext, or nil.
+func FromContext(ctx context.Context) *Claims {
+	c, _ := ctx.Value(claimsKey{}).(*Claims)
+	return c
+}
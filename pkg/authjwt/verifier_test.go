@@ -0,0 +1,242 @@
+package authjwt
+
+import (
+	"context"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testIssuer   = "http://auth-svc:8080"
+	testAudience = "e-commerce"
+)
+
+func newVerifier(t *testing.T, is *issuer) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(Config{JWKSURL: is.url(), Issuer: testIssuer, Audience: testAudience})
+	require.NoError(t, err)
+	return v
+}
+
+// accessClaims are the claims auth-svc puts into a user access token.
+func accessClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub":   "7d1f3c1e-5d0a-4d43-9c55-3d0d8bfe1a10",
+		"jti":   "0b4d3c8e-2a7e-4f57-8d4b-2c7b8f1b7e21",
+		"typ":   TypeAccess,
+		"email": "user@example.com",
+		"roles": []string{"user"},
+		"perms": []string{"orders:read"},
+		"scope": "openid orders",
+		"sid":   "session",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   now.Add(15 * time.Minute).Unix(),
+		"iat":   now.Unix(),
+	}
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	is := newIssuer(t)
+	key := is.addKey("current")
+	v := newVerifier(t, is)
+
+	claims, err := v.Verify(context.Background(), sign(t, key, "current", accessClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "7d1f3c1e-5d0a-4d43-9c55-3d0d8bfe1a10", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.Equal(t, []string{"openid", "orders"}, claims.Scopes())
+	assert.True(t, claims.HasRole("user"))
+	assert.True(t, claims.HasPermission("orders:read"))
+	assert.True(t, claims.HasScope("orders"))
+	assert.False(t, claims.IsClient())
+	assert.Equal(t, "session", claims.SessionID)
+}
+
+func TestVerifier_RejectsInvalidTokens(t *testing.T) {
+	is := newIssuer(t)
+	key := is.addKey("current")
+	other := is.addKey("other")
+	v := newVerifier(t, is)
+
+	with := func(name string, value any) jwt.MapClaims {
+		c := accessClaims()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+	hs256 := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims())
+	hs256.Header["kid"] = "current"
+	hsRaw, err := hs256.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"expired":        sign(t, key, "current", with("exp", time.Now().Add(-time.Minute).Unix())),
+		"without exp":    sign(t, key, "current", with("exp", nil)),
+		"not yet valid":  sign(t, key, "current", with("nbf", time.Now().Add(time.Hour).Unix())),
+		"foreign issuer": sign(t, key, "current", with("iss", "http://evil")),
+		"other audience": sign(t, key, "current", with("aud", "admin-panel")),
+		"refresh token":  sign(t, key, "current", with("typ", "refresh")),
+		"without sub":    sign(t, key, "current", with("sub", nil)),
+		"wrong kid":      sign(t, other, "current", accessClaims()),
+		"unknown kid":    sign(t, key, "missing", accessClaims()),
+		"hs256":          hsRaw,
+		"garbage":        "not-a-token",
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), raw)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestVerifier_EdDSA(t *testing.T) {
+	is := newIssuer(t)
+	edKey := is.addEdKey("ed")
+	rsaKey := is.addKey("rsa")
+	v := newVerifier(t, is)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, accessClaims())
+	token.Header["kid"] = "ed"
+	raw, err := token.SignedString(edKey)
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), raw)
+	assert.NoError(t, err, "ключи Ed25519 должны поддерживаться наравне с RSA")
+
+	_, err = v.Verify(context.Background(), sign(t, rsaKey, "rsa", accessClaims()))
+	assert.NoError(t, err)
+
+	token = jwt.NewWithClaims(jwt.SigningMethodEdDSA, accessClaims())
+	token.Header["kid"] = "rsa"
+	raw, err = token.SignedString(edKey)
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrInvalidToken, "алгоритм токена должен совпадать с alg ключа")
+}
+
+func TestVerifier_DelegatedToken(t *testing.T) {
+	is := newIssuer(t)
+	key := is.addKey("current")
+	v := newVerifier(t, is)
+
+	c := accessClaims()
+	c["aud"] = []string{"catalog-svc", testAudience}
+	c["client_id"] = "cart-svc"
+	c["act"] = map[string]any{"sub": "cart-svc", "act": map[string]any{"sub": "gateway"}}
+	claims, err := v.Verify(context.Background(), sign(t, key, "current", c))
+	require.NoError(t, err)
+	assert.True(t, claims.IsDelegated())
+	assert.Equal(t, "7d1f3c1e-5d0a-4d43-9c55-3d0d8bfe1a10", claims.Subject, "субъектом остается пользователь")
+	assert.Equal(t, "cart-svc", claims.Actor.Subject)
+	if assert.NotNil(t, claims.Actor.Actor) {
+		assert.Equal(t, "gateway", claims.Actor.Actor.Subject)
+	}
+}
+
+func TestVerifier_AcceptsClientTokens(t *testing.T) {
+	is := newIssuer(t)
+	key := is.addKey("current")
+	v := newVerifier(t, is)
+
+	c := accessClaims()
+	c["typ"] = TypeClient
+	c["sub"] = "cart-svc"
+	delete(c, "email")
+	claims, err := v.Verify(context.Background(), sign(t, key, "current", c))
+	require.NoError(t, err)
+	assert.True(t, claims.IsClient())
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	is := newIssuer(t)
+	key := is.addKey("current")
+	v := newVerifier(t, is)
+
+	r := gin.New()
+	r.GET("/orders", Middleware(v), RequireScope("orders"), func(c *gin.Context) {
+		assert.Same(t, ClaimsFrom(c), FromContext(c.Request.Context()))
+		c.String(http.StatusOK, ClaimsFrom(c).Subject)
+	})
+	call := func(auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("Bearer " + sign(t, key, "current", accessClaims()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "7d1f3c1e-5d0a-4d43-9c55-3d0d8bfe1a10", w.Body.String())
+
+	w = call("")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+	w = call("Bearer garbage")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	c := accessClaims()
+	c["scope"] = "openid"
+	w = call("Bearer " + sign(t, key, "current", c))
+	assert.Equal(t, http.StatusForbidden, w.Code, "без нужного scope доступ запрещен")
+
+	down := newIssuer(t)
+	down.setDown(true)
+	r = gin.New()
+	r.GET("/orders", Middleware(newVerifier(t, down)), func(c *gin.Context) { c.Status(http.StatusOK) })
+	w = call("Bearer " + sign(t, key, "current", accessClaims()))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "недоступность JWKS не должна выглядеть как неверный токен")
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	is := newIssuer(t)
+	key := is.addKey("current")
+	intercept := UnaryServerInterceptor(newVerifier(t, is))
+	info := &grpc.UnaryServerInfo{FullMethod: "/cart.Cart/GetCart"}
+	handler := func(ctx context.Context, _ any) (any, error) {
+		return FromContext(ctx).Subject, nil
+	}
+	incoming := func(auth string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", auth))
+	}
+
+	resp, err := intercept(incoming("Bearer "+sign(t, key, "current", accessClaims())), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "7d1f3c1e-5d0a-4d43-9c55-3d0d8bfe1a10", resp)
+
+	_, err = intercept(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = intercept(incoming("Bearer garbage"), nil, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/db"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/grpcx"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/handler"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/keys"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
 	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -41,17 +47,81 @@ func main() {
 	defer database.Close()
 	logger.Info().Msg("Connected to database")
 
+	keyRing, err := keys.NewRing(cfg.JWT, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to load JWT keys")
+	}
+
+	mail, err := mailer.New(cfg.Mail, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to configure mailer")
+	}
+
 	ClientRepo := repository.NewClientRepository(database)
 	authRepo := repository.NewAuthRepository(database)
+	tokenRepo := repository.NewTokenRepository(database)
+	codeRepo := repository.NewAuthorizationCodeRepository(database)
+	apiKeyRepo := repository.NewAPIKeyRepository(database)
+	roleRepo := repository.NewRoleRepository(database)
+	resetRepo := repository.NewPasswordResetRepository(database)
+	throttleRepo := repository.NewThrottleRepository(database)
+	mfaRepo := repository.NewMFARepository(database)
+	auditRepo := repository.NewAuditRepository(database)
 	logger.Info().Msg("Auth repository initialized")
-	authService := service.NewAuthService(authRepo, logger, cfg, ClientRepo)
+
+	var throttleStore throttle.Store = throttleRepo
+	if cfg.Throttle.Backend == "redis" {
+		rds, err := db.NewRedisClient(cfg)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to connect to redis")
+		}
+		defer rds.Close()
+		throttleStore = throttle.NewRedisStore(rds)
+	}
+	guard := throttle.NewGuard(throttleStore, throttleRepo, cfg.Throttle, logger)
+	auditLog := audit.New(auditRepo, logger)
+
+	authService := service.NewAuthService(authRepo, logger, cfg, ClientRepo, tokenRepo, codeRepo, apiKeyRepo, keyRing, mail, guard, mfaRepo, auditLog)
+	clientService := service.NewClientService(ClientRepo, logger, cfg, auditLog)
+	userService := service.NewUserService(authRepo, tokenRepo, logger, auditLog)
+	roleService := service.NewRoleService(roleRepo, logger, auditLog)
+	resetGuard := throttle.NewGuard(throttle.WithPrefix(throttleStore, "password_reset:"), nil, cfg.Throttle, logger)
+	passwordService := service.NewPasswordService(authRepo, resetRepo, mail, resetGuard, logger, cfg)
+	lockoutService := service.NewLockoutService(guard, authRepo, ClientRepo, throttleRepo, logger, auditLog)
+	auditService := service.NewAuditService(auditRepo, logger, cfg.Audit.Retention)
 	logger.Info().Msg("Auth service initialized")
 	logger.Info().Msg("db_name" + ": " + cfg.Database.Name)
 	router := gin.New()
+	// Without trusted proxies gin would take X-Forwarded-For from anyone,
+	// letting callers pick the IP that is throttled and audited.
+	var proxies []string
+	if len(cfg.Server.TrustedProxies) > 0 {
+		proxies = cfg.Server.TrustedProxies
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid trusted proxies")
+	}
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggingMiddleware(logger))
-	handler.RegisterRoutes(router, authService, cfg)
+	handler.RegisterRoutes(router, handler.Services{
+		Auth:     authService,
+		Clients:  clientService,
+		Users:    userService,
+		Roles:    roleService,
+		Password: passwordService,
+		Lockouts: lockoutService,
+		Audit:    auditService,
+	}, cfg)
 	logger.Info().Msg("Routes registered")
+
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go authService.RunCleanup(cleanupCtx, cfg.JWT.CleanupInterval)
+	go passwordService.RunCleanup(cleanupCtx, cfg.JWT.CleanupInterval)
+	go lockoutService.RunCleanup(cleanupCtx, cfg.JWT.CleanupInterval)
+	go auditService.RunRetention(cleanupCtx, cfg.Audit.PurgeInterval)
+	go watchKeys(cleanupCtx, keyRing, cfg.JWT.KeysReload, logger)
+
 	srv := &http.Server{
 		Addr:    cfg.Server.Port,
 		Handler: router,
@@ -63,6 +133,17 @@ func main() {
 		}
 	}()
 
+	grpcServer := grpcx.NewServer(authService, userService, logger)
+	go func() {
+		lis, err := net.Listen("tcp", cfg.Server.GRPCPort)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to listen for gRPC")
+		}
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to start gRPC server")
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
@@ -77,6 +158,47 @@ func main() {
 	} else {
 		logger.Info().Msg("Server gracefully stopped")
 	}
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+		logger.Info().Msg("gRPC server gracefully stopped")
+	case <-ctx.Done():
+		grpcServer.Stop()
+		logger.Error().Msg("gRPC server shutdown timed out")
+	}
+}
+
+// watchKeys reloads the key ring on SIGHUP and, if interval is positive, on
+// every tick of it.
+func watchKeys(ctx context.Context, ring *keys.Ring, interval time.Duration, logger zerolog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Info().Msg("SIGHUP received, reloading JWT keys")
+		case <-tick:
+		}
+		if err := ring.Reload(); err != nil {
+			logger.Error().Err(err).Msg("Failed to reload JWT keys, keeping previous keys")
+		}
+	}
 }
 
 func SetupLogger(env string) zerolog.Logger {
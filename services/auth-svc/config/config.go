@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"github.com/spf13/viper"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -11,12 +13,28 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:",squash"`
 	Database DatabaseConfig `mapstructure:",squash"`
 	JWT      JWTConfig      `mapstructure:",squash"`
+	OAuth    OAuthConfig    `mapstructure:",squash"`
+	Password PasswordConfig `mapstructure:",squash"`
+	Hash     HashConfig     `mapstructure:",squash"`
+	Mail     MailConfig     `mapstructure:",squash"`
+	Verify   VerifyConfig   `mapstructure:",squash"`
+	MFA      MFAConfig      `mapstructure:",squash"`
+	APIKey   APIKeyConfig   `mapstructure:",squash"`
+	Throttle ThrottleConfig `mapstructure:",squash"`
+	Redis    RedisConfig    `mapstructure:",squash"`
+	Audit    AuditConfig    `mapstructure:",squash"`
 }
 
 type ServerConfig struct {
 	Port         string        `mapstructure:"auth_server_port"`
 	ReadTimeout  time.Duration `mapstructure:"auth_server_read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"auth_server_write_timeout"`
+	// GRPCPort is the address of the gRPC API for other services.
+	GRPCPort string `mapstructure:"auth_server_grpc_port"`
+	// TrustedProxies are the addresses and CIDRs of the reverse proxies
+	// whose X-Forwarded-For header gives the client IP, comma-separated.
+	// With none, the IP is that of the connection.
+	TrustedProxies []string `mapstructure:"auth_server_trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -35,8 +53,144 @@ type JWTConfig struct {
 	PrivateKeyPath  string        `mapstructure:"auth_jwt_private_key"`
 	PublicKeyPath   string        `mapstructure:"auth_jwt_public_key"`
 	KeyID           string        `mapstructure:"auth_jwt_kid"`
+	KeysDir         string        `mapstructure:"auth_jwt_keys_dir"`
+	KeysReload      time.Duration `mapstructure:"auth_jwt_keys_reload_interval"`
 	AccessTokenTTL  time.Duration `mapstructure:"auth_jwt_access_token_ttl"`
 	RefreshTokenTTL time.Duration `mapstructure:"auth_jwt_refresh_token_ttl"`
+	CleanupInterval time.Duration `mapstructure:"auth_jwt_cleanup_interval"`
+	// Issuer is the public base URL of the service. It is the iss claim of
+	// every token and the OpenID Connect issuer identifier.
+	Issuer string `mapstructure:"auth_jwt_issuer"`
+	// Audience is the aud claim of every token except ID tokens, which are
+	// issued to the OAuth client.
+	Audience string `mapstructure:"auth_jwt_audience"`
+	// LegacyRefreshClaims keeps the email and roles claims in refresh tokens,
+	// which releases before they were dropped require. Enable it while such a
+	// release may still have to be rolled back to.
+	LegacyRefreshClaims bool `mapstructure:"auth_jwt_legacy_refresh_claims"`
+}
+
+type OAuthConfig struct {
+	ClientSecretGrace time.Duration `mapstructure:"auth_oauth_client_secret_grace"`
+	// CodeTTL is how long an authorization code can be exchanged for tokens.
+	CodeTTL time.Duration `mapstructure:"auth_oauth_code_ttl"`
+	// ExchangeTokenTTL is the lifetime of tokens issued by the token exchange
+	// grant. They never outlive the token they were exchanged for.
+	ExchangeTokenTTL time.Duration `mapstructure:"auth_oauth_exchange_token_ttl"`
+}
+
+// PasswordConfig configures password resets and the policy new passwords
+// must satisfy on registration, reset and change. Zero MinClasses and
+// History and an empty BreachedDir disable their rule.
+type PasswordConfig struct {
+	ResetTTL time.Duration `mapstructure:"auth_password_reset_ttl"`
+	// ResetURL is the page that receives the reset token as its token query
+	// parameter.
+	ResetURL string `mapstructure:"auth_password_reset_url"`
+	// MinLength and MaxLength are counted in characters.
+	MinLength int `mapstructure:"auth_password_min_length"`
+	MaxLength int `mapstructure:"auth_password_max_length"`
+	// MinClasses is how many of lowercase letters, uppercase letters, digits
+	// and other characters a password must mix.
+	MinClasses int `mapstructure:"auth_password_min_classes"`
+	// History is how many of the latest passwords of a user, the current one
+	// included, may not be reused.
+	History int `mapstructure:"auth_password_history"`
+	// BreachedDir holds a list of breached passwords in the range format of
+	// the k-anonymity API of Have I Been Pwned: one file per first five hex
+	// digits of the SHA-1 hash, named by them with an optional .txt
+	// extension, whose lines are the remaining 35 digits, a colon and a
+	// count. It is never fetched over the network.
+	BreachedDir string `mapstructure:"auth_password_breached_dir"`
+}
+
+// Unverified account policies, see VerifyConfig.UnverifiedPolicy.
+const (
+	UnverifiedAllow   = "allow"
+	UnverifiedLimited = "limited"
+	UnverifiedDeny    = "deny"
+)
+
+type VerifyConfig struct {
+	TTL time.Duration `mapstructure:"auth_verify_email_ttl"`
+	// URL is the page that receives the verification token as its token
+	// query parameter.
+	URL            string        `mapstructure:"auth_verify_email_url"`
+	ResendInterval time.Duration `mapstructure:"auth_verify_email_resend_interval"`
+	// UnverifiedPolicy decides what users with an unverified email may do:
+	// "allow" treats them like everyone else, "limited" issues tokens without
+	// roles and permissions, and "deny" refuses to issue tokens at all.
+	UnverifiedPolicy string `mapstructure:"auth_verify_email_unverified_policy"`
+}
+
+// MFAConfig configures two-factor authentication with TOTP authenticators.
+type MFAConfig struct {
+	// Issuer names the service in authenticator apps.
+	Issuer string `mapstructure:"auth_mfa_issuer"`
+	// ChallengeTTL is how long the second step of a sign-in may take.
+	ChallengeTTL  time.Duration `mapstructure:"auth_mfa_challenge_ttl"`
+	RecoveryCodes int           `mapstructure:"auth_mfa_recovery_codes"`
+}
+
+// APIKeyConfig configures the API keys users create for scripts.
+type APIKeyConfig struct {
+	// TokenTTL is the lifetime of access tokens a key is exchanged for.
+	TokenTTL time.Duration `mapstructure:"auth_api_key_token_ttl"`
+	// MaxPerUser bounds the keys a user may have that are neither revoked
+	// nor expired.
+	MaxPerUser int `mapstructure:"auth_api_key_max_per_user"`
+}
+
+// ThrottleConfig limits failed sign-in attempts. Once a counter reaches its
+// threshold the account, client or IP address is locked for BaseLockout,
+// doubling with every further failure up to MaxLockout. Counters are
+// forgotten after Window without failures.
+type ThrottleConfig struct {
+	// Backend is "postgres" or "redis".
+	Backend          string        `mapstructure:"auth_throttle_backend"`
+	AccountThreshold int           `mapstructure:"auth_throttle_account_threshold"`
+	IPThreshold      int           `mapstructure:"auth_throttle_ip_threshold"`
+	BaseLockout      time.Duration `mapstructure:"auth_throttle_base_lockout"`
+	MaxLockout       time.Duration `mapstructure:"auth_throttle_max_lockout"`
+	Window           time.Duration `mapstructure:"auth_throttle_window"`
+}
+
+// AuditConfig configures the security audit log. Events older than
+// Retention are purged every PurgeInterval.
+type AuditConfig struct {
+	Retention     time.Duration `mapstructure:"auth_audit_retention"`
+	PurgeInterval time.Duration `mapstructure:"auth_audit_purge_interval"`
+}
+
+type RedisConfig struct {
+	Host     string `mapstructure:"auth_redis_host"`
+	Password string `mapstructure:"auth_redis_password"`
+	DB       int    `mapstructure:"auth_redis_db"`
+}
+
+// HashConfig selects how passwords and client secrets are hashed. Stored
+// hashes made with another algorithm or other parameters are upgraded on the
+// next successful sign-in.
+type HashConfig struct {
+	// Algorithm is "argon2id" or "bcrypt".
+	Algorithm string `mapstructure:"auth_password_hash_algorithm"`
+	// Argon2Memory is in KiB.
+	Argon2Memory      uint32 `mapstructure:"auth_password_argon2_memory"`
+	Argon2Iterations  uint32 `mapstructure:"auth_password_argon2_iterations"`
+	Argon2Parallelism uint8  `mapstructure:"auth_password_argon2_parallelism"`
+	BcryptCost        int    `mapstructure:"auth_password_bcrypt_cost"`
+}
+
+type MailConfig struct {
+	// Driver is "smtp" or "log". The log driver only logs messages and, if
+	// Dir is set, writes them there as .eml files.
+	Driver       string `mapstructure:"auth_mail_driver"`
+	From         string `mapstructure:"auth_mail_from"`
+	SMTPHost     string `mapstructure:"auth_mail_smtp_host"`
+	SMTPPort     int    `mapstructure:"auth_mail_smtp_port"`
+	SMTPUser     string `mapstructure:"auth_mail_smtp_user"`
+	SMTPPassword string `mapstructure:"auth_mail_smtp_password"`
+	Dir          string `mapstructure:"auth_mail_dir"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -47,6 +201,8 @@ func LoadConfig() (*Config, error) {
 	_ = viper.BindEnv("auth_server_port", "AUTH_SERVER_PORT")
 	_ = viper.BindEnv("auth_server_read_timeout", "AUTH_SERVER_READ_TIMEOUT")
 	_ = viper.BindEnv("auth_server_write_timeout", "AUTH_SERVER_WRITE_TIMEOUT")
+	_ = viper.BindEnv("auth_server_grpc_port", "AUTH_SERVER_GRPC_PORT")
+	_ = viper.BindEnv("auth_server_trusted_proxies", "AUTH_SERVER_TRUSTED_PROXIES")
 
 	_ = viper.BindEnv("auth_db_user", "AUTH_DB_USER")
 	_ = viper.BindEnv("auth_db_password", "AUTH_DB_PASSWORD")
@@ -61,8 +217,66 @@ func LoadConfig() (*Config, error) {
 	_ = viper.BindEnv("auth_jwt_private_key", "AUTH_JWT_PRIVATE_KEY")
 	_ = viper.BindEnv("auth_jwt_public_key", "AUTH_JWT_PUBLIC_KEY")
 	_ = viper.BindEnv("auth_jwt_kid", "AUTH_JWT_KID")
+	_ = viper.BindEnv("auth_jwt_keys_dir", "AUTH_JWT_KEYS_DIR")
+	_ = viper.BindEnv("auth_jwt_keys_reload_interval", "AUTH_JWT_KEYS_RELOAD_INTERVAL")
 	_ = viper.BindEnv("auth_jwt_access_token_ttl", "AUTH_JWT_ACCESS_TOKEN_TTL")
 	_ = viper.BindEnv("auth_jwt_refresh_token_ttl", "AUTH_JWT_REFRESH_TOKEN_TTL")
+	_ = viper.BindEnv("auth_jwt_cleanup_interval", "AUTH_JWT_CLEANUP_INTERVAL")
+	_ = viper.BindEnv("auth_jwt_issuer", "AUTH_JWT_ISSUER")
+	_ = viper.BindEnv("auth_jwt_audience", "AUTH_JWT_AUDIENCE")
+	_ = viper.BindEnv("auth_jwt_legacy_refresh_claims", "AUTH_JWT_LEGACY_REFRESH_CLAIMS")
+
+	_ = viper.BindEnv("auth_oauth_client_secret_grace", "AUTH_OAUTH_CLIENT_SECRET_GRACE")
+	_ = viper.BindEnv("auth_oauth_code_ttl", "AUTH_OAUTH_CODE_TTL")
+	_ = viper.BindEnv("auth_oauth_exchange_token_ttl", "AUTH_OAUTH_EXCHANGE_TOKEN_TTL")
+
+	_ = viper.BindEnv("auth_password_reset_ttl", "AUTH_PASSWORD_RESET_TTL")
+	_ = viper.BindEnv("auth_password_reset_url", "AUTH_PASSWORD_RESET_URL")
+	_ = viper.BindEnv("auth_password_min_length", "AUTH_PASSWORD_MIN_LENGTH")
+	_ = viper.BindEnv("auth_password_max_length", "AUTH_PASSWORD_MAX_LENGTH")
+	_ = viper.BindEnv("auth_password_min_classes", "AUTH_PASSWORD_MIN_CLASSES")
+	_ = viper.BindEnv("auth_password_history", "AUTH_PASSWORD_HISTORY")
+	_ = viper.BindEnv("auth_password_breached_dir", "AUTH_PASSWORD_BREACHED_DIR")
+
+	_ = viper.BindEnv("auth_password_hash_algorithm", "AUTH_PASSWORD_HASH_ALGORITHM")
+	_ = viper.BindEnv("auth_password_argon2_memory", "AUTH_PASSWORD_ARGON2_MEMORY")
+	_ = viper.BindEnv("auth_password_argon2_iterations", "AUTH_PASSWORD_ARGON2_ITERATIONS")
+	_ = viper.BindEnv("auth_password_argon2_parallelism", "AUTH_PASSWORD_ARGON2_PARALLELISM")
+	_ = viper.BindEnv("auth_password_bcrypt_cost", "AUTH_PASSWORD_BCRYPT_COST")
+
+	_ = viper.BindEnv("auth_mail_driver", "AUTH_MAIL_DRIVER")
+	_ = viper.BindEnv("auth_mail_from", "AUTH_MAIL_FROM")
+	_ = viper.BindEnv("auth_mail_smtp_host", "AUTH_MAIL_SMTP_HOST")
+	_ = viper.BindEnv("auth_mail_smtp_port", "AUTH_MAIL_SMTP_PORT")
+	_ = viper.BindEnv("auth_mail_smtp_user", "AUTH_MAIL_SMTP_USER")
+	_ = viper.BindEnv("auth_mail_smtp_password", "AUTH_MAIL_SMTP_PASSWORD")
+	_ = viper.BindEnv("auth_mail_dir", "AUTH_MAIL_DIR")
+
+	_ = viper.BindEnv("auth_verify_email_ttl", "AUTH_VERIFY_EMAIL_TTL")
+	_ = viper.BindEnv("auth_verify_email_url", "AUTH_VERIFY_EMAIL_URL")
+	_ = viper.BindEnv("auth_verify_email_resend_interval", "AUTH_VERIFY_EMAIL_RESEND_INTERVAL")
+	_ = viper.BindEnv("auth_verify_email_unverified_policy", "AUTH_VERIFY_EMAIL_UNVERIFIED_POLICY")
+
+	_ = viper.BindEnv("auth_mfa_issuer", "AUTH_MFA_ISSUER")
+	_ = viper.BindEnv("auth_mfa_challenge_ttl", "AUTH_MFA_CHALLENGE_TTL")
+	_ = viper.BindEnv("auth_mfa_recovery_codes", "AUTH_MFA_RECOVERY_CODES")
+
+	_ = viper.BindEnv("auth_api_key_token_ttl", "AUTH_API_KEY_TOKEN_TTL")
+	_ = viper.BindEnv("auth_api_key_max_per_user", "AUTH_API_KEY_MAX_PER_USER")
+
+	_ = viper.BindEnv("auth_throttle_backend", "AUTH_THROTTLE_BACKEND")
+	_ = viper.BindEnv("auth_throttle_account_threshold", "AUTH_THROTTLE_ACCOUNT_THRESHOLD")
+	_ = viper.BindEnv("auth_throttle_ip_threshold", "AUTH_THROTTLE_IP_THRESHOLD")
+	_ = viper.BindEnv("auth_throttle_base_lockout", "AUTH_THROTTLE_BASE_LOCKOUT")
+	_ = viper.BindEnv("auth_throttle_max_lockout", "AUTH_THROTTLE_MAX_LOCKOUT")
+	_ = viper.BindEnv("auth_throttle_window", "AUTH_THROTTLE_WINDOW")
+
+	_ = viper.BindEnv("auth_redis_host", "AUTH_REDIS_HOST")
+	_ = viper.BindEnv("auth_redis_password", "AUTH_REDIS_PASSWORD")
+	_ = viper.BindEnv("auth_redis_db", "AUTH_REDIS_DB")
+
+	_ = viper.BindEnv("auth_audit_retention", "AUTH_AUDIT_RETENTION")
+	_ = viper.BindEnv("auth_audit_purge_interval", "AUTH_AUDIT_PURGE_INTERVAL")
 
 	viper.AutomaticEnv()
 
@@ -77,8 +291,129 @@ func LoadConfig() (*Config, error) {
 	if cfg.Database.MaxOpenConns < 1 || cfg.Database.MaxIdleConns < 1 {
 		return nil, fmt.Errorf("AUTH_DB_MAX_OPEN_CONNS and AUTH_DB_MAX_IDLE_CONNS must be >= 1")
 	}
-	if cfg.JWT.PrivateKeyPath == "" || cfg.JWT.PublicKeyPath == "" || cfg.JWT.KeyID == "" {
-		return nil, fmt.Errorf("AUTH_JWT_PRIVATE_KEY, AUTH_JWT_PUBLIC_KEY and AUTH_JWT_KID must be set")
+	if cfg.JWT.KeysDir == "" && (cfg.JWT.PrivateKeyPath == "" || cfg.JWT.PublicKeyPath == "" || cfg.JWT.KeyID == "") {
+		return nil, fmt.Errorf("either AUTH_JWT_KEYS_DIR or AUTH_JWT_PRIVATE_KEY, AUTH_JWT_PUBLIC_KEY and AUTH_JWT_KID must be set")
+	}
+	if cfg.Server.GRPCPort == "" {
+		cfg.Server.GRPCPort = ":9090"
+	}
+	if cfg.JWT.CleanupInterval <= 0 {
+		cfg.JWT.CleanupInterval = time.Hour
+	}
+	if cfg.JWT.Issuer == "" {
+		cfg.JWT.Issuer = "http://localhost:8080"
+	}
+	cfg.JWT.Issuer = strings.TrimSuffix(cfg.JWT.Issuer, "/")
+	if cfg.JWT.Audience == "" {
+		cfg.JWT.Audience = "e-commerce"
+	}
+	if cfg.OAuth.ClientSecretGrace <= 0 {
+		cfg.OAuth.ClientSecretGrace = 24 * time.Hour
+	}
+	if cfg.OAuth.CodeTTL <= 0 {
+		cfg.OAuth.CodeTTL = time.Minute
+	}
+	if cfg.OAuth.ExchangeTokenTTL <= 0 {
+		cfg.OAuth.ExchangeTokenTTL = 5 * time.Minute
+	}
+	if cfg.Password.ResetTTL <= 0 {
+		cfg.Password.ResetTTL = time.Hour
+	}
+	if cfg.Password.ResetURL == "" {
+		cfg.Password.ResetURL = "http://localhost:3000/reset-password"
+	}
+	if cfg.Password.MinLength <= 0 {
+		cfg.Password.MinLength = 8
+	}
+	if cfg.Password.MaxLength <= 0 {
+		cfg.Password.MaxLength = 128
+	}
+	if cfg.Password.MaxLength < cfg.Password.MinLength {
+		return nil, fmt.Errorf("AUTH_PASSWORD_MAX_LENGTH must not be less than AUTH_PASSWORD_MIN_LENGTH")
+	}
+	if cfg.Password.MinClasses < 0 || cfg.Password.MinClasses > 4 {
+		return nil, fmt.Errorf("AUTH_PASSWORD_MIN_CLASSES must be between 0 and 4")
+	}
+	if cfg.Password.History < 0 {
+		return nil, fmt.Errorf("AUTH_PASSWORD_HISTORY must be >= 0")
+	}
+	if cfg.Password.BreachedDir != "" {
+		if fi, err := os.Stat(cfg.Password.BreachedDir); err != nil || !fi.IsDir() {
+			return nil, fmt.Errorf("AUTH_PASSWORD_BREACHED_DIR must be a directory")
+		}
+	}
+	if cfg.Hash.Algorithm != "" && cfg.Hash.Algorithm != "argon2id" && cfg.Hash.Algorithm != "bcrypt" {
+		return nil, fmt.Errorf("AUTH_PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt")
+	}
+	if cfg.Hash.BcryptCost != 0 && (cfg.Hash.BcryptCost < 4 || cfg.Hash.BcryptCost > 31) {
+		return nil, fmt.Errorf("AUTH_PASSWORD_BCRYPT_COST must be between 4 and 31")
+	}
+	if cfg.Mail.Driver == "" {
+		cfg.Mail.Driver = "log"
+	}
+	if cfg.Mail.Driver == "smtp" && (cfg.Mail.SMTPHost == "" || cfg.Mail.From == "") {
+		return nil, fmt.Errorf("AUTH_MAIL_SMTP_HOST and AUTH_MAIL_FROM must be set for the smtp mail driver")
+	}
+	if cfg.Verify.TTL <= 0 {
+		cfg.Verify.TTL = 24 * time.Hour
+	}
+	if cfg.Verify.URL == "" {
+		cfg.Verify.URL = "http://localhost:3000/verify-email"
+	}
+	if cfg.Verify.ResendInterval <= 0 {
+		cfg.Verify.ResendInterval = time.Minute
+	}
+	switch cfg.Verify.UnverifiedPolicy {
+	case "":
+		cfg.Verify.UnverifiedPolicy = UnverifiedAllow
+	case UnverifiedAllow, UnverifiedLimited, UnverifiedDeny:
+	default:
+		return nil, fmt.Errorf("AUTH_VERIFY_EMAIL_UNVERIFIED_POLICY must be one of allow, limited, deny")
+	}
+	if cfg.MFA.Issuer == "" {
+		cfg.MFA.Issuer = "e-commerce"
+	}
+	if cfg.MFA.ChallengeTTL <= 0 {
+		cfg.MFA.ChallengeTTL = 5 * time.Minute
+	}
+	if cfg.MFA.RecoveryCodes <= 0 {
+		cfg.MFA.RecoveryCodes = 10
+	}
+	if cfg.APIKey.TokenTTL <= 0 {
+		cfg.APIKey.TokenTTL = 15 * time.Minute
+	}
+	if cfg.APIKey.MaxPerUser <= 0 {
+		cfg.APIKey.MaxPerUser = 10
+	}
+	if cfg.Throttle.Backend == "" {
+		cfg.Throttle.Backend = "postgres"
+	}
+	if cfg.Throttle.Backend != "postgres" && cfg.Throttle.Backend != "redis" {
+		return nil, fmt.Errorf("AUTH_THROTTLE_BACKEND must be postgres or redis")
+	}
+	if cfg.Throttle.Backend == "redis" && cfg.Redis.Host == "" {
+		return nil, fmt.Errorf("AUTH_REDIS_HOST must be set for the redis throttle backend")
+	}
+	if cfg.Throttle.AccountThreshold <= 0 {
+		cfg.Throttle.AccountThreshold = 5
+	}
+	if cfg.Throttle.IPThreshold <= 0 {
+		cfg.Throttle.IPThreshold = 20
+	}
+	if cfg.Throttle.BaseLockout <= 0 {
+		cfg.Throttle.BaseLockout = 30 * time.Second
+	}
+	if cfg.Throttle.MaxLockout <= 0 {
+		cfg.Throttle.MaxLockout = time.Hour
+	}
+	if cfg.Throttle.Window <= 0 {
+		cfg.Throttle.Window = 15 * time.Minute
+	}
+	if cfg.Audit.Retention <= 0 {
+		cfg.Audit.Retention = 365 * 24 * time.Hour
+	}
+	if cfg.Audit.PurgeInterval <= 0 {
+		cfg.Audit.PurgeInterval = 24 * time.Hour
 	}
 	return &cfg, nil
 }
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id    SMALLINT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission TEXT     NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE IF NOT EXISTS role_changes (
+    id           BIGSERIAL   PRIMARY KEY,
+    actor        TEXT        NOT NULL,
+    action       TEXT        NOT NULL,
+    role_name    TEXT        NOT NULL,
+    subject_type TEXT        NOT NULL DEFAULT '',
+    subject_id   TEXT        NOT NULL DEFAULT '',
+    permission   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_role_changes_created_at ON role_changes(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS role_changes;
+DROP TABLE IF EXISTS role_permissions;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS login_failures (
+    key          TEXT PRIMARY KEY,
+    failures     INTEGER     NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_expires_at ON login_failures (expires_at);
+
+CREATE TABLE IF NOT EXISTS lockout_events (
+    id           BIGSERIAL PRIMARY KEY,
+    kind         TEXT        NOT NULL,
+    subject      TEXT        NOT NULL,
+    action       TEXT        NOT NULL,
+    actor        TEXT,
+    ip           TEXT,
+    failures     INTEGER     NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lockout_events_created_at ON lockout_events (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS lockout_events;
+DROP TABLE IF EXISTS login_failures;
+-- +goose StatementEnd
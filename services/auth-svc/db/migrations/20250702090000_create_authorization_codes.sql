@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS redirect_uris TEXT[]  NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS public        BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS authorization_codes (
+    code_hash             TEXT        PRIMARY KEY,
+    client_id             TEXT        NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    user_id               UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id            UUID        NOT NULL,
+    redirect_uri          TEXT        NOT NULL DEFAULT '',
+    scope                 TEXT        NOT NULL DEFAULT '',
+    code_challenge        TEXT        NOT NULL DEFAULT '',
+    code_challenge_method TEXT        NOT NULL DEFAULT '',
+    nonce                 TEXT        NOT NULL DEFAULT '',
+    amr                   TEXT[]      NOT NULL DEFAULT '{}',
+    expires_at            TIMESTAMPTZ NOT NULL,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    used_at               TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_authorization_codes_expires_at ON authorization_codes(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS authorization_codes;
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS public,
+    DROP COLUMN IF EXISTS redirect_uris;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (type, outcome, actor, subject, ip, user_agent, details)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListAuditEvents :many
+-- ListAuditEvents returns the events matching the filters, newest first.
+-- Events with an id of before_id or above are skipped, so the id of the
+-- last event of a page is the cursor of the next one.
+SELECT * FROM audit_events
+WHERE (sqlc.narg('before_id')::BIGINT IS NULL OR id < sqlc.narg('before_id'))
+  AND (sqlc.narg('type')::TEXT IS NULL OR type = sqlc.narg('type'))
+  AND (sqlc.narg('outcome')::TEXT IS NULL OR outcome = sqlc.narg('outcome'))
+  AND (sqlc.narg('actor')::TEXT IS NULL OR actor = sqlc.narg('actor'))
+  AND (sqlc.narg('subject')::TEXT IS NULL OR subject = sqlc.narg('subject'))
+  AND (sqlc.narg('ip')::TEXT IS NULL OR ip = sqlc.narg('ip'))
+  AND (sqlc.narg('since')::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg('until'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteAuditEventsBefore :execrows
+DELETE FROM audit_events
+WHERE created_at < $1;
@@ -0,0 +1,21 @@
+-- name: CreateAuthorizationCode :exec
+INSERT INTO authorization_codes (
+    code_hash, client_id, user_id, session_id, redirect_uri, scope,
+    code_challenge, code_challenge_method, nonce, amr, expires_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: GetAuthorizationCode :one
+SELECT * FROM authorization_codes
+WHERE code_hash = $1;
+
+-- name: UseAuthorizationCode :execrows
+UPDATE authorization_codes
+SET used_at = now()
+WHERE code_hash = $1
+  AND used_at IS NULL;
+
+-- name: DeleteExpiredAuthorizationCodes :execrows
+-- Used codes are kept until they expire so that a replay is recognised.
+DELETE FROM authorization_codes
+WHERE expires_at < now();
@@ -3,5 +3,71 @@ SELECT c.id,
        c.secret_hash,
        c.roles,
        c.status,
-       c.created_at
-FROM clients c WHERE id = $1;
\ No newline at end of file
+       c.created_at,
+       c.allowed_scopes,
+       c.grant_types,
+       c.previous_secret_hash,
+       c.previous_secret_expires_at,
+       c.redirect_uris,
+       c.public,
+       c.exchange_audiences,
+       ARRAY(
+           SELECT DISTINCT rp.permission
+           FROM roles r
+           JOIN role_permissions rp ON rp.role_id = r.id
+           WHERE r.name = ANY(c.roles)
+           ORDER BY rp.permission
+       )::TEXT[] AS permissions
+FROM clients c WHERE id = $1;
+
+-- name: CreateClient :one
+INSERT INTO clients (id, secret_hash, roles, allowed_scopes, grant_types, redirect_uris, public, exchange_audiences)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
+
+-- name: ListClients :many
+SELECT * FROM clients
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListExchangeAudiences :many
+SELECT DISTINCT unnest(exchange_audiences)::TEXT AS audience
+FROM clients
+WHERE status = 1;
+
+-- name: UpdateClientStatus :execrows
+UPDATE clients
+SET status = $2
+WHERE id = $1;
+
+-- name: UpdateClientRoles :execrows
+UPDATE clients
+SET roles = $2
+WHERE id = $1;
+
+-- name: UpdateClientRedirectURIs :execrows
+UPDATE clients
+SET redirect_uris = $2
+WHERE id = $1;
+
+-- name: UpdateClientExchangeAudiences :execrows
+UPDATE clients
+SET exchange_audiences = $2
+WHERE id = $1;
+
+-- name: RotateClientSecret :execrows
+UPDATE clients
+SET previous_secret_hash       = secret_hash,
+    previous_secret_expires_at = $3,
+    secret_hash                = $2
+WHERE id = $1;
+
+-- name: RehashClientSecret :execrows
+UPDATE clients
+SET secret_hash = sqlc.arg('new_hash')
+WHERE id = sqlc.arg('id') AND secret_hash = sqlc.arg('old_hash');
+
+-- name: DeleteClient :execrows
+DELETE FROM clients
+WHERE id = $1;
\ No newline at end of file
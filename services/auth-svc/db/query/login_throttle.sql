@@ -0,0 +1,35 @@
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE key = $1 AND expires_at > now();
+
+-- name: AddLoginFailure :one
+INSERT INTO login_failures (key, failures, expires_at)
+VALUES ($1, 1, $2)
+ON CONFLICT (key) DO UPDATE
+SET failures     = CASE WHEN login_failures.expires_at > now() THEN login_failures.failures + 1 ELSE 1 END,
+    locked_until = CASE WHEN login_failures.expires_at > now() THEN login_failures.locked_until END,
+    expires_at   = GREATEST(EXCLUDED.expires_at, login_failures.locked_until)
+RETURNING failures;
+
+-- name: LockLoginFailure :exec
+UPDATE login_failures
+SET locked_until = $2,
+    expires_at   = GREATEST(expires_at, $2)
+WHERE key = $1;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE key = $1;
+
+-- name: DeleteExpiredLoginFailures :execrows
+DELETE FROM login_failures
+WHERE expires_at <= now();
+
+-- name: CreateLockoutEvent :exec
+INSERT INTO lockout_events (kind, subject, action, actor, ip, failures, locked_until)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListLockoutEvents :many
+SELECT * FROM lockout_events
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2;
@@ -0,0 +1,22 @@
+-- name: AddPasswordHistory :exec
+INSERT INTO password_history (user_id, password_hash)
+VALUES ($1, $2);
+
+-- name: ListPasswordHistory :many
+SELECT password_hash
+FROM password_history
+WHERE user_id = $1
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: TrimPasswordHistory :exec
+-- Keeps the newest keep hashes of the user.
+DELETE FROM password_history
+WHERE user_id = sqlc.arg('user_id')
+  AND id NOT IN (
+    SELECT id
+    FROM password_history
+    WHERE user_id = sqlc.arg('user_id')
+    ORDER BY id DESC
+    LIMIT sqlc.arg('keep')
+  );
@@ -0,0 +1,21 @@
+-- name: RevokeTokenID :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS(
+    SELECT 1 FROM revoked_tokens
+    WHERE jti = $1
+) AS revoked;
+
+-- name: ListRevokedTokens :many
+SELECT * FROM revoked_tokens
+WHERE expires_at > now()
+  AND revoked_at >= $1
+ORDER BY revoked_at, jti
+LIMIT $2 OFFSET $3;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at < now();
@@ -0,0 +1,77 @@
+-- name: ListRolesWithPermissions :many
+SELECT
+    r.id,
+    r.name,
+    ARRAY_REMOVE(ARRAY_AGG(rp.permission ORDER BY rp.permission), NULL)::TEXT[] AS permissions,
+    EXISTS (SELECT 1 FROM mfa_required_roles m WHERE m.role_id = r.id) AS mfa_required
+FROM roles r
+LEFT JOIN role_permissions rp ON rp.role_id = r.id
+GROUP BY r.id
+ORDER BY r.name;
+
+-- name: GetRoleWithPermissions :one
+SELECT
+    r.id,
+    r.name,
+    ARRAY_REMOVE(ARRAY_AGG(rp.permission ORDER BY rp.permission), NULL)::TEXT[] AS permissions,
+    EXISTS (SELECT 1 FROM mfa_required_roles m WHERE m.role_id = r.id) AS mfa_required
+FROM roles r
+LEFT JOIN role_permissions rp ON rp.role_id = r.id
+WHERE r.name = $1
+GROUP BY r.id;
+
+-- name: CreateRole :one
+INSERT INTO roles (name)
+VALUES ($1)
+ON CONFLICT (name) DO NOTHING
+RETURNING *;
+
+-- name: DeleteRole :execrows
+DELETE FROM roles
+WHERE id = $1;
+
+-- name: AddRolePermission :exec
+INSERT INTO role_permissions (role_id, permission)
+VALUES ($1, $2)
+ON CONFLICT (role_id, permission) DO NOTHING;
+
+-- name: RemoveRolePermission :execrows
+DELETE FROM role_permissions
+WHERE role_id = $1 AND permission = $2;
+
+-- name: DeleteUserRole :execrows
+DELETE FROM user_roles
+WHERE user_id = $1 AND role_id = $2;
+
+-- name: AddClientRole :execrows
+UPDATE clients
+SET roles = array_append(array_remove(roles, sqlc.arg('role')::TEXT), sqlc.arg('role')::TEXT)
+WHERE id = sqlc.arg('id');
+
+-- name: RemoveClientRole :execrows
+UPDATE clients
+SET roles = array_remove(roles, sqlc.arg('role')::TEXT)
+WHERE id = sqlc.arg('id') AND sqlc.arg('role')::TEXT = ANY(roles);
+
+-- name: RemoveRoleFromClients :exec
+UPDATE clients
+SET roles = array_remove(roles, sqlc.arg('role')::TEXT)
+WHERE sqlc.arg('role')::TEXT = ANY(roles);
+
+-- name: CreateRoleChange :exec
+INSERT INTO role_changes (actor, action, role_name, subject_type, subject_id, permission)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListRoleChanges :many
+SELECT * FROM role_changes
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: RequireRoleMFA :exec
+INSERT INTO mfa_required_roles (role_id)
+VALUES ($1)
+ON CONFLICT (role_id) DO NOTHING;
+
+-- name: WaiveRoleMFA :execrows
+DELETE FROM mfa_required_roles
+WHERE role_id = $1;
@@ -0,0 +1,34 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (id, user_id, user_agent, ip)
+VALUES ($1, $2, $3, $4);
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_refreshed_at = now(),
+    user_agent = $2,
+    ip = $3
+WHERE id = $1;
+
+-- name: ListUserSessions :many
+SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_refreshed_at, t.expires_at
+FROM sessions s
+JOIN refresh_tokens t ON t.family_id = s.id
+WHERE s.user_id = $1
+  AND t.rotated_at IS NULL
+  AND t.revoked_at IS NULL
+  AND t.expires_at > now()
+ORDER BY s.last_refreshed_at DESC;
+
+-- name: RevokeUserSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE family_id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL;
+
+-- name: DeleteEndedSessions :execrows
+DELETE FROM sessions s
+WHERE NOT EXISTS (
+    SELECT 1 FROM refresh_tokens t
+    WHERE t.family_id = s.id
+);
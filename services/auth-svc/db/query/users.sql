@@ -32,9 +32,138 @@ SELECT
     u.status,
     u.created_at,
     u.updated_at,
-    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles,
+    ARRAY(
+        SELECT DISTINCT rp.permission
+        FROM user_roles pur
+        JOIN role_permissions rp ON rp.role_id = pur.role_id
+        WHERE pur.user_id = u.id
+        ORDER BY rp.permission
+    )::TEXT[] AS permissions,
+    EXISTS (
+        SELECT 1 FROM user_totp t
+        WHERE t.user_id = u.id AND t.confirmed_at IS NOT NULL
+    ) AS mfa_enabled,
+    EXISTS (
+        SELECT 1
+        FROM user_roles mur
+        JOIN mfa_required_roles mr ON mr.role_id = mur.role_id
+        WHERE mur.user_id = u.id
+    ) AS mfa_required
 FROM users u
 LEFT JOIN user_roles ur ON ur.user_id = u.id
 LEFT JOIN roles r ON r.id = ur.role_id
 WHERE u.email = $1
-GROUP BY u.id;
\ No newline at end of file
+GROUP BY u.id;
+
+-- name: GetUserByID :one
+SELECT
+    u.id,
+    u.email,
+    u.password_hash,
+    u.status,
+    u.created_at,
+    u.updated_at,
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles,
+    ARRAY(
+        SELECT DISTINCT rp.permission
+        FROM user_roles pur
+        JOIN role_permissions rp ON rp.role_id = pur.role_id
+        WHERE pur.user_id = u.id
+        ORDER BY rp.permission
+    )::TEXT[] AS permissions,
+    EXISTS (
+        SELECT 1 FROM user_totp t
+        WHERE t.user_id = u.id AND t.confirmed_at IS NOT NULL
+    ) AS mfa_enabled,
+    EXISTS (
+        SELECT 1
+        FROM user_roles mur
+        JOIN mfa_required_roles mr ON mr.role_id = mur.role_id
+        WHERE mur.user_id = u.id
+    ) AS mfa_required
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN roles r ON r.id = ur.role_id
+WHERE u.id = $1
+GROUP BY u.id;
+
+-- name: GetUsersByIDs :many
+SELECT
+    u.id,
+    u.email,
+    u.password_hash,
+    u.status,
+    u.created_at,
+    u.updated_at,
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN roles r ON r.id = ur.role_id
+WHERE u.id = ANY(sqlc.arg('ids')::UUID[])
+GROUP BY u.id;
+
+-- name: SearchUsers :many
+SELECT
+    u.id,
+    u.email,
+    u.password_hash,
+    u.status,
+    u.created_at,
+    u.updated_at,
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN roles r ON r.id = ur.role_id
+WHERE (sqlc.narg('email_prefix')::TEXT IS NULL OR starts_with(lower(u.email), lower(sqlc.narg('email_prefix'))))
+  AND (sqlc.narg('status')::SMALLINT IS NULL OR u.status = sqlc.narg('status'))
+  AND (sqlc.narg('created_after')::TIMESTAMPTZ IS NULL OR u.created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::TIMESTAMPTZ IS NULL OR u.created_at < sqlc.narg('created_before'))
+GROUP BY u.id
+HAVING sqlc.narg('role')::TEXT IS NULL OR sqlc.narg('role') = ANY(ARRAY_AGG(r.name))
+ORDER BY u.created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: UpdateUserStatus :execrows
+UPDATE users
+SET status = $2
+WHERE id = $1;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = $1;
+
+-- name: UpdateUserPassword :execrows
+UPDATE users
+SET password_hash = $2
+WHERE id = $1;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET password_hash = sqlc.arg('new_hash')
+WHERE id = sqlc.arg('id') AND password_hash = sqlc.arg('old_hash');
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1 AND email = $2;
+
+-- name: MarkVerificationSent :execrows
+UPDATE users
+SET verification_sent_at = now()
+WHERE id = $1
+  AND email_verified_at IS NULL
+  AND (verification_sent_at IS NULL OR verification_sent_at < $2);
+
+-- name: ChangeUserEmail :execrows
+-- The new address counts as verified: it is only changed once the user has
+-- followed a link sent to it.
+UPDATE users
+SET email                = sqlc.arg('new_email'),
+    email_verified_at    = now(),
+    verification_sent_at = NULL
+WHERE id = sqlc.arg('id') AND email = sqlc.arg('old_email');
@@ -7,26 +7,313 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createClient = `-- name: CreateClient :one
+INSERT INTO clients (id, secret_hash, roles, allowed_scopes, grant_types, redirect_uris, public, exchange_audiences)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (id) DO NOTHING
+RETURNING id, secret_hash, roles, status, created_at, allowed_scopes, grant_types, previous_secret_hash, previous_secret_expires_at, redirect_uris, public, exchange_audiences
+`
+
+type CreateClientParams struct {
+	ID                string   `json:"id"`
+	SecretHash        string   `json:"secret_hash"`
+	Roles             []string `json:"roles"`
+	AllowedScopes     []string `json:"allowed_scopes"`
+	GrantTypes        []string `json:"grant_types"`
+	RedirectUris      []string `json:"redirect_uris"`
+	Public            bool     `json:"public"`
+	ExchangeAudiences []string `json:"exchange_audiences"`
+}
+
+func (q *Queries) CreateClient(ctx context.Context, arg CreateClientParams) (Client, error) {
+	row := q.db.QueryRow(ctx, createClient,
+		arg.ID,
+		arg.SecretHash,
+		arg.Roles,
+		arg.AllowedScopes,
+		arg.GrantTypes,
+		arg.RedirectUris,
+		arg.Public,
+		arg.ExchangeAudiences,
+	)
+	var i Client
+	err := row.Scan(
+		&i.ID,
+		&i.SecretHash,
+		&i.Roles,
+		&i.Status,
+		&i.CreatedAt,
+		&i.AllowedScopes,
+		&i.GrantTypes,
+		&i.PreviousSecretHash,
+		&i.PreviousSecretExpiresAt,
+		&i.RedirectUris,
+		&i.Public,
+		&i.ExchangeAudiences,
+	)
+	return i, err
+}
+
+const deleteClient = `-- name: DeleteClient :execrows
+DELETE FROM clients
+WHERE id = $1
+`
+
+func (q *Queries) DeleteClient(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteClient, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getById = `-- name: GetById :one
 SELECT c.id,
        c.secret_hash,
        c.roles,
        c.status,
-       c.created_at
+       c.created_at,
+       c.allowed_scopes,
+       c.grant_types,
+       c.previous_secret_hash,
+       c.previous_secret_expires_at,
+       c.redirect_uris,
+       c.public,
+       c.exchange_audiences,
+       ARRAY(
+           SELECT DISTINCT rp.permission
+           FROM roles r
+           JOIN role_permissions rp ON rp.role_id = r.id
+           WHERE r.name = ANY(c.roles)
+           ORDER BY rp.permission
+       )::TEXT[] AS permissions
 FROM clients c WHERE id = $1
 `
 
-func (q *Queries) GetById(ctx context.Context, id string) (Client, error) {
+type GetByIdRow struct {
+	ID                      string             `json:"id"`
+	SecretHash              string             `json:"secret_hash"`
+	Roles                   []string           `json:"roles"`
+	Status                  int16              `json:"status"`
+	CreatedAt               pgtype.Timestamptz `json:"created_at"`
+	AllowedScopes           []string           `json:"allowed_scopes"`
+	GrantTypes              []string           `json:"grant_types"`
+	PreviousSecretHash      pgtype.Text        `json:"previous_secret_hash"`
+	PreviousSecretExpiresAt pgtype.Timestamptz `json:"previous_secret_expires_at"`
+	RedirectUris            []string           `json:"redirect_uris"`
+	Public                  bool               `json:"public"`
+	ExchangeAudiences       []string           `json:"exchange_audiences"`
+	Permissions             []string           `json:"permissions"`
+}
+
+func (q *Queries) GetById(ctx context.Context, id string) (GetByIdRow, error) {
 	row := q.db.QueryRow(ctx, getById, id)
-	var i Client
+	var i GetByIdRow
 	err := row.Scan(
 		&i.ID,
 		&i.SecretHash,
 		&i.Roles,
 		&i.Status,
 		&i.CreatedAt,
+		&i.AllowedScopes,
+		&i.GrantTypes,
+		&i.PreviousSecretHash,
+		&i.PreviousSecretExpiresAt,
+		&i.RedirectUris,
+		&i.Public,
+		&i.ExchangeAudiences,
+		&i.Permissions,
 	)
 	return i, err
 }
+
+const listClients = `-- name: ListClients :many
+SELECT id, secret_hash, roles, status, created_at, allowed_scopes, grant_types, previous_secret_hash, previous_secret_expires_at, redirect_uris, public, exchange_audiences FROM clients
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListClientsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListClients(ctx context.Context, arg ListClientsParams) ([]Client, error) {
+	rows, err := q.db.Query(ctx, listClients, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Client
+	for rows.Next() {
+		var i Client
+		if err := rows.Scan(
+			&i.ID,
+			&i.SecretHash,
+			&i.Roles,
+			&i.Status,
+			&i.CreatedAt,
+			&i.AllowedScopes,
+			&i.GrantTypes,
+			&i.PreviousSecretHash,
+			&i.PreviousSecretExpiresAt,
+			&i.RedirectUris,
+			&i.Public,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExchangeAudiences = `-- name: ListExchangeAudiences :many
+SELECT DISTINCT unnest(exchange_audiences)::TEXT AS audience
+FROM clients
+WHERE status = 1
+`
+
+func (q *Queries) ListExchangeAudiences(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listExchangeAudiences)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var audience string
+		if err := rows.Scan(&audience); err != nil {
+			return nil, err
+		}
+		items = append(items, audience)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rehashClientSecret = `-- name: RehashClientSecret :execrows
+UPDATE clients
+SET secret_hash = $1
+WHERE id = $2 AND secret_hash = $3
+`
+
+type RehashClientSecretParams struct {
+	NewHash string `json:"new_hash"`
+	ID      string `json:"id"`
+	OldHash string `json:"old_hash"`
+}
+
+func (q *Queries) RehashClientSecret(ctx context.Context, arg RehashClientSecretParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rehashClientSecret, arg.NewHash, arg.ID, arg.OldHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rotateClientSecret = `-- name: RotateClientSecret :execrows
+UPDATE clients
+SET previous_secret_hash       = secret_hash,
+    previous_secret_expires_at = $3,
+    secret_hash                = $2
+WHERE id = $1
+`
+
+type RotateClientSecretParams struct {
+	ID                      string             `json:"id"`
+	SecretHash              string             `json:"secret_hash"`
+	PreviousSecretExpiresAt pgtype.Timestamptz `json:"previous_secret_expires_at"`
+}
+
+func (q *Queries) RotateClientSecret(ctx context.Context, arg RotateClientSecretParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rotateClientSecret, arg.ID, arg.SecretHash, arg.PreviousSecretExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateClientExchangeAudiences = `-- name: UpdateClientExchangeAudiences :execrows
+UPDATE clients
+SET exchange_audiences = $2
+WHERE id = $1
+`
+
+type UpdateClientExchangeAudiencesParams struct {
+	ID                string   `json:"id"`
+	ExchangeAudiences []string `json:"exchange_audiences"`
+}
+
+func (q *Queries) UpdateClientExchangeAudiences(ctx context.Context, arg UpdateClientExchangeAudiencesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateClientExchangeAudiences, arg.ID, arg.ExchangeAudiences)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateClientRedirectURIs = `-- name: UpdateClientRedirectURIs :execrows
+UPDATE clients
+SET redirect_uris = $2
+WHERE id = $1
+`
+
+type UpdateClientRedirectURIsParams struct {
+	ID           string   `json:"id"`
+	RedirectUris []string `json:"redirect_uris"`
+}
+
+func (q *Queries) UpdateClientRedirectURIs(ctx context.Context, arg UpdateClientRedirectURIsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateClientRedirectURIs, arg.ID, arg.RedirectUris)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateClientRoles = `-- name: UpdateClientRoles :execrows
+UPDATE clients
+SET roles = $2
+WHERE id = $1
+`
+
+type UpdateClientRolesParams struct {
+	ID    string   `json:"id"`
+	Roles []string `json:"roles"`
+}
+
+func (q *Queries) UpdateClientRoles(ctx context.Context, arg UpdateClientRolesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateClientRoles, arg.ID, arg.Roles)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateClientStatus = `-- name: UpdateClientStatus :execrows
+UPDATE clients
+SET status = $2
+WHERE id = $1
+`
+
+type UpdateClientStatusParams struct {
+	ID     string `json:"id"`
+	Status int16  `json:"status"`
+}
+
+func (q *Queries) UpdateClientStatus(ctx context.Context, arg UpdateClientStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateClientStatus, arg.ID, arg.Status)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
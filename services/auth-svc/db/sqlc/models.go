@@ -8,12 +8,121 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Client struct {
-	ID         string             `json:"id"`
+type ApiKey struct {
+	ID         pgtype.UUID        `json:"id"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
 	SecretHash string             `json:"secret_hash"`
-	Roles      []string           `json:"roles"`
-	Status     int16              `json:"status"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type AuditEvent struct {
+	ID        int64              `json:"id"`
+	Type      string             `json:"type"`
+	Outcome   string             `json:"outcome"`
+	Actor     pgtype.Text        `json:"actor"`
+	Subject   pgtype.Text        `json:"subject"`
+	Ip        pgtype.Text        `json:"ip"`
+	UserAgent pgtype.Text        `json:"user_agent"`
+	Details   []byte             `json:"details"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type AuthorizationCode struct {
+	CodeHash            string             `json:"code_hash"`
+	ClientID            string             `json:"client_id"`
+	UserID              pgtype.UUID        `json:"user_id"`
+	SessionID           pgtype.UUID        `json:"session_id"`
+	RedirectUri         string             `json:"redirect_uri"`
+	Scope               string             `json:"scope"`
+	CodeChallenge       string             `json:"code_challenge"`
+	CodeChallengeMethod string             `json:"code_challenge_method"`
+	Nonce               string             `json:"nonce"`
+	Amr                 []string           `json:"amr"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UsedAt              pgtype.Timestamptz `json:"used_at"`
+}
+
+type Client struct {
+	ID                      string             `json:"id"`
+	SecretHash              string             `json:"secret_hash"`
+	Roles                   []string           `json:"roles"`
+	Status                  int16              `json:"status"`
+	CreatedAt               pgtype.Timestamptz `json:"created_at"`
+	AllowedScopes           []string           `json:"allowed_scopes"`
+	GrantTypes              []string           `json:"grant_types"`
+	PreviousSecretHash      pgtype.Text        `json:"previous_secret_hash"`
+	PreviousSecretExpiresAt pgtype.Timestamptz `json:"previous_secret_expires_at"`
+	RedirectUris            []string           `json:"redirect_uris"`
+	Public                  bool               `json:"public"`
+	ExchangeAudiences       []string           `json:"exchange_audiences"`
+}
+
+type LockoutEvent struct {
+	ID          int64              `json:"id"`
+	Kind        string             `json:"kind"`
+	Subject     string             `json:"subject"`
+	Action      string             `json:"action"`
+	Actor       pgtype.Text        `json:"actor"`
+	Ip          pgtype.Text        `json:"ip"`
+	Failures    int32              `json:"failures"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type LoginFailure struct {
+	Key         string             `json:"key"`
+	Failures    int32              `json:"failures"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+type MfaRecoveryCode struct {
+	CodeHash  string             `json:"code_hash"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+}
+
+type MfaRequiredRole struct {
+	RoleID int16 `json:"role_id"`
+}
+
+type PasswordHistory struct {
+	ID           int64              `json:"id"`
+	UserID       pgtype.UUID        `json:"user_id"`
+	PasswordHash string             `json:"password_hash"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	TokenHash string             `json:"token_hash"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+}
+
+type RefreshToken struct {
+	ID        pgtype.UUID        `json:"id"`
+	FamilyID  pgtype.UUID        `json:"family_id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	RotatedAt pgtype.Timestamptz `json:"rotated_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type RevokedToken struct {
+	Jti       string             `json:"jti"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
 }
 
 type Role struct {
@@ -21,16 +130,51 @@ type Role struct {
 	Name string `json:"name"`
 }
 
+type RoleChange struct {
+	ID          int64              `json:"id"`
+	Actor       string             `json:"actor"`
+	Action      string             `json:"action"`
+	RoleName    string             `json:"role_name"`
+	SubjectType string             `json:"subject_type"`
+	SubjectID   string             `json:"subject_id"`
+	Permission  string             `json:"permission"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID     int16  `json:"role_id"`
+	Permission string `json:"permission"`
+}
+
+type Session struct {
+	ID              pgtype.UUID        `json:"id"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	UserAgent       string             `json:"user_agent"`
+	Ip              string             `json:"ip"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	LastRefreshedAt pgtype.Timestamptz `json:"last_refreshed_at"`
+}
+
 type User struct {
-	ID           pgtype.UUID        `json:"id"`
-	Email        string             `json:"email"`
-	PasswordHash string             `json:"password_hash"`
-	Status       int16              `json:"status"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	ID                 pgtype.UUID        `json:"id"`
+	Email              string             `json:"email"`
+	PasswordHash       string             `json:"password_hash"`
+	Status             int16              `json:"status"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	EmailVerifiedAt    pgtype.Timestamptz `json:"email_verified_at"`
+	VerificationSentAt pgtype.Timestamptz `json:"verification_sent_at"`
 }
 
 type UserRole struct {
 	UserID pgtype.UUID `json:"user_id"`
 	RoleID int16       `json:"role_id"`
 }
+
+type UserTotp struct {
+	UserID       pgtype.UUID        `json:"user_id"`
+	Secret       string             `json:"secret"`
+	ConfirmedAt  pgtype.Timestamptz `json:"confirmed_at"`
+	LastUsedStep int64              `json:"last_used_step"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
@@ -6,16 +6,113 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AddClientRole(ctx context.Context, arg AddClientRoleParams) (int64, error)
+	AddLoginFailure(ctx context.Context, arg AddLoginFailureParams) (int32, error)
+	AddPasswordHistory(ctx context.Context, arg AddPasswordHistoryParams) error
+	AddRolePermission(ctx context.Context, arg AddRolePermissionParams) error
+	// The new address counts as verified: it is only changed once the user has
+	// followed a link sent to it.
+	ChangeUserEmail(ctx context.Context, arg ChangeUserEmailParams) (int64, error)
+	ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) (int64, error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (pgtype.UUID, error)
+	CountActiveUserAPIKeys(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CountRecoveryCodes(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) error
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error
+	CreateAuthorizationCode(ctx context.Context, arg CreateAuthorizationCodeParams) error
+	CreateClient(ctx context.Context, arg CreateClientParams) (Client, error)
+	CreateLockoutEvent(ctx context.Context, arg CreateLockoutEventParams) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error
+	CreateRole(ctx context.Context, name string) (Role, error)
+	CreateRoleChange(ctx context.Context, arg CreateRoleChangeParams) error
+	CreateSession(ctx context.Context, arg CreateSessionParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateUserIfNotExists(ctx context.Context, arg CreateUserIfNotExistsParams) (User, error)
 	CreateUserRole(ctx context.Context, arg CreateUserRoleParams) error
-	GetById(ctx context.Context, id string) (Client, error)
+	DeleteAuditEventsBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DeleteClient(ctx context.Context, id string) (int64, error)
+	DeleteEndedAPIKeys(ctx context.Context) (int64, error)
+	DeleteEndedSessions(ctx context.Context) (int64, error)
+	// Used codes are kept until they expire so that a replay is recognised.
+	DeleteExpiredAuthorizationCodes(ctx context.Context) (int64, error)
+	DeleteExpiredLoginFailures(ctx context.Context) (int64, error)
+	DeleteExpiredPasswordResetTokens(ctx context.Context) (int64, error)
+	DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	DeleteLoginFailure(ctx context.Context, key string) error
+	DeleteRecoveryCodes(ctx context.Context, userID pgtype.UUID) error
+	DeleteRole(ctx context.Context, id int16) (int64, error)
+	DeleteUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	DeleteUserRole(ctx context.Context, arg DeleteUserRoleParams) (int64, error)
+	DeleteUserTOTP(ctx context.Context, userID pgtype.UUID) (int64, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
+	GetAuthorizationCode(ctx context.Context, codeHash string) (AuthorizationCode, error)
+	GetById(ctx context.Context, id string) (GetByIdRow, error)
+	GetLoginFailure(ctx context.Context, key string) (LoginFailure, error)
+	GetPasswordResetTokenUser(ctx context.Context, tokenHash string) (pgtype.UUID, error)
+	GetRefreshToken(ctx context.Context, id pgtype.UUID) (RefreshToken, error)
 	GetRoleByName(ctx context.Context, name string) (Role, error)
+	GetRoleWithPermissions(ctx context.Context, name string) (GetRoleWithPermissionsRow, error)
 	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
+	GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error)
+	GetUserTOTP(ctx context.Context, userID pgtype.UUID) (UserTotp, error)
+	GetUsersByIDs(ctx context.Context, ids []pgtype.UUID) ([]GetUsersByIDsRow, error)
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID pgtype.UUID) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// ListAuditEvents returns the events matching the filters, newest first.
+	// Events with an id of before_id or above are skipped, so the id of the
+	// last event of a page is the cursor of the next one.
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListClients(ctx context.Context, arg ListClientsParams) ([]Client, error)
+	ListExchangeAudiences(ctx context.Context) ([]string, error)
+	ListLockoutEvents(ctx context.Context, arg ListLockoutEventsParams) ([]LockoutEvent, error)
+	ListPasswordHistory(ctx context.Context, arg ListPasswordHistoryParams) ([]string, error)
+	ListRevokedTokens(ctx context.Context, arg ListRevokedTokensParams) ([]RevokedToken, error)
+	ListRoleChanges(ctx context.Context, arg ListRoleChangesParams) ([]RoleChange, error)
+	ListRolesWithPermissions(ctx context.Context) ([]ListRolesWithPermissionsRow, error)
+	ListUserAPIKeys(ctx context.Context, userID pgtype.UUID) ([]ApiKey, error)
+	ListUserSessions(ctx context.Context, userID pgtype.UUID) ([]ListUserSessionsRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	LockLoginFailure(ctx context.Context, arg LockLoginFailureParams) error
+	MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error)
+	MarkVerificationSent(ctx context.Context, arg MarkVerificationSentParams) (int64, error)
+	RehashClientSecret(ctx context.Context, arg RehashClientSecretParams) (int64, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error)
+	RemoveClientRole(ctx context.Context, arg RemoveClientRoleParams) (int64, error)
+	RemoveRoleFromClients(ctx context.Context, role string) error
+	RemoveRolePermission(ctx context.Context, arg RemoveRolePermissionParams) (int64, error)
+	RequireRoleMFA(ctx context.Context, roleID int16) error
+	RevokeOtherUserRefreshTokens(ctx context.Context, arg RevokeOtherUserRefreshTokensParams) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID pgtype.UUID) error
+	RevokeTokenID(ctx context.Context, arg RevokeTokenIDParams) error
+	RevokeUserAPIKey(ctx context.Context, arg RevokeUserAPIKeyParams) (int64, error)
+	RevokeUserRefreshTokens(ctx context.Context, userID pgtype.UUID) error
+	RevokeUserSession(ctx context.Context, arg RevokeUserSessionParams) (int64, error)
+	RotateClientSecret(ctx context.Context, arg RotateClientSecretParams) (int64, error)
+	RotateRefreshToken(ctx context.Context, id pgtype.UUID) (int64, error)
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
+	StartUserTOTP(ctx context.Context, arg StartUserTOTPParams) (int64, error)
+	TouchAPIKey(ctx context.Context, id pgtype.UUID) error
+	TouchSession(ctx context.Context, arg TouchSessionParams) error
+	// Keeps the newest keep hashes of the user.
+	TrimPasswordHistory(ctx context.Context, arg TrimPasswordHistoryParams) error
+	UpdateClientExchangeAudiences(ctx context.Context, arg UpdateClientExchangeAudiencesParams) (int64, error)
+	UpdateClientRedirectURIs(ctx context.Context, arg UpdateClientRedirectURIsParams) (int64, error)
+	UpdateClientRoles(ctx context.Context, arg UpdateClientRolesParams) (int64, error)
+	UpdateClientStatus(ctx context.Context, arg UpdateClientStatusParams) (int64, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error)
+	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (int64, error)
+	UseAuthorizationCode(ctx context.Context, codeHash string) (int64, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
+	WaiveRoleMFA(ctx context.Context, roleID int16) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
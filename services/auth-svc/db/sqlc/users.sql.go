@@ -11,10 +11,34 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const changeUserEmail = `-- name: ChangeUserEmail :execrows
+UPDATE users
+SET email                = $1,
+    email_verified_at    = now(),
+    verification_sent_at = NULL
+WHERE id = $2 AND email = $3
+`
+
+type ChangeUserEmailParams struct {
+	NewEmail string      `json:"new_email"`
+	ID       pgtype.UUID `json:"id"`
+	OldEmail string      `json:"old_email"`
+}
+
+// The new address counts as verified: it is only changed once the user has
+// followed a link sent to it.
+func (q *Queries) ChangeUserEmail(ctx context.Context, arg ChangeUserEmailParams) (int64, error) {
+	result, err := q.db.Exec(ctx, changeUserEmail, arg.NewEmail, arg.ID, arg.OldEmail)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash)
 VALUES ($1, $2)
-RETURNING id, email, password_hash, status, created_at, updated_at
+RETURNING id, email, password_hash, status, created_at, updated_at, email_verified_at, verification_sent_at
 `
 
 type CreateUserParams struct {
@@ -32,6 +56,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.VerificationSentAt,
 	)
 	return i, err
 }
@@ -40,7 +66,7 @@ const createUserIfNotExists = `-- name: CreateUserIfNotExists :one
 INSERT INTO users (email, password_hash)
 VALUES ($1, $2)
 ON CONFLICT (email) DO NOTHING
-RETURNING id, email, password_hash, status, created_at, updated_at
+RETURNING id, email, password_hash, status, created_at, updated_at, email_verified_at, verification_sent_at
 `
 
 type CreateUserIfNotExistsParams struct {
@@ -58,6 +84,8 @@ func (q *Queries) CreateUserIfNotExists(ctx context.Context, arg CreateUserIfNot
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.VerificationSentAt,
 	)
 	return i, err
 }
@@ -78,6 +106,19 @@ func (q *Queries) CreateUserRole(ctx context.Context, arg CreateUserRoleParams)
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getRoleByName = `-- name: GetRoleByName :one
 SELECT id, name
 FROM roles
@@ -99,7 +140,25 @@ SELECT
     u.status,
     u.created_at,
     u.updated_at,
-    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles,
+    ARRAY(
+        SELECT DISTINCT rp.permission
+        FROM user_roles pur
+        JOIN role_permissions rp ON rp.role_id = pur.role_id
+        WHERE pur.user_id = u.id
+        ORDER BY rp.permission
+    )::TEXT[] AS permissions,
+    EXISTS (
+        SELECT 1 FROM user_totp t
+        WHERE t.user_id = u.id AND t.confirmed_at IS NOT NULL
+    ) AS mfa_enabled,
+    EXISTS (
+        SELECT 1
+        FROM user_roles mur
+        JOIN mfa_required_roles mr ON mr.role_id = mur.role_id
+        WHERE mur.user_id = u.id
+    ) AS mfa_required
 FROM users u
 LEFT JOIN user_roles ur ON ur.user_id = u.id
 LEFT JOIN roles r ON r.id = ur.role_id
@@ -108,13 +167,17 @@ GROUP BY u.id
 `
 
 type GetUserByEmailRow struct {
-	ID           pgtype.UUID        `json:"id"`
-	Email        string             `json:"email"`
-	PasswordHash string             `json:"password_hash"`
-	Status       int16              `json:"status"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
-	Roles        []string           `json:"roles"`
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	PasswordHash    string             `json:"password_hash"`
+	Status          int16              `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	Roles           []string           `json:"roles"`
+	Permissions     []string           `json:"permissions"`
+	MfaEnabled      bool               `json:"mfa_enabled"`
+	MfaRequired     bool               `json:"mfa_required"`
 }
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
@@ -127,13 +190,84 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEm
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.Roles,
+		&i.Permissions,
+		&i.MfaEnabled,
+		&i.MfaRequired,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT
+    u.id,
+    u.email,
+    u.password_hash,
+    u.status,
+    u.created_at,
+    u.updated_at,
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles,
+    ARRAY(
+        SELECT DISTINCT rp.permission
+        FROM user_roles pur
+        JOIN role_permissions rp ON rp.role_id = pur.role_id
+        WHERE pur.user_id = u.id
+        ORDER BY rp.permission
+    )::TEXT[] AS permissions,
+    EXISTS (
+        SELECT 1 FROM user_totp t
+        WHERE t.user_id = u.id AND t.confirmed_at IS NOT NULL
+    ) AS mfa_enabled,
+    EXISTS (
+        SELECT 1
+        FROM user_roles mur
+        JOIN mfa_required_roles mr ON mr.role_id = mur.role_id
+        WHERE mur.user_id = u.id
+    ) AS mfa_required
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN roles r ON r.id = ur.role_id
+WHERE u.id = $1
+GROUP BY u.id
+`
+
+type GetUserByIDRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	PasswordHash    string             `json:"password_hash"`
+	Status          int16              `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	Roles           []string           `json:"roles"`
+	Permissions     []string           `json:"permissions"`
+	MfaEnabled      bool               `json:"mfa_enabled"`
+	MfaRequired     bool               `json:"mfa_required"`
+}
+
+func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i GetUserByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
 		&i.Roles,
+		&i.Permissions,
+		&i.MfaEnabled,
+		&i.MfaRequired,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, password_hash, status, created_at, updated_at FROM users
+SELECT id, email, password_hash, status, created_at, updated_at, email_verified_at, verification_sent_at FROM users
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -159,6 +293,8 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EmailVerifiedAt,
+			&i.VerificationSentAt,
 		); err != nil {
 			return nil, err
 		}
@@ -169,3 +305,239 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	}
 	return items, nil
 }
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, now())
+WHERE id = $1 AND email = $2
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Email string      `json:"email"`
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markUserEmailVerified, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markVerificationSent = `-- name: MarkVerificationSent :execrows
+UPDATE users
+SET verification_sent_at = now()
+WHERE id = $1
+  AND email_verified_at IS NULL
+  AND (verification_sent_at IS NULL OR verification_sent_at < $2)
+`
+
+type MarkVerificationSentParams struct {
+	ID                 pgtype.UUID        `json:"id"`
+	VerificationSentAt pgtype.Timestamptz `json:"verification_sent_at"`
+}
+
+func (q *Queries) MarkVerificationSent(ctx context.Context, arg MarkVerificationSentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markVerificationSent, arg.ID, arg.VerificationSentAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users
+SET password_hash = $1
+WHERE id = $2 AND password_hash = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHash string      `json:"new_hash"`
+	ID      pgtype.UUID `json:"id"`
+	OldHash string      `json:"old_hash"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rehashUserPassword, arg.NewHash, arg.ID, arg.OldHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT
+    u.id,
+    u.email,
+    u.password_hash,
+    u.status,
+    u.created_at,
+    u.updated_at,
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN roles r ON r.id = ur.role_id
+WHERE u.id = ANY($1::UUID[])
+GROUP BY u.id
+`
+
+type GetUsersByIDsRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	PasswordHash    string             `json:"password_hash"`
+	Status          int16              `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	Roles           []string           `json:"roles"`
+}
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []pgtype.UUID) ([]GetUsersByIDsRow, error) {
+	rows, err := q.db.Query(ctx, getUsersByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByIDsRow
+	for rows.Next() {
+		var i GetUsersByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EmailVerifiedAt,
+			&i.Roles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT
+    u.id,
+    u.email,
+    u.password_hash,
+    u.status,
+    u.created_at,
+    u.updated_at,
+    u.email_verified_at,
+    ARRAY_REMOVE(ARRAY_AGG(r.name), NULL)::TEXT[] AS roles
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN roles r ON r.id = ur.role_id
+WHERE ($1::TEXT IS NULL OR starts_with(lower(u.email), lower($1)))
+  AND ($2::SMALLINT IS NULL OR u.status = $2)
+  AND ($3::TIMESTAMPTZ IS NULL OR u.created_at >= $3)
+  AND ($4::TIMESTAMPTZ IS NULL OR u.created_at < $4)
+GROUP BY u.id
+HAVING $5::TEXT IS NULL OR $5 = ANY(ARRAY_AGG(r.name))
+ORDER BY u.created_at DESC
+LIMIT $6 OFFSET $7
+`
+
+type SearchUsersParams struct {
+	EmailPrefix   pgtype.Text        `json:"email_prefix"`
+	Status        pgtype.Int2        `json:"status"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	Role          pgtype.Text        `json:"role"`
+	Limit         int32              `json:"limit"`
+	Offset        int32              `json:"offset"`
+}
+
+type SearchUsersRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	PasswordHash    string             `json:"password_hash"`
+	Status          int16              `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	Roles           []string           `json:"roles"`
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.EmailPrefix,
+		arg.Status,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Role,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EmailVerifiedAt,
+			&i.Roles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserStatus = `-- name: UpdateUserStatus :execrows
+UPDATE users
+SET status = $2
+WHERE id = $1
+`
+
+type UpdateUserStatusParams struct {
+	ID     pgtype.UUID `json:"id"`
+	Status int16       `json:"status"`
+}
+
+func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserStatus, arg.ID, arg.Status)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :execrows
+UPDATE users
+SET password_hash = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           pgtype.UUID `json:"id"`
+	PasswordHash string      `json:"password_hash"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
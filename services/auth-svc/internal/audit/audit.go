@@ -0,0 +1,56 @@
+// Package audit records security relevant events, such as sign-ins, token
+// reuse and administrative actions, in an append-only log.
+package audit
+
+import (
+	"context"
+
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/rs/zerolog"
+)
+
+// Store appends events to the audit log.
+type Store interface {
+	Append(ctx context.Context, e *model.AuditEvent) error
+}
+
+// Log records audit events in a Store. A nil *Log records nothing.
+type Log struct {
+	store Store
+	log   zerolog.Logger
+}
+
+func New(store Store, log zerolog.Logger) *Log {
+	return &Log{store: store, log: log}
+}
+
+// Record appends e to the log. The IP address and user agent are taken from
+// ctx unless e has them, and the outcome defaults to success.
+//
+// Failures are logged rather than returned: an unavailable audit store must
+// not lock users out. The write is not cancelled with ctx, so that events of
+// aborted requests are kept as well.
+func (l *Log) Record(ctx context.Context, e model.AuditEvent) {
+	if l == nil {
+		return
+	}
+	if e.Outcome == "" {
+		e.Outcome = model.AuditSuccess
+	}
+	if e.IP == "" {
+		e.IP = requestinfo.ClientIP(ctx)
+	}
+	if e.UserAgent == "" {
+		e.UserAgent = requestinfo.UserAgent(ctx)
+	}
+	if err := l.store.Append(context.WithoutCancel(ctx), &e); err != nil {
+		l.log.Error().
+			Err(err).
+			Str("type", e.Type).
+			Str("outcome", e.Outcome).
+			Str("actor", e.Actor).
+			Str("subject", e.Subject).
+			Msg("record audit event")
+	}
+}
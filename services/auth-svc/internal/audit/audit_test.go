@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type memStore struct {
+	events []model.AuditEvent
+	err    error
+}
+
+func (m *memStore) Append(ctx context.Context, e *model.AuditEvent) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, *e)
+	return nil
+}
+
+func TestLog_Record(t *testing.T) {
+	store := &memStore{}
+	l := New(store, zerolog.Nop())
+
+	ctx := requestinfo.WithUserAgent(requestinfo.WithClientIP(context.Background(), "203.0.113.7"), "curl/8.0")
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	l.Record(ctx, model.AuditEvent{Type: model.AuditLogin, Subject: "user@example.com"})
+	l.Record(ctx, model.AuditEvent{Type: model.AuditLogin, Outcome: model.AuditFailure, IP: "198.51.100.1"})
+
+	if !assert.Len(t, store.events, 2, "событие должно записываться и после отмены запроса") {
+		return
+	}
+	assert.Equal(t, model.AuditSuccess, store.events[0].Outcome, "по умолчанию исход успешный")
+	assert.Equal(t, "203.0.113.7", store.events[0].IP)
+	assert.Equal(t, "curl/8.0", store.events[0].UserAgent)
+	assert.Equal(t, model.AuditFailure, store.events[1].Outcome)
+	assert.Equal(t, "198.51.100.1", store.events[1].IP, "явно указанный адрес не должен перезаписываться")
+}
+
+func TestLog_RecordIgnoresStoreErrors(t *testing.T) {
+	l := New(&memStore{err: errors.New("connection refused")}, zerolog.Nop())
+	assert.NotPanics(t, func() {
+		l.Record(context.Background(), model.AuditEvent{Type: model.AuditLogin})
+	})
+
+	var nilLog *Log
+	assert.NotPanics(t, func() {
+		nilLog.Record(context.Background(), model.AuditEvent{Type: model.AuditLogin})
+	}, "nil-журнал ничего не записывает")
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+func NewRedisClient(cfg *config.Config) (*redis.Client, error) {
+	rds := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Host,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rds.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis ping failed: %w", err)
+	}
+
+	return rds, nil
+}
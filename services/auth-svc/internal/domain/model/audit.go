@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// Types of audit events.
+const (
+	AuditUserRegistered = "user.registered"
+	AuditLogin          = "user.login"
+	// AuditSecondFactor is recorded when a user answers a sign-in MFA
+	// challenge.
+	AuditSecondFactor    = "user.second_factor"
+	AuditPasswordChanged = "user.password_changed"
+	// AuditEmailChangeRequested is recorded when a link confirming a new
+	// address is mailed, AuditEmailChanged once it is followed.
+	AuditEmailChangeRequested = "user.email_change_requested"
+	AuditEmailChanged         = "user.email_changed"
+	AuditTokenRefreshed       = "token.refreshed"
+	AuditTokenReuse           = "token.reuse_detected"
+	AuditClientAuth           = "client.authentication"
+	AuditClientTokenIssued    = "client.token_issued"
+	AuditRoleChanged          = "role.changed"
+	AuditSessionRevoked       = "session.revoked"
+	// AuditTokenExchanged is recorded when a client swaps a user access
+	// token for one it may use on the user's behalf.
+	AuditTokenExchanged = "token.exchanged"
+	// API keys are audited when created, revoked and exchanged for tokens.
+	AuditAPIKeyCreated   = "api_key.created"
+	AuditAPIKeyRevoked   = "api_key.revoked"
+	AuditAPIKeyExchanged = "api_key.exchanged"
+
+	AuditUserDisabled = "admin.user_disabled"
+	AuditUserEnabled  = "admin.user_enabled"
+	AuditUserDeleted  = "admin.user_deleted"
+	AuditUserUnlocked = "admin.user_unlocked"
+	AuditMFAReset     = "admin.mfa_reset"
+
+	AuditClientCreated         = "admin.client_created"
+	AuditClientDisabled        = "admin.client_disabled"
+	AuditClientEnabled         = "admin.client_enabled"
+	AuditClientDeleted         = "admin.client_deleted"
+	AuditClientSecretRotated   = "admin.client_secret_rotated"
+	AuditClientRedirectURIsSet = "admin.client_redirect_uris_set"
+	AuditClientUnlocked        = "admin.client_unlocked"
+	AuditIPUnlocked            = "admin.ip_unlocked"
+
+	AuditClientExchangeAudiencesSet = "admin.client_exchange_audiences_set"
+)
+
+// Outcomes of audit events.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent is an entry of the security audit log. Entries are never
+// changed once written.
+type AuditEvent struct {
+	ID      int64
+	Type    string
+	Outcome string
+	// Actor is who performed the action: a user ID, a client ID or the
+	// subject of an administrator. It is empty for anonymous callers.
+	Actor string
+	// Subject is what the action was performed on, such as a user ID, an
+	// email, a client ID or a role name.
+	Subject   string
+	IP        string
+	UserAgent string
+	// Details holds event specific facts, such as the reason of a failure.
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+// AuditFilter narrows an audit log query. Zero-valued fields match
+// everything.
+type AuditFilter struct {
+	Type    string
+	Outcome string
+	Actor   string
+	Subject string
+	IP      string
+	Since   time.Time
+	Until   time.Time
+	// BeforeID skips events with this ID or above.
+	BeforeID int64
+}
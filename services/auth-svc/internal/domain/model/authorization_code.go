@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorizationCode is an OAuth authorization code waiting to be exchanged
+// for tokens. Only the hash of the code is stored. SessionID is reserved for
+// the session the exchange opens, so that the tokens issued for a code can be
+// revoked if the code is replayed.
+type AuthorizationCode struct {
+	CodeHash            string
+	ClientID            string
+	UserID              uuid.UUID
+	SessionID           uuid.UUID
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	AMR                 []string
+	ExpiresAt           time.Time
+	CreatedAt           time.Time
+	UsedAt              *time.Time
+}
@@ -2,10 +2,34 @@ package model
 
 import "time"
 
+const (
+	ClientStatusActive   int16 = 1
+	ClientStatusDisabled int16 = 2
+)
+
 type Client struct {
-	ID        string
-	Secret    string
-	Roles     []string
-	Status    int16
-	CreatedAt time.Time
+	ID     string
+	Secret string
+	Roles  []string
+	// Permissions are the effective permissions granted by Roles. They are
+	// only loaded by ClientRepository.GetById.
+	Permissions   []string
+	Status        int16
+	AllowedScopes []string
+	GrantTypes    []string
+	CreatedAt     time.Time
+	// PreviousSecret is the hash of the secret replaced by the last rotation.
+	// It is accepted until PreviousSecretExpiresAt.
+	PreviousSecret          string
+	PreviousSecretExpiresAt *time.Time
+	// RedirectURIs are the registered redirection endpoints of the
+	// authorization code flow, compared verbatim.
+	RedirectURIs []string
+	// Public clients, such as SPAs and mobile apps, cannot keep a secret.
+	// They use the authorization code flow with PKCE instead.
+	Public bool
+	// ExchangeAudiences are the audiences the client may request tokens for
+	// with the token exchange grant, e.g. the services it calls on behalf of
+	// users.
+	ExchangeAudiences []string
 }
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Kinds of throttled subjects.
+const (
+	LockoutKindAccount = "account"
+	LockoutKindClient  = "client"
+	LockoutKindIP      = "ip"
+)
+
+// Actions recorded in LockoutEvent.Action.
+const (
+	LockoutActionLock   = "lockout.lock"
+	LockoutActionUnlock = "lockout.unlock"
+)
+
+// LockoutEvent records that a subject was locked after repeated sign-in
+// failures or unlocked by an administrator.
+type LockoutEvent struct {
+	ID      int64
+	Kind    string
+	Subject string
+	Action  string
+	// Actor is the administrator who unlocked the subject. It is empty for
+	// automatic lockouts.
+	Actor string
+	// IP is the address of the last failed attempt.
+	IP          string
+	Failures    int
+	LockedUntil *time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTP is the authenticator of a user. It only counts as a second factor once
+// confirmed with a first code; until then it can be replaced freely.
+type TOTP struct {
+	UserID    uuid.UUID
+	Secret    string
+	Confirmed bool
+	// LastUsedStep is the time step of the last accepted code. Codes of this
+	// or an earlier step are rejected so that a code cannot be replayed.
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
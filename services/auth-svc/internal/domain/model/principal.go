@@ -0,0 +1,51 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// AMRAPIKey is the authentication method of tokens an API key was exchanged
+// for.
+const AMRAPIKey = "api_key"
+
+// Principal is the authenticated caller of a request, taken from a verified
+// access token.
+type Principal struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Roles         []string
+	Permissions   []string
+	Scopes        []string
+	ClientID      string
+	TokenID       string
+	// AMR lists how the user authenticated, e.g. "pwd" and "otp".
+	AMR []string
+	// SessionID is the session a user token was issued in.
+	SessionID string
+	ExpiresAt time.Time
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) HasPermission(permission string) bool {
+	for _, v := range p.Permissions {
+		if v == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// FromAPIKey tells whether the token was obtained with an API key.
+func (p *Principal) FromAPIKey() bool {
+	return slices.Contains(p.AMR, AMRAPIKey)
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RevokedToken is an entry of the access token denylist. It is kept until the
+// token it refers to would have expired anyway.
+type RevokedToken struct {
+	JTI       string
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type Role struct {
+	ID          int16
+	Name        string
+	Permissions []string
+	// MFARequired makes users with the role sign in with a second factor.
+	MFARequired bool
+}
+
+// Actions recorded in RoleChange.Action.
+const (
+	RoleActionCreate           = "role.create"
+	RoleActionDelete           = "role.delete"
+	RoleActionAddPermission    = "permission.add"
+	RoleActionRemovePermission = "permission.remove"
+	RoleActionGrant            = "role.grant"
+	RoleActionRevoke           = "role.revoke"
+	RoleActionSet              = "role.set"
+	RoleActionRequireMFA       = "mfa.require"
+	RoleActionWaiveMFA         = "mfa.waive"
+)
+
+// Subject types of a RoleChange. Changes to a role itself have no subject.
+const (
+	RoleSubjectUser   = "user"
+	RoleSubjectClient = "client"
+)
+
+// RoleChange records who changed a role, its permissions or its assignments.
+type RoleChange struct {
+	ID          int64
+	Actor       string
+	Action      string
+	Role        string
+	SubjectType string
+	SubjectID   string
+	Permission  string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a device signed in to a user account. Its ID is the family ID of
+// the refresh tokens rotated within it.
+type Session struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	UserAgent       string
+	IP              string
+	CreatedAt       time.Time
+	LastRefreshedAt time.Time
+	ExpiresAt       time.Time
+}
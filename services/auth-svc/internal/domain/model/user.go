@@ -6,11 +6,32 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	UserStatusActive   int16 = 1
+	UserStatusDisabled int16 = 2
+)
+
 type User struct {
-	ID        uuid.UUID
-	Email     string
-	Password  string
-	Status    int16
-	Roles     []string
-	CreatedAt time.Time
+	ID       uuid.UUID
+	Email    string
+	Password string
+	Status   int16
+	Roles    []string
+	// Permissions are the effective permissions granted by Roles.
+	Permissions   []string
+	EmailVerified bool
+	// MFAEnabled tells whether the user has a confirmed TOTP authenticator.
+	MFAEnabled bool
+	// MFARequired tells whether one of Roles requires a second factor.
+	MFARequired bool
+	CreatedAt   time.Time
+}
+
+// UserFilter narrows a user listing. Zero-valued fields match everything.
+type UserFilter struct {
+	EmailPrefix   string
+	Status        int16
+	Role          string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
 }
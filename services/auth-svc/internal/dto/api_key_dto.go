@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,required,max=64"`
+	// ExpiresAt is RFC 3339. Keys without it never expire.
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type ExchangeAPIKeyRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+type APIKeyResponse struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// Expired keys are listed until they are cleaned up.
+	Expired    bool   `json:"expired"`
+	ExpiresAt  string `json:"expiresAt,omitempty"`
+	LastUsedAt string `json:"lastUsedAt,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+type APIKeysResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
+
+// CreatedAPIKeyResponse carries the plaintext key. It is returned only once,
+// when the key is created.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
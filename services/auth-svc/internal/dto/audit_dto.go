@@ -0,0 +1,18 @@
+package dto
+
+type AuditEventResponse struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	Outcome   string            `json:"outcome"`
+	Actor     string            `json:"actor,omitempty"`
+	Subject   string            `json:"subject,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"userAgent,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt string            `json:"createdAt"`
+}
+
+type AuditEventsResponse struct {
+	Events     []AuditEventResponse `json:"events"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
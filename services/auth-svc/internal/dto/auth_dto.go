@@ -2,7 +2,7 @@ package dto
 
 type SignUpRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type SignUpResponse struct {
@@ -13,16 +13,102 @@ type SignUpResponse struct {
 	UpdatedAt string `json:"updated_at"`
 }
 
+// PendingVerificationResponse is returned by registration when tokens are
+// withheld until the email is verified.
+type PendingVerificationResponse struct {
+	Email                string `json:"email"`
+	VerificationRequired bool   `json:"verificationRequired"`
+}
+
 type SignInRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
-type ClientCredentialsRequest struct {
-	ClientID     string `json:"client_id" binding:"required"`
-	ClientSecret string `json:"client_secret" binding:"required"`
+// TokenRequest is the body of the OAuth token endpoint. It is normally sent
+// as application/x-www-form-urlencoded, JSON is accepted as well.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" json:"grant_type"`
+	ClientID     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+	Scope        string `form:"scope" json:"scope"`
+	Username     string `form:"username" json:"username"`
+	Password     string `form:"password" json:"password"`
+	RefreshToken string `form:"refresh_token" json:"refresh_token"`
+	Code         string `form:"code" json:"code"`
+	RedirectURI  string `form:"redirect_uri" json:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier" json:"code_verifier"`
+	// The parameters of the token exchange grant, RFC 8693 section 2.1.
+	// audience may be repeated.
+	SubjectToken       string   `form:"subject_token" json:"subject_token"`
+	SubjectTokenType   string   `form:"subject_token_type" json:"subject_token_type"`
+	RequestedTokenType string   `form:"requested_token_type" json:"requested_token_type"`
+	ActorToken         string   `form:"actor_token" json:"actor_token"`
+	Audience           []string `form:"audience" json:"audience"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RevokedTokenResponse struct {
+	JTI       string `json:"jti"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type RevokedTokensResponse struct {
+	Tokens []RevokedTokenResponse `json:"tokens"`
+}
+
+type TokenRevocationStatus struct {
+	JTI     string `json:"jti"`
+	Revoked bool   `json:"revoked"`
+}
+
+type IntrospectRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	TokenType string   `json:"token_type,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Perms     []string `json:"perms,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// PasswordViolation is a rule of the password policy a new password broke.
+// Limit is the bound of the length, character class and reuse rules.
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
 }
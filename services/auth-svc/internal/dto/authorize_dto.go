@@ -0,0 +1,29 @@
+package dto
+
+// AuthorizeRequest holds the parameters of the OAuth authorization endpoint.
+// They arrive in the query string and are posted back by the login form as
+// hidden fields.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Nonce               string `form:"nonce"`
+}
+
+// AuthorizeForm is the login form of the authorization page. Its first step
+// posts the email and password, the second the MFA token with a code.
+type AuthorizeForm struct {
+	AuthorizeRequest
+	CSRFToken    string `form:"csrf_token"`
+	Email        string `form:"email"`
+	Password     string `form:"password"`
+	MFAToken     string `form:"mfa_token"`
+	OTP          string `form:"otp"`
+	RecoveryCode string `form:"recovery_code"`
+	// Cancel is set when the user declines to sign in to the client.
+	Cancel string `form:"cancel"`
+}
@@ -0,0 +1,56 @@
+package dto
+
+type CreateClientRequest struct {
+	ClientID      string   `json:"clientId" binding:"required,min=3,max=64"`
+	Roles         []string `json:"roles" binding:"dive,required"`
+	AllowedScopes []string `json:"allowedScopes" binding:"dive,required"`
+	GrantTypes    []string `json:"grantTypes" binding:"dive,oneof=client_credentials password refresh_token authorization_code urn:ietf:params:oauth:grant-type:token-exchange"`
+	RedirectURIs  []string `json:"redirectUris" binding:"dive,required,url"`
+	// Public clients get no secret and must use PKCE.
+	Public bool `json:"public"`
+	// ExchangeAudiences are the audiences the client may request with the
+	// token exchange grant.
+	ExchangeAudiences []string `json:"exchangeAudiences" binding:"dive,required,max=255"`
+}
+
+type UpdateClientRedirectURIsRequest struct {
+	RedirectURIs []string `json:"redirectUris" binding:"required,dive,required,url"`
+}
+
+type UpdateClientExchangeAudiencesRequest struct {
+	ExchangeAudiences []string `json:"exchangeAudiences" binding:"required,dive,required,max=255"`
+}
+
+type UpdateClientRolesRequest struct {
+	Roles []string `json:"roles" binding:"required,dive,required"`
+}
+
+type RotateClientSecretRequest struct {
+	// GraceSeconds overrides the configured grace period of the old secret.
+	GraceSeconds int64 `json:"graceSeconds" binding:"min=0"`
+}
+
+type ClientResponse struct {
+	ClientID                string   `json:"clientId"`
+	Roles                   []string `json:"roles"`
+	AllowedScopes           []string `json:"allowedScopes"`
+	GrantTypes              []string `json:"grantTypes"`
+	RedirectURIs            []string `json:"redirectUris"`
+	Public                  bool     `json:"public"`
+	ExchangeAudiences       []string `json:"exchangeAudiences"`
+	Status                  int16    `json:"status"`
+	CreatedAt               string   `json:"createdAt"`
+	PreviousSecretExpiresAt string   `json:"previousSecretExpiresAt,omitempty"`
+}
+
+type ClientsResponse struct {
+	Clients []ClientResponse `json:"clients"`
+}
+
+// ClientSecretResponse carries a plaintext client secret. It is returned
+// only once, when the client is created or its secret is rotated. Public
+// clients have none.
+type ClientSecretResponse struct {
+	ClientResponse
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
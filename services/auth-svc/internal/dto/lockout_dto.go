@@ -0,0 +1,17 @@
+package dto
+
+type LockoutEventResponse struct {
+	ID          int64  `json:"id"`
+	Kind        string `json:"kind"`
+	Subject     string `json:"subject"`
+	Action      string `json:"action"`
+	Actor       string `json:"actor,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	Failures    int    `json:"failures"`
+	LockedUntil string `json:"lockedUntil,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type LockoutEventsResponse struct {
+	Events []LockoutEventResponse `json:"events"`
+}
@@ -0,0 +1,56 @@
+package dto
+
+// MFAChallengeResponse is returned by sign-in instead of tokens when a second
+// factor is needed. The token is exchanged for tokens at /login/mfa.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfaRequired"`
+	MFAToken           string `json:"mfaToken"`
+	ExpiresAt          int64  `json:"expiresAt"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+}
+
+// VerifyMFARequest finishes a sign-in with either a TOTP code or a recovery
+// code.
+type VerifyMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+type ConfirmMFAEnrollmentRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type MFAEnrollmentResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+	Tokens        any      `json:"tokens"`
+}
+
+type MFAStatusResponse struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleResponse struct {
+	ID          int16    `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	MFARequired bool     `json:"mfaRequired"`
+}
+
+type RolesResponse struct {
+	Roles []RoleResponse `json:"roles"`
+}
+
+type PermissionRequest struct {
+	Permission string `json:"permission" binding:"required"`
+}
+
+type RoleAssignmentRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RoleMFARequest turns the second-factor requirement of a role on or off.
+type RoleMFARequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+type RoleChangeResponse struct {
+	ID          int64  `json:"id"`
+	Actor       string `json:"actor"`
+	Action      string `json:"action"`
+	Role        string `json:"role"`
+	SubjectType string `json:"subjectType,omitempty"`
+	SubjectID   string `json:"subjectId,omitempty"`
+	Permission  string `json:"permission,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type RoleChangesResponse struct {
+	Changes []RoleChangeResponse `json:"changes"`
+}
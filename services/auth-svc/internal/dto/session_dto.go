@@ -0,0 +1,16 @@
+package dto
+
+type SessionResponse struct {
+	ID              string `json:"id"`
+	UserAgent       string `json:"userAgent"`
+	IP              string `json:"ip"`
+	CreatedAt       string `json:"createdAt"`
+	LastRefreshedAt string `json:"lastRefreshedAt"`
+	ExpiresAt       string `json:"expiresAt"`
+	// Current marks the session of the token the request was made with.
+	Current bool `json:"current"`
+}
+
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
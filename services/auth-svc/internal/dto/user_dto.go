@@ -0,0 +1,13 @@
+package dto
+
+type UserResponse struct {
+	ID        string   `json:"id"`
+	Email     string   `json:"email"`
+	Status    int16    `json:"status"`
+	Roles     []string `json:"roles"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+type UsersResponse struct {
+	Users []UserResponse `json:"users"`
+}
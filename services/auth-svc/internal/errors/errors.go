@@ -1,13 +1,53 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrRoleNotFound       = errors.New("role not found")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUnauthorized       = errors.New("unauthorized")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrForbidden          = errors.New("forbidden")
-	ErrNotFound           = errors.New("not found")
+	ErrUserAlreadyExists   = errors.New("user already exists")
+	ErrRoleNotFound        = errors.New("role not found")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrTokenReused         = errors.New("refresh token reuse detected")
+	ErrInvalidScope        = errors.New("invalid scope")
+	ErrClientAlreadyExists = errors.New("client already exists")
+	ErrUserDisabled        = errors.New("user is disabled")
+	ErrRoleAlreadyExists   = errors.New("role already exists")
+	ErrRoleProtected       = errors.New("role is protected")
+	ErrInvalidRoleName     = errors.New("invalid role name")
+	ErrInvalidPermission   = errors.New("invalid permission")
+	ErrEmailNotVerified    = errors.New("email is not verified")
+	ErrAccountLocked       = errors.New("account is temporarily locked")
+	ErrTooManyAttempts     = errors.New("too many attempts")
+	ErrMFARequired         = errors.New("second factor required")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode      = errors.New("invalid verification code")
+	ErrInvalidClient       = errors.New("invalid client")
+	ErrInvalidRedirectURI  = errors.New("invalid redirect uri")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrEmailUnchanged      = errors.New("email is unchanged")
+	ErrAPIKeyLimit         = errors.New("api key limit reached")
+	ErrWeakPassword        = errors.New("password does not satisfy the policy")
 )
+
+// LockoutError is returned while sign-in attempts are throttled. It wraps
+// ErrAccountLocked for a locked account or client and ErrTooManyAttempts for
+// a locked IP address.
+type LockoutError struct {
+	Err   error
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return e.Err.Error() + " until " + e.Until.UTC().Format(time.RFC3339)
+}
+
+func (e *LockoutError) Unwrap() error {
+	return e.Err
+}
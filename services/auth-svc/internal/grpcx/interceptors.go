@@ -0,0 +1,129 @@
+package grpcx
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type clientKey struct{}
+
+// clientFrom returns the client that authenticated the call.
+func clientFrom(ctx context.Context) *user.Client {
+	cli, _ := ctx.Value(clientKey{}).(*user.Client)
+	return cli
+}
+
+func logging(log zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+
+		e := log.Info()
+		if code == codes.Internal || code == codes.Unknown {
+			e = log.Error().Err(err)
+		}
+		e.Str("method", info.FullMethod).
+			Str("code", code.String()).
+			Dur("duration", time.Since(start)).
+			Msg("handled rpc")
+		return resp, err
+	}
+}
+
+// recovery turns a panic in a handler into an Internal error, so that one
+// bad call does not take the whole service down.
+func recovery(log zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Interface("panic", r).
+					Str("method", info.FullMethod).
+					Bytes("stack", debug.Stack()).
+					Msg("rpc panicked")
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// clientAuth authenticates the call with the client credentials in its
+// authorization metadata, as with HTTP Basic. Failures are throttled and
+// audited like those of the HTTP endpoints, by the address of the peer.
+func clientAuth(auth *service.AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = withRequestInfo(ctx)
+		id, secret, ok := basicCredentials(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "client credentials are required")
+		}
+		cli, err := auth.AuthenticateClient(ctx, id, secret)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return handler(context.WithValue(ctx, clientKey{}, cli), req)
+	}
+}
+
+// basicCredentials reads "Basic <base64 of id:secret>" from the authorization
+// metadata. Like the HTTP endpoints it accepts form-encoded IDs and secrets.
+func basicCredentials(ctx context.Context) (string, string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", "", false
+	}
+	const prefix = "basic "
+	if len(values[0]) < len(prefix) || !strings.EqualFold(values[0][:len(prefix)], prefix) {
+		return "", "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(values[0][len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return "", "", false
+	}
+	if v, err := url.QueryUnescape(id); err == nil {
+		id = v
+	}
+	if v, err := url.QueryUnescape(secret); err == nil {
+		secret = v
+	}
+	return id, secret, id != "" && secret != ""
+}
+
+// withRequestInfo makes the address and user agent of the peer available to
+// services, like middleware.RequestInfo does for HTTP.
+func withRequestInfo(ctx context.Context) context.Context {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip := p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		ctx = requestinfo.WithClientIP(ctx, ip)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ua := md.Get("user-agent"); len(ua) > 0 {
+		ctx = requestinfo.WithUserAgent(ctx, ua[0])
+	}
+	return ctx
+}
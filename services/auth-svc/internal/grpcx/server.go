@@ -0,0 +1,164 @@
+// Package grpcx serves the gRPC API other services of the shop use to check
+// tokens, look users up and get client tokens, see proto/auth.proto.
+package grpcx
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	pb "github.com/oidiral/e-commerce/services/auth-svc/internal/pb/auth"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchUsers bounds the number of IDs in one GetUsersBatch call.
+const maxBatchUsers = 100
+
+// scopeUsersRead lets a client look users up with GetUser and GetUsersBatch.
+const scopeUsersRead = "users:read"
+
+// NewServer returns a gRPC server with the Auth service registered. Every
+// call is logged, recovered from panics and authenticated as a client.
+func NewServer(auth *service.AuthService, users *service.UserService, log zerolog.Logger) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		logging(log),
+		recovery(log),
+		clientAuth(auth),
+	))
+	pb.RegisterAuthServer(srv, &authServer{auth: auth, users: users})
+	return srv
+}
+
+type authServer struct {
+	pb.UnimplementedAuthServer
+	auth  *service.AuthService
+	users *service.UserService
+}
+
+func (s *authServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+	res, err := s.auth.Introspect(ctx, req.GetToken())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ValidateTokenResponse{
+		Active:    res.Active,
+		TokenType: res.TokenType,
+		Sub:       res.Subject,
+		Email:     res.Email,
+		Roles:     res.Roles,
+		Perms:     res.Permissions,
+		ClientId:  res.ClientID,
+		Scope:     res.Scope,
+		Jti:       res.JTI,
+		Exp:       res.ExpiresAt,
+		Iat:       res.IssuedAt,
+	}, nil
+}
+
+func (s *authServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if err := requireScope(ctx, scopeUsersRead); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+	u, err := s.users.Get(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetUserResponse{User: toUser(u)}, nil
+}
+
+func (s *authServer) GetUsersBatch(ctx context.Context, req *pb.GetUsersBatchRequest) (*pb.GetUsersBatchResponse, error) {
+	if err := requireScope(ctx, scopeUsersRead); err != nil {
+		return nil, err
+	}
+	if len(req.GetIds()) > maxBatchUsers {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids are allowed", maxBatchUsers)
+	}
+	ids := make([]uuid.UUID, len(req.GetIds()))
+	for i, raw := range req.GetIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid user id %q", raw)
+		}
+		ids[i] = id
+	}
+	users, err := s.users.GetBatch(ctx, ids)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.GetUsersBatchResponse{Users: make([]*pb.User, len(users))}
+	for i := range users {
+		resp.Users[i] = toUser(&users[i])
+	}
+	return resp, nil
+}
+
+func (s *authServer) IssueClientToken(ctx context.Context, req *pb.IssueClientTokenRequest) (*pb.IssueClientTokenResponse, error) {
+	tok, err := s.auth.IssueClientToken(ctx, clientFrom(ctx), service.ParseScope(req.GetScope()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.IssueClientTokenResponse{
+		AccessToken: tok.AccessToken,
+		TokenType:   tok.TokenType,
+		ExpiresIn:   tok.ExpiresIn,
+		Scope:       tok.Scope,
+	}, nil
+}
+
+// requireScope fails with PermissionDenied unless the client of the call is
+// allowed scope.
+func requireScope(ctx context.Context, scope string) error {
+	if cli := clientFrom(ctx); cli == nil || !slices.Contains(cli.AllowedScopes, scope) {
+		return status.Errorf(codes.PermissionDenied, "client is not allowed the %s scope", scope)
+	}
+	return nil
+}
+
+func toUser(u *user.User) *pb.User {
+	return &pb.User{
+		Id:            u.ID.String(),
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Status:        int32(u.Status),
+		Roles:         u.Roles,
+		CreatedAt:     u.CreatedAt.Unix(),
+	}
+}
+
+// toStatus maps service errors to gRPC status errors. Unexpected errors are
+// not passed on to the caller.
+func toStatus(err error) error {
+	var (
+		oauthErr *service.OAuthError
+		lockErr  *domainErr.LockoutError
+	)
+	switch {
+	case errors.As(err, &oauthErr):
+		if oauthErr.Code == service.OAuthInvalidScope {
+			return status.Error(codes.InvalidArgument, oauthErr.Description)
+		}
+		return status.Error(codes.PermissionDenied, oauthErr.Description)
+	case errors.As(err, &lockErr):
+		return status.Error(codes.ResourceExhausted, lockErr.Error())
+	case errors.Is(err, domainErr.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "invalid client credentials")
+	case errors.Is(err, domainErr.ErrNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
@@ -0,0 +1,119 @@
+package grpcx
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	pb "github.com/oidiral/e-commerce/services/auth-svc/internal/pb/auth"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves auth and users in memory and returns a client of them.
+func dial(t *testing.T, auth *service.AuthService, users *service.UserService) pb.AuthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(auth, users, zerolog.Nop())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewAuthClient(conn)
+}
+
+func withClientCredentials(ctx context.Context, id, secret string) context.Context {
+	creds := base64.StdEncoding.EncodeToString([]byte(id + ":" + secret))
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+creds)
+}
+
+func TestServer_GetUser_RequiresClientCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Config{}
+	mockClients := mocks.NewMockClientRepository(ctrl)
+	mockUsers := mocks.NewMockAuthRepository(ctrl)
+	auth := service.NewAuthService(nil, zerolog.Nop(), cfg, mockClients, nil, nil, nil, nil, nil, nil, nil, nil)
+	client := dial(t, auth, service.NewUserService(mockUsers, nil, zerolog.Nop(), nil))
+
+	hash, _ := passhash.New(config.HashConfig{}).Hash("secret")
+	cli := &model.Client{ID: "cart-svc", Secret: hash, Status: model.ClientStatusActive, AllowedScopes: []string{scopeUsersRead}}
+	mockClients.EXPECT().GetById(gomock.Any(), cli.ID).Return(cli, nil).Times(3)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}, CreatedAt: time.Now()}
+	mockUsers.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+	missing := uuid.New()
+	mockUsers.EXPECT().GetByID(gomock.Any(), missing).Return(nil, AppErr.ErrNotFound)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.GetUser(ctx, &pb.GetUserRequest{Id: u.ID.String()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "вызов без учетных данных клиента должен отклоняться")
+
+	_, err = client.GetUser(withClientCredentials(ctx, cli.ID, "wrong"), &pb.GetUserRequest{Id: u.ID.String()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	authed := withClientCredentials(ctx, cli.ID, "secret")
+	resp, err := client.GetUser(authed, &pb.GetUserRequest{Id: u.ID.String()})
+	if assert.NoError(t, err) {
+		assert.Equal(t, u.Email, resp.GetUser().GetEmail())
+		assert.Equal(t, u.Roles, resp.GetUser().GetRoles())
+	}
+
+	_, err = client.GetUser(authed, &pb.GetUserRequest{Id: missing.String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRecovery_TurnsPanicIntoInternal(t *testing.T) {
+	intercept := recovery(zerolog.Nop())
+	_, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/GetUser"},
+		func(context.Context, any) (any, error) { panic("boom") })
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestServer_GetUser_RequiresUsersReadScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Config{}
+	mockClients := mocks.NewMockClientRepository(ctrl)
+	auth := service.NewAuthService(nil, zerolog.Nop(), cfg, mockClients, nil, nil, nil, nil, nil, nil, nil, nil)
+	client := dial(t, auth, service.NewUserService(mocks.NewMockAuthRepository(ctrl), nil, zerolog.Nop(), nil))
+
+	hash, _ := passhash.New(config.HashConfig{}).Hash("secret")
+	cli := &model.Client{ID: "catalog-svc", Secret: hash, Status: model.ClientStatusActive, AllowedScopes: []string{"catalog:read"}}
+	mockClients.EXPECT().GetById(gomock.Any(), cli.ID).Return(cli, nil).Times(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	authed := withClientCredentials(ctx, cli.ID, "secret")
+
+	_, err := client.GetUser(authed, &pb.GetUserRequest{Id: uuid.NewString()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "клиент без скоупа users:read не должен получать пользователей")
+	_, err = client.GetUsersBatch(authed, &pb.GetUsersBatchRequest{Ids: []string{uuid.NewString()}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// APIKeyHandler lets the signed-in user manage its API keys, admins list and
+// revoke the keys of any user, and scripts exchange a key for an access
+// token.
+type APIKeyHandler struct {
+	svc *service.AuthService
+}
+
+func NewAPIKeyHandler(svc *service.AuthService) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+func (h *APIKeyHandler) Create(ctx *gin.Context) {
+	p := middleware.PrincipalFrom(ctx)
+	if _, ok := principalUserID(ctx); !ok {
+		return
+	}
+	var req dto.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		response.BadRequest(ctx, "срок действия ключа должен быть в будущем", nil)
+		return
+	}
+	k, raw, err := h.svc.CreateAPIKey(ctx.Request.Context(), p, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, dto.CreatedAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(k),
+		Key:            raw,
+	})
+}
+
+func (h *APIKeyHandler) List(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	h.list(ctx, id)
+}
+
+func (h *APIKeyHandler) Revoke(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	h.revoke(ctx, id)
+}
+
+func (h *APIKeyHandler) ListForUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.list(ctx, id)
+}
+
+func (h *APIKeyHandler) RevokeForUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.revoke(ctx, id)
+}
+
+// Exchange issues a short-lived access token for an API key.
+func (h *APIKeyHandler) Exchange(ctx *gin.Context) {
+	var req dto.ExchangeAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	token, err := h.svc.ExchangeAPIKey(ctx.Request.Context(), req.Key)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, token)
+}
+
+func (h *APIKeyHandler) list(ctx *gin.Context, userID uuid.UUID) {
+	keys, err := h.svc.ListAPIKeys(ctx.Request.Context(), userID)
+	if err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	resp := dto.APIKeysResponse{Keys: make([]dto.APIKeyResponse, len(keys))}
+	for i, k := range keys {
+		resp.Keys[i] = toAPIKeyResponse(&k)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) revoke(ctx *gin.Context, userID uuid.UUID) {
+	keyID, err := uuid.Parse(ctx.Param("keyId"))
+	if err != nil {
+		response.BadRequest(ctx, "некорректный идентификатор ключа", nil)
+		return
+	}
+	if err := h.svc.RevokeAPIKey(ctx.Request.Context(), userID, keyID, actor(ctx)); err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func respondAPIKeyError(ctx *gin.Context, err error) {
+	if respondLockout(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domainErr.ErrInvalidCredentials):
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"неверный API-ключ", nil)
+	case errors.Is(err, domainErr.ErrInvalidToken):
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"неверный токен", nil)
+	case errors.Is(err, domainErr.ErrForbidden):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"токен, выданный по API-ключу, не может создавать ключи", nil)
+	case errors.Is(err, domainErr.ErrInvalidScope):
+		response.BadRequest(ctx, "scope ключа превышают scope токена", nil)
+	case errors.Is(err, domainErr.ErrAPIKeyLimit):
+		response.RespondWithError(ctx, http.StatusConflict,
+			"достигнуто максимальное число API-ключей", nil)
+	case errors.Is(err, domainErr.ErrUserDisabled):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"учетная запись заблокирована", nil)
+	case errors.Is(err, domainErr.ErrEmailNotVerified):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"email не подтвержден", nil)
+	case errors.Is(err, domainErr.ErrMFARequired):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"API-ключи недоступны для ролей с обязательной двухфакторной аутентификацией", nil)
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"API-ключ не найден", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
+
+func toAPIKeyResponse(k *user.APIKey) dto.APIKeyResponse {
+	resp := dto.APIKeyResponse{
+		ID:        k.ID.String(),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if k.ExpiresAt != nil {
+		resp.ExpiresAt = k.ExpiresAt.UTC().Format(time.RFC3339)
+		resp.Expired = !k.ExpiresAt.After(time.Now())
+	}
+	if k.LastUsedAt != nil {
+		resp.LastUsedAt = k.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
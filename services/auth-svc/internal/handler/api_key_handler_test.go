@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/keys"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig returns a configuration with a freshly generated signing key.
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privPath := filepath.Join(dir, "private.pem")
+	require.NoError(t, os.WriteFile(privPath,
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)}), 0o600))
+	pub, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	pubPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0o644))
+
+	return &config.Config{
+		JWT: config.JWTConfig{
+			PrivateKeyPath:  privPath,
+			PublicKeyPath:   pubPath,
+			KeyID:           "test",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		},
+		APIKey: config.APIKeyConfig{TokenTTL: 10 * time.Minute, MaxPerUser: 3},
+	}
+}
+
+func TestRoutes_APIKeyTokenCannotManageAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := testConfig(t)
+	ring, err := keys.NewRing(cfg.JWT, zerolog.Nop())
+	require.NoError(t, err)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+	svc := service.NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, mockKeys, ring, nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "buyer@example.com", Status: model.UserStatusActive, EmailVerified: true}
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).AnyTimes()
+	mockKeys.EXPECT().CountActive(gomock.Any(), u.ID).Return(0, nil)
+	var stored *model.APIKey
+	mockKeys.EXPECT().Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, k *model.APIKey) error {
+			stored = k
+			return nil
+		})
+	owner := &model.Principal{Subject: u.ID.String(), AMR: []string{"pwd"}}
+	k, raw, err := svc.CreateAPIKey(context.Background(), owner, "cart-script", []string{"cart:write"}, nil)
+	require.NoError(t, err)
+	mockKeys.EXPECT().Get(gomock.Any(), k.Prefix).DoAndReturn(func(context.Context, string) (*model.APIKey, error) {
+		return stored, nil
+	})
+	mockKeys.EXPECT().Touch(gomock.Any(), k.ID).Return(nil)
+	token, err := svc.ExchangeAPIKey(context.Background(), raw)
+	require.NoError(t, err)
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, Services{Auth: svc}, cfg)
+
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/auth/mfa/totp"},
+		{http.MethodDelete, "/api/v1/auth/sessions/" + uuid.NewString()},
+		{http.MethodPost, "/api/v1/auth/logout-all"},
+		{http.MethodPost, "/api/v1/auth/api-keys"},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code,
+			"токен по API-ключу не должен управлять учетной записью: %s %s", tt.method, tt.path)
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// AuditHandler serves the admin API for the security audit log.
+type AuditHandler struct {
+	svc *service.AuditService
+}
+
+func NewAuditHandler(svc *service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// Events pages through the audit log, newest first. Supported filters: type,
+// outcome, actor, subject, ip, since and until (RFC 3339). The nextCursor of
+// a response is passed as cursor to get the next page.
+func (h *AuditHandler) Events(ctx *gin.Context) {
+	limit := int64(defaultPageLimit)
+	if raw := ctx.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit < 1 {
+			response.BadRequest(ctx, "некорректный параметр limit", nil)
+			return
+		}
+		limit = min(limit, maxPageLimit)
+	}
+	f, ok := auditFilter(ctx)
+	if !ok {
+		return
+	}
+	page, err := h.svc.Events(ctx.Request.Context(), f, ctx.Query("cursor"), int32(limit))
+	if err != nil {
+		if errors.Is(err, domainErr.ErrInvalidCursor) {
+			response.BadRequest(ctx, "некорректный параметр cursor", nil)
+			return
+		}
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.AuditEventsResponse{
+		Events:     make([]dto.AuditEventResponse, len(page.Events)),
+		NextCursor: page.NextCursor,
+	}
+	for i, e := range page.Events {
+		resp.Events[i] = dto.AuditEventResponse{
+			ID:        e.ID,
+			Type:      e.Type,
+			Outcome:   e.Outcome,
+			Actor:     e.Actor,
+			Subject:   e.Subject,
+			IP:        e.IP,
+			UserAgent: e.UserAgent,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func auditFilter(ctx *gin.Context) (user.AuditFilter, bool) {
+	f := user.AuditFilter{
+		Type:    ctx.Query("type"),
+		Outcome: ctx.Query("outcome"),
+		Actor:   ctx.Query("actor"),
+		Subject: ctx.Query("subject"),
+		IP:      ctx.Query("ip"),
+	}
+	if f.Outcome != "" && f.Outcome != user.AuditSuccess && f.Outcome != user.AuditFailure {
+		response.BadRequest(ctx, "некорректный параметр outcome", nil)
+		return f, false
+	}
+	for param, dst := range map[string]*time.Time{
+		"since": &f.Since,
+		"until": &f.Until,
+	} {
+		raw := ctx.Query(param)
+		if raw == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			response.BadRequest(ctx, "некорректный параметр "+param, nil)
+			return f, false
+		}
+		*dst = ts
+	}
+	return f, true
+}
@@ -4,10 +4,14 @@ import (
 	"errors"
 	"github.com/oidiral/e-commerce/services/auth-svc/config"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
 	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
 )
@@ -34,6 +38,9 @@ func (h *AuthHandler) Register(ctx *gin.Context) {
 
 	tokens, err := h.svc.RegisterUser(ctx.Request.Context(), req.Email, req.Password)
 	if err != nil {
+		if respondPasswordPolicy(ctx, err) {
+			return
+		}
 		switch {
 		case errors.Is(err, domainErr.ErrUserAlreadyExists):
 			response.RespondWithError(ctx, http.StatusConflict,
@@ -45,30 +52,94 @@ func (h *AuthHandler) Register(ctx *gin.Context) {
 			return
 		}
 	}
+	if tokens == nil {
+		ctx.JSON(http.StatusCreated, dto.PendingVerificationResponse{
+			Email:                req.Email,
+			VerificationRequired: true,
+		})
+		return
+	}
 
 	ctx.JSON(http.StatusCreated, tokens)
 }
 
+// VerifyEmail confirms an email address with the token from a verification
+// link.
+func (h *AuthHandler) VerifyEmail(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.VerifyEmail(ctx.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, domainErr.ErrInvalidToken) {
+			response.RespondWithError(ctx, http.StatusBadRequest,
+				"ссылка для подтверждения email недействительна или устарела", nil)
+			return
+		}
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ResendVerification always answers 202 so that it cannot be used to find out
+// which addresses are registered.
+func (h *AuthHandler) ResendVerification(ctx *gin.Context) {
+	var req dto.ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.ResendVerification(ctx.Request.Context(), req.Email); err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
 func (h *AuthHandler) Login(ctx *gin.Context) {
 	var req dto.SignInRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		handleValidationError(ctx, err)
 		return
 	}
-	tokens, err := h.svc.Login(ctx.Request.Context(), req.Email, req.Password)
+	res, err := h.svc.Login(ctx.Request.Context(), req.Email, req.Password)
 	if err != nil {
+		if respondLockout(ctx, err) {
+			return
+		}
 		switch {
 		case errors.Is(err, domainErr.ErrInvalidCredentials):
 			response.RespondWithError(ctx, http.StatusUnauthorized,
 				"неверный email или пароль", nil)
 			return
+		case errors.Is(err, domainErr.ErrUserDisabled):
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"учетная запись заблокирована", nil)
+			return
+		case errors.Is(err, domainErr.ErrEmailNotVerified):
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"email не подтвержден", nil)
+			return
 		default:
 			response.RespondWithError(ctx, http.StatusInternalServerError,
 				"внутренняя ошибка сервера", nil)
 			return
 		}
 	}
-	ctx.JSON(http.StatusOK, tokens)
+	if res.Challenge != nil {
+		ctx.JSON(http.StatusOK, dto.MFAChallengeResponse{
+			MFARequired:        true,
+			MFAToken:           res.Challenge.Token,
+			ExpiresAt:          res.Challenge.ExpiresAt,
+			EnrollmentRequired: res.Challenge.EnrollmentRequired,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, res.Tokens)
 }
 
 func (h *AuthHandler) Refresh(ctx *gin.Context) {
@@ -77,13 +148,29 @@ func (h *AuthHandler) Refresh(ctx *gin.Context) {
 		handleValidationError(ctx, err)
 		return
 	}
-	tokens, err := h.svc.Refresh(req.RefreshToken)
+	tokens, err := h.svc.Refresh(ctx.Request.Context(), req.RefreshToken)
 	if err != nil {
 		switch {
 		case errors.Is(err, domainErr.ErrInvalidToken):
 			response.RespondWithError(ctx, http.StatusUnauthorized,
 				"неверный токен", nil)
 			return
+		case errors.Is(err, domainErr.ErrTokenReused):
+			response.RespondWithError(ctx, http.StatusUnauthorized,
+				"токен уже был использован, сессия отозвана", nil)
+			return
+		case errors.Is(err, domainErr.ErrUserDisabled):
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"учетная запись заблокирована", nil)
+			return
+		case errors.Is(err, domainErr.ErrEmailNotVerified):
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"email не подтвержден", nil)
+			return
+		case errors.Is(err, domainErr.ErrMFARequired):
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"требуется повторный вход с двухфакторной аутентификацией", nil)
+			return
 		default:
 			response.RespondWithError(ctx, http.StatusInternalServerError,
 				"внутренняя ошибка сервера", nil)
@@ -93,24 +180,244 @@ func (h *AuthHandler) Refresh(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, tokens)
 }
 
-func (h *AuthHandler) ClientToken(ctx *gin.Context) {
-	var req dto.ClientCredentialsRequest
+// Token is the OAuth 2.0 token endpoint (RFC 6749). Client credentials are
+// taken from HTTP Basic authentication or from the request body, and errors
+// are reported with the standard OAuth error codes.
+func (h *AuthHandler) Token(ctx *gin.Context) {
+	var req dto.TokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response.RespondWithOAuthError(ctx, http.StatusBadRequest,
+			service.OAuthInvalidRequest, "malformed token request")
+		return
+	}
+	if id, secret, ok := basicClientAuth(ctx); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+
+	tokens, err := h.svc.Token(ctx.Request.Context(), service.TokenRequest{
+		GrantType:    req.GrantType,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Scope:        req.Scope,
+		Username:     req.Username,
+		Password:     req.Password,
+		RefreshToken: req.RefreshToken,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+
+		SubjectToken:       req.SubjectToken,
+		SubjectTokenType:   req.SubjectTokenType,
+		RequestedTokenType: req.RequestedTokenType,
+		ActorToken:         req.ActorToken,
+		Audience:           req.Audience,
+	})
+	if err != nil {
+		if respondOAuthLockout(ctx, err) {
+			return
+		}
+		var oauthErr *service.OAuthError
+		switch {
+		case errors.As(err, &oauthErr) && oauthErr.Code == service.OAuthInvalidClient:
+			ctx.Header("WWW-Authenticate", `Basic realm="auth"`)
+			response.RespondWithOAuthError(ctx, http.StatusUnauthorized, oauthErr.Code, oauthErr.Description)
+			return
+		case errors.As(err, &oauthErr):
+			response.RespondWithOAuthError(ctx, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+			return
+		default:
+			response.RespondWithOAuthError(ctx, http.StatusInternalServerError,
+				"server_error", "internal server error")
+			return
+		}
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(ctx *gin.Context) {
+	var req dto.LogoutRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		handleValidationError(ctx, err)
 		return
 	}
-	tokens, err := h.svc.ClientToken(ctx.Request.Context(), req.ClientID, req.ClientSecret)
+	if err := h.svc.Logout(ctx.Request.Context(), middleware.BearerToken(ctx), req.RefreshToken); err != nil {
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidToken):
+			response.RespondWithError(ctx, http.StatusUnauthorized,
+				"неверный токен", nil)
+			return
+		default:
+			response.RespondWithError(ctx, http.StatusInternalServerError,
+				"внутренняя ошибка сервера", nil)
+			return
+		}
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) LogoutAll(ctx *gin.Context) {
+	token := middleware.BearerToken(ctx)
+	if token == "" {
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"требуется access токен", nil)
+		return
+	}
+	if err := h.svc.LogoutAll(ctx.Request.Context(), token); err != nil {
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidToken):
+			response.RespondWithError(ctx, http.StatusUnauthorized,
+				"неверный токен", nil)
+			return
+		case errors.Is(err, domainErr.ErrForbidden):
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"токен, выданный по API-ключу, не может управлять учетной записью", nil)
+			return
+		default:
+			response.RespondWithError(ctx, http.StatusInternalServerError,
+				"внутренняя ошибка сервера", nil)
+			return
+		}
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RevokedTokens lists denylisted access tokens so that downstream services
+// can cache them. The optional since query parameter is a unix timestamp;
+// the list is paged with limit and offset. The caller must authenticate as a
+// client.
+func (h *AuthHandler) RevokedTokens(ctx *gin.Context) {
+	if !h.authenticateClient(ctx) {
+		return
+	}
+	since := time.Unix(0, 0)
+	if raw := ctx.Query("since"); raw != "" {
+		ts, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			response.BadRequest(ctx, "некорректный параметр since", nil)
+			return
+		}
+		since = time.Unix(ts, 0)
+	}
+	limit, offset, ok := pagination(ctx)
+	if !ok {
+		return
+	}
+	tokens, err := h.svc.RevokedTokens(ctx.Request.Context(), since, limit, offset)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.RevokedTokensResponse{Tokens: make([]dto.RevokedTokenResponse, len(tokens))}
+	for i, t := range tokens {
+		resp.Tokens[i] = dto.RevokedTokenResponse{JTI: t.JTI, ExpiresAt: t.ExpiresAt.Unix()}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// TokenRevocationStatus tells whether an access token is denylisted. The
+// caller must authenticate as a client.
+func (h *AuthHandler) TokenRevocationStatus(ctx *gin.Context) {
+	if !h.authenticateClient(ctx) {
+		return
+	}
+	jti := ctx.Param("jti")
+	revoked, err := h.svc.IsTokenRevoked(ctx.Request.Context(), jti)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.TokenRevocationStatus{JTI: jti, Revoked: revoked})
+}
+
+// Introspect implements RFC 7662. The caller must authenticate as a client,
+// either with HTTP Basic or with client_id and client_secret form fields.
+func (h *AuthHandler) Introspect(ctx *gin.Context) {
+	if !h.authenticateClient(ctx) {
+		return
+	}
+
+	var req dto.IntrospectRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	res, err := h.svc.Introspect(ctx.Request.Context(), req.Token)
 	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, dto.IntrospectionResponse{
+		Active:    res.Active,
+		TokenType: res.TokenType,
+		Sub:       res.Subject,
+		Email:     res.Email,
+		Roles:     res.Roles,
+		Perms:     res.Permissions,
+		ClientID:  res.ClientID,
+		Scope:     res.Scope,
+		Jti:       res.JTI,
+		Exp:       res.ExpiresAt,
+		Iat:       res.IssuedAt,
+	})
+}
+
+// authenticateClient checks the client credentials of the request. On
+// failure it writes the response and returns false.
+func (h *AuthHandler) authenticateClient(ctx *gin.Context) bool {
+	id, secret, ok := clientCredentials(ctx)
+	if !ok {
+		ctx.Header("WWW-Authenticate", `Basic realm="auth"`)
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"требуется аутентификация клиента", nil)
+		return false
+	}
+	if _, err := h.svc.AuthenticateClient(ctx.Request.Context(), id, secret); err != nil {
+		if respondLockout(ctx, err) {
+			return false
+		}
 		switch {
 		case errors.Is(err, domainErr.ErrInvalidCredentials):
+			ctx.Header("WWW-Authenticate", `Basic realm="auth"`)
 			response.RespondWithError(ctx, http.StatusUnauthorized,
 				"неверные учетные данные клиента", nil)
-			return
 		default:
 			response.RespondWithError(ctx, http.StatusInternalServerError,
 				"внутренняя ошибка сервера", nil)
-			return
 		}
+		return false
 	}
-	ctx.JSON(http.StatusOK, tokens)
+	return true
+}
+
+// clientCredentials reads client credentials from the Authorization header
+// (HTTP Basic) or, failing that, from the client_id and client_secret form
+// fields.
+func clientCredentials(ctx *gin.Context) (string, string, bool) {
+	if id, secret, ok := basicClientAuth(ctx); ok {
+		return id, secret, id != "" && secret != ""
+	}
+	id, secret := ctx.PostForm("client_id"), ctx.PostForm("client_secret")
+	return id, secret, id != "" && secret != ""
+}
+
+// basicClientAuth decodes HTTP Basic client credentials, which RFC 6749
+// section 2.3.1 requires to be form-urlencoded before being base64-encoded.
+func basicClientAuth(ctx *gin.Context) (string, string, bool) {
+	id, secret, ok := ctx.Request.BasicAuth()
+	if !ok {
+		return "", "", false
+	}
+	if v, err := url.QueryUnescape(id); err == nil {
+		id = v
+	}
+	if v, err := url.QueryUnescape(secret); err == nil {
+		secret = v
+	}
+	return id, secret, true
 }
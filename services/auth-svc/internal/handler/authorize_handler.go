@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+)
+
+const (
+	authorizePath = "/api/v1/auth/authorize"
+	// csrfCookie holds the double-submit token of the login form.
+	csrfCookie     = "authorize_csrf"
+	csrfTokenBytes = 32
+)
+
+//go:embed templates/authorize.html
+var authorizeHTML string
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(authorizeHTML))
+
+// AuthorizeHandler serves the OAuth authorization endpoint with its login
+// page, so that clients send users here instead of handling their
+// passwords.
+type AuthorizeHandler struct {
+	svc *service.AuthService
+	cfg *config.Config
+}
+
+func NewAuthorizeHandler(svc *service.AuthService, cfg *config.Config) *AuthorizeHandler {
+	return &AuthorizeHandler{svc: svc, cfg: cfg}
+}
+
+// authorizePage is the data of the login page template.
+type authorizePage struct {
+	Request   dto.AuthorizeRequest
+	CSRFToken string
+	Email     string
+	MFAToken  string
+	Error     string
+	// Fatal pages show just the error, without a form.
+	Fatal bool
+}
+
+// Show validates the authorization request and renders the login form.
+func (h *AuthorizeHandler) Show(ctx *gin.Context) {
+	var req dto.AuthorizeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderAuthorizeError(ctx, http.StatusBadRequest, "Некорректный запрос авторизации.")
+		return
+	}
+	if _, ok := h.validate(ctx, req); !ok {
+		return
+	}
+	token, err := utils.GenerateSecret(csrfTokenBytes)
+	if err != nil {
+		renderAuthorizeError(ctx, http.StatusInternalServerError, "Внутренняя ошибка сервера.")
+		return
+	}
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     authorizePath,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.cfg.JWT.Issuer, "https://"),
+		SameSite: http.SameSiteStrictMode,
+	})
+	renderAuthorizePage(ctx, http.StatusOK, authorizePage{Request: req, CSRFToken: token})
+}
+
+// Submit handles both steps of the login form. On success the user is
+// redirected to the client with an authorization code.
+func (h *AuthorizeHandler) Submit(ctx *gin.Context) {
+	var form dto.AuthorizeForm
+	if err := ctx.ShouldBind(&form); err != nil {
+		renderAuthorizeError(ctx, http.StatusBadRequest, "Некорректный запрос авторизации.")
+		return
+	}
+	if !validCSRFToken(ctx, form.CSRFToken) {
+		renderAuthorizeError(ctx, http.StatusForbidden, "Страница входа устарела. Вернитесь в приложение и начните вход заново.")
+		return
+	}
+	az, ok := h.validate(ctx, form.AuthorizeRequest)
+	if !ok {
+		return
+	}
+	if form.Cancel != "" {
+		ctx.Redirect(http.StatusFound, h.svc.ErrorRedirect(az,
+			&service.OAuthError{Code: service.OAuthAccessDenied, Description: "the user declined to sign in"}))
+		return
+	}
+
+	page := authorizePage{Request: form.AuthorizeRequest, CSRFToken: form.CSRFToken, Email: form.Email}
+	var (
+		res *service.AuthorizeResult
+		err error
+	)
+	if form.MFAToken != "" {
+		page.MFAToken = form.MFAToken
+		res, err = h.svc.AuthorizeWithMFA(ctx.Request.Context(), az, form.MFAToken, form.OTP, form.RecoveryCode)
+	} else {
+		res, err = h.svc.AuthorizeWithPassword(ctx.Request.Context(), az, form.Email, form.Password)
+	}
+	if err != nil {
+		respondAuthorizeError(ctx, page, err)
+		return
+	}
+	if res.Challenge != nil {
+		if res.Challenge.EnrollmentRequired {
+			page.Error = "Для вашей роли обязательна двухфакторная аутентификация. Настройте ее в приложении и повторите вход."
+			renderAuthorizePage(ctx, http.StatusForbidden, page)
+			return
+		}
+		page.MFAToken = res.Challenge.Token
+		renderAuthorizePage(ctx, http.StatusOK, page)
+		return
+	}
+	ctx.Redirect(http.StatusFound, res.RedirectURL)
+}
+
+// validate checks the authorization request. Errors that concern the client
+// are redirected to it, the others are shown on the page, since the redirect
+// URI cannot be trusted then.
+func (h *AuthorizeHandler) validate(ctx *gin.Context, req dto.AuthorizeRequest) (*service.Authorization, bool) {
+	az, err := h.svc.ValidateAuthorize(ctx.Request.Context(), service.AuthorizeRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+	})
+	var oauthErr *service.OAuthError
+	switch {
+	case err == nil:
+		return az, true
+	case errors.As(err, &oauthErr):
+		ctx.Redirect(http.StatusFound, h.svc.ErrorRedirect(az, oauthErr))
+	case errors.Is(err, domainErr.ErrInvalidClient):
+		renderAuthorizeError(ctx, http.StatusBadRequest, "Неизвестное приложение.")
+	case errors.Is(err, domainErr.ErrInvalidRedirectURI):
+		renderAuthorizeError(ctx, http.StatusBadRequest, "Адрес возврата не зарегистрирован для этого приложения.")
+	default:
+		renderAuthorizeError(ctx, http.StatusInternalServerError, "Внутренняя ошибка сервера.")
+	}
+	return nil, false
+}
+
+func respondAuthorizeError(ctx *gin.Context, page authorizePage, err error) {
+	var lockErr *domainErr.LockoutError
+	switch {
+	case errors.As(err, &lockErr):
+		ctx.Header("Retry-After", retryAfter(lockErr.Until))
+		page.Error = "Слишком много неудачных попыток входа. Попробуйте позже."
+		renderAuthorizePage(ctx, http.StatusTooManyRequests, page)
+	case errors.Is(err, domainErr.ErrInvalidCredentials):
+		page.Error = "Неверный email или пароль."
+		renderAuthorizePage(ctx, http.StatusUnauthorized, page)
+	case errors.Is(err, domainErr.ErrInvalidMFACode):
+		page.Error = "Неверный код подтверждения."
+		renderAuthorizePage(ctx, http.StatusUnauthorized, page)
+	case errors.Is(err, domainErr.ErrInvalidToken):
+		page.MFAToken = ""
+		page.Error = "Время на ввод кода истекло. Войдите заново."
+		renderAuthorizePage(ctx, http.StatusUnauthorized, page)
+	case errors.Is(err, domainErr.ErrUserDisabled):
+		page.Error = "Учетная запись заблокирована."
+		renderAuthorizePage(ctx, http.StatusForbidden, page)
+	case errors.Is(err, domainErr.ErrEmailNotVerified):
+		page.Error = "Подтвердите email, чтобы войти."
+		renderAuthorizePage(ctx, http.StatusForbidden, page)
+	default:
+		renderAuthorizeError(ctx, http.StatusInternalServerError, "Внутренняя ошибка сервера.")
+	}
+}
+
+// validCSRFToken compares the token posted by the form with its cookie.
+func validCSRFToken(ctx *gin.Context, token string) bool {
+	cookie, err := ctx.Cookie(csrfCookie)
+	if err != nil || cookie == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(token)) == 1
+}
+
+func renderAuthorizeError(ctx *gin.Context, code int, msg string) {
+	renderAuthorizePage(ctx, code, authorizePage{Error: msg, Fatal: true})
+}
+
+// renderAuthorizePage renders the login page. It must never be framed, lest
+// the user be tricked into signing in, and it loads nothing but inline
+// styles.
+func renderAuthorizePage(ctx *gin.Context, code int, page authorizePage) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("X-Frame-Options", "DENY")
+	ctx.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.Render(code, render.HTML{Template: authorizeTemplate, Name: "authorize", Data: page})
+	ctx.Abort()
+}
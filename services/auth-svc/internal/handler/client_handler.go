@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// ClientHandler serves the admin API for OAuth clients.
+type ClientHandler struct {
+	svc *service.ClientService
+}
+
+func NewClientHandler(svc *service.ClientService) *ClientHandler {
+	return &ClientHandler{svc: svc}
+}
+
+func (h *ClientHandler) Create(ctx *gin.Context) {
+	var req dto.CreateClientRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	cli, secret, err := h.svc.Create(ctx.Request.Context(), &user.Client{
+		ID:            req.ClientID,
+		Roles:         req.Roles,
+		AllowedScopes: req.AllowedScopes,
+		GrantTypes:    req.GrantTypes,
+		RedirectURIs:  req.RedirectURIs,
+		Public:        req.Public,
+
+		ExchangeAudiences: req.ExchangeAudiences,
+	}, actor(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErr.ErrClientAlreadyExists):
+			response.RespondWithError(ctx, http.StatusConflict,
+				"клиент с таким идентификатором уже существует", nil)
+			return
+		default:
+			response.RespondWithError(ctx, http.StatusInternalServerError,
+				"внутренняя ошибка сервера", nil)
+			return
+		}
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, dto.ClientSecretResponse{
+		ClientResponse: toClientResponse(cli),
+		ClientSecret:   secret,
+	})
+}
+
+func (h *ClientHandler) List(ctx *gin.Context) {
+	limit, offset, ok := pagination(ctx)
+	if !ok {
+		return
+	}
+	clients, err := h.svc.List(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.ClientsResponse{Clients: make([]dto.ClientResponse, len(clients))}
+	for i := range clients {
+		resp.Clients[i] = toClientResponse(&clients[i])
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ClientHandler) Get(ctx *gin.Context) {
+	cli, err := h.svc.Get(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toClientResponse(cli))
+}
+
+func (h *ClientHandler) Disable(ctx *gin.Context) {
+	if err := h.svc.Disable(ctx.Request.Context(), ctx.Param("id"), actor(ctx)); err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *ClientHandler) Enable(ctx *gin.Context) {
+	if err := h.svc.Enable(ctx.Request.Context(), ctx.Param("id"), actor(ctx)); err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// SetRedirectURIs replaces the redirect URIs the client may use in the
+// authorization code flow.
+func (h *ClientHandler) SetRedirectURIs(ctx *gin.Context) {
+	var req dto.UpdateClientRedirectURIsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	id := ctx.Param("id")
+	if err := h.svc.SetRedirectURIs(ctx.Request.Context(), id, req.RedirectURIs, actor(ctx)); err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	cli, err := h.svc.Get(ctx.Request.Context(), id)
+	if err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toClientResponse(cli))
+}
+
+// SetExchangeAudiences replaces the audiences the client may request tokens
+// for with the token exchange grant.
+func (h *ClientHandler) SetExchangeAudiences(ctx *gin.Context) {
+	var req dto.UpdateClientExchangeAudiencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	id := ctx.Param("id")
+	if err := h.svc.SetExchangeAudiences(ctx.Request.Context(), id, req.ExchangeAudiences, actor(ctx)); err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	cli, err := h.svc.Get(ctx.Request.Context(), id)
+	if err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toClientResponse(cli))
+}
+
+func (h *ClientHandler) Delete(ctx *gin.Context) {
+	if err := h.svc.Delete(ctx.Request.Context(), ctx.Param("id"), actor(ctx)); err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RotateSecret issues a new secret. The old one stays valid for the grace
+// period, which may be overridden in the request body.
+func (h *ClientHandler) RotateSecret(ctx *gin.Context) {
+	var req dto.RotateClientSecretRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			handleValidationError(ctx, err)
+			return
+		}
+	}
+	id := ctx.Param("id")
+	secret, previousExpiresAt, err := h.svc.RotateSecret(ctx.Request.Context(), id,
+		time.Duration(req.GraceSeconds)*time.Second, actor(ctx))
+	if err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	cli, err := h.svc.Get(ctx.Request.Context(), id)
+	if err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	resp := toClientResponse(cli)
+	resp.PreviousSecretExpiresAt = previousExpiresAt.UTC().Format(time.RFC3339)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, dto.ClientSecretResponse{
+		ClientResponse: resp,
+		ClientSecret:   secret,
+	})
+}
+
+func respondClientError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"клиент не найден", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
+
+func toClientResponse(c *user.Client) dto.ClientResponse {
+	resp := dto.ClientResponse{
+		ClientID:      c.ID,
+		Roles:         c.Roles,
+		AllowedScopes: c.AllowedScopes,
+		GrantTypes:    c.GrantTypes,
+		RedirectURIs:  c.RedirectURIs,
+		Public:        c.Public,
+		Status:        c.Status,
+		CreatedAt:     c.CreatedAt.UTC().Format(time.RFC3339),
+
+		ExchangeAudiences: c.ExchangeAudiences,
+	}
+	if c.PreviousSecretExpiresAt != nil && c.PreviousSecretExpiresAt.After(time.Now()) {
+		resp.PreviousSecretExpiresAt = c.PreviousSecretExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// pagination reads the limit and offset query parameters. On invalid input
+// it writes a 400 response and returns false.
+func pagination(ctx *gin.Context) (int32, int32, bool) {
+	limit, offset := int64(defaultPageLimit), int64(0)
+	var err error
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit < 1 {
+			response.BadRequest(ctx, "некорректный параметр limit", nil)
+			return 0, 0, false
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+	}
+	if raw := ctx.Query("offset"); raw != "" {
+		offset, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || offset < 0 {
+			response.BadRequest(ctx, "некорректный параметр offset", nil)
+			return 0, 0, false
+		}
+	}
+	return int32(limit), int32(offset), true
+}
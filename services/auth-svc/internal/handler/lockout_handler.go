@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// LockoutHandler serves the admin API for sign-in lockouts.
+type LockoutHandler struct {
+	svc *service.LockoutService
+}
+
+func NewLockoutHandler(svc *service.LockoutService) *LockoutHandler {
+	return &LockoutHandler{svc: svc}
+}
+
+func (h *LockoutHandler) UnlockUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := h.svc.UnlockUser(ctx.Request.Context(), id, actor(ctx)); err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *LockoutHandler) UnlockClient(ctx *gin.Context) {
+	if err := h.svc.UnlockClient(ctx.Request.Context(), ctx.Param("id"), actor(ctx)); err != nil {
+		respondClientError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *LockoutHandler) UnlockIP(ctx *gin.Context) {
+	ip := ctx.Param("ip")
+	if net.ParseIP(ip) == nil {
+		response.BadRequest(ctx, "некорректный IP-адрес", nil)
+		return
+	}
+	if err := h.svc.UnlockIP(ctx.Request.Context(), ip, actor(ctx)); err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *LockoutHandler) Events(ctx *gin.Context) {
+	limit, offset, ok := pagination(ctx)
+	if !ok {
+		return
+	}
+	events, err := h.svc.Events(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.LockoutEventsResponse{Events: make([]dto.LockoutEventResponse, len(events))}
+	for i, e := range events {
+		resp.Events[i] = dto.LockoutEventResponse{
+			ID:        e.ID,
+			Kind:      e.Kind,
+			Subject:   e.Subject,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			IP:        e.IP,
+			Failures:  e.Failures,
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if e.LockedUntil != nil {
+			resp.Events[i].LockedUntil = e.LockedUntil.UTC().Format(time.RFC3339)
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// respondLockout answers with 423 for a locked account or client and 429 for
+// a throttled IP address, with Retry-After set to the end of the lockout. It
+// reports false if err is not a lockout.
+func respondLockout(ctx *gin.Context, err error) bool {
+	var lockErr *domainErr.LockoutError
+	if !errors.As(err, &lockErr) {
+		return false
+	}
+	ctx.Header("Retry-After", retryAfter(lockErr.Until))
+	if errors.Is(err, domainErr.ErrAccountLocked) {
+		response.RespondWithError(ctx, http.StatusLocked,
+			"учетная запись временно заблокирована из-за неудачных попыток входа", nil)
+		return true
+	}
+	response.RespondWithError(ctx, http.StatusTooManyRequests,
+		"слишком много попыток входа, попробуйте позже", nil)
+	return true
+}
+
+// respondOAuthLockout is respondLockout for the OAuth token endpoint.
+func respondOAuthLockout(ctx *gin.Context, err error) bool {
+	var lockErr *domainErr.LockoutError
+	if !errors.As(err, &lockErr) {
+		return false
+	}
+	ctx.Header("Retry-After", retryAfter(lockErr.Until))
+	code := http.StatusTooManyRequests
+	if errors.Is(err, domainErr.ErrAccountLocked) {
+		code = http.StatusLocked
+	}
+	response.RespondWithOAuthError(ctx, code, service.OAuthTemporarilyUnavailable,
+		"too many failed attempts, retry later")
+	return true
+}
+
+func retryAfter(until time.Time) string {
+	return strconv.Itoa(int(math.Ceil(time.Until(until).Seconds())))
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// MFAHandler serves the second step of sign-in and the two-factor settings of
+// the signed-in user.
+type MFAHandler struct {
+	svc *service.AuthService
+}
+
+func NewMFAHandler(svc *service.AuthService) *MFAHandler {
+	return &MFAHandler{svc: svc}
+}
+
+// Verify exchanges an MFA challenge and a second factor for tokens.
+func (h *MFAHandler) Verify(ctx *gin.Context) {
+	var req dto.VerifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	tokens, err := h.svc.VerifyMFA(ctx.Request.Context(), req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// StartChallengeEnrollment enrolls an authenticator for a user whose role
+// requires one before the sign-in can finish.
+func (h *MFAHandler) StartChallengeEnrollment(ctx *gin.Context) {
+	var req dto.MFATokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	enrollment, err := h.svc.StartChallengeEnrollment(ctx.Request.Context(), req.MFAToken)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toTOTPEnrollmentResponse(enrollment))
+}
+
+func (h *MFAHandler) ConfirmChallengeEnrollment(ctx *gin.Context) {
+	var req dto.ConfirmMFAEnrollmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	res, err := h.svc.ConfirmChallengeEnrollment(ctx.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.MFAEnrollmentResponse{
+		RecoveryCodes: res.RecoveryCodes,
+		Tokens:        res.Tokens,
+	})
+}
+
+func (h *MFAHandler) Status(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	status, err := h.svc.MFAStatus(ctx.Request.Context(), id)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.MFAStatusResponse{
+		Enabled:           status.Enabled,
+		Required:          status.Required,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	})
+}
+
+// StartEnrollment generates a new authenticator secret for the signed-in
+// user. It has to be confirmed with a code before it is used at sign-in.
+func (h *MFAHandler) StartEnrollment(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	enrollment, err := h.svc.StartTOTPEnrollment(ctx.Request.Context(), id)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toTOTPEnrollmentResponse(enrollment))
+}
+
+func (h *MFAHandler) ConfirmEnrollment(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	codes, err := h.svc.ConfirmTOTPEnrollment(ctx.Request.Context(), id, req.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) Disable(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	var req dto.DisableMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.DisableTOTP(ctx.Request.Context(), id, req.Code, req.RecoveryCode); err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	codes, err := h.svc.RegenerateRecoveryCodes(ctx.Request.Context(), id, req.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Reset removes the authenticator of a user who lost it. It is an admin
+// endpoint.
+func (h *MFAHandler) Reset(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := h.svc.ResetMFA(ctx.Request.Context(), id, actor(ctx)); err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func respondMFAError(ctx *gin.Context, err error) {
+	if respondLockout(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domainErr.ErrInvalidToken):
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"сессия входа недействительна или устарела", nil)
+	case errors.Is(err, domainErr.ErrInvalidMFACode):
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"неверный код подтверждения", nil)
+	case errors.Is(err, domainErr.ErrMFAAlreadyEnabled):
+		response.RespondWithError(ctx, http.StatusConflict,
+			"двухфакторная аутентификация уже включена", nil)
+	case errors.Is(err, domainErr.ErrMFANotEnabled):
+		response.RespondWithError(ctx, http.StatusConflict,
+			"двухфакторная аутентификация не включена", nil)
+	case errors.Is(err, domainErr.ErrMFARequired):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"двухфакторная аутентификация обязательна для вашей роли", nil)
+	case errors.Is(err, domainErr.ErrUserDisabled):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"учетная запись заблокирована", nil)
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"пользователь не найден", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
+
+// principalUserID returns the ID of the signed-in user. Client tokens have
+// no user behind them and are rejected.
+func principalUserID(ctx *gin.Context) (uuid.UUID, bool) {
+	p := middleware.PrincipalFrom(ctx)
+	if p == nil {
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"требуется аутентификация", nil)
+		return uuid.UUID{}, false
+	}
+	id, err := uuid.Parse(p.Subject)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"доступно только пользователям", nil)
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+func toTOTPEnrollmentResponse(e *service.TOTPEnrollment) dto.TOTPEnrollmentResponse {
+	return dto.TOTPEnrollmentResponse{Secret: e.Secret, OTPAuthURI: e.URI}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// OIDCHandler serves the OpenID Connect discovery document and the userinfo
+// endpoint.
+type OIDCHandler struct {
+	svc *service.AuthService
+	cfg *config.Config
+}
+
+func NewOIDCHandler(svc *service.AuthService, cfg *config.Config) *OIDCHandler {
+	return &OIDCHandler{svc: svc, cfg: cfg}
+}
+
+func (h *OIDCHandler) Discovery(ctx *gin.Context) {
+	iss := h.cfg.JWT.Issuer
+	ctx.JSON(http.StatusOK, dto.OpenIDConfiguration{
+		Issuer:                 iss,
+		JWKSURI:                iss + "/.well-known/jwks.json",
+		AuthorizationEndpoint:  iss + authorizePath,
+		TokenEndpoint:          iss + "/api/v1/auth/token",
+		UserinfoEndpoint:       iss + "/api/v1/auth/userinfo",
+		IntrospectionEndpoint:  iss + "/api/v1/auth/introspect",
+		ScopesSupported:        service.OIDCScopes,
+		ResponseTypesSupported: []string{service.ResponseTypeCode},
+		GrantTypesSupported: []string{
+			service.GrantAuthorizationCode,
+			service.GrantPassword,
+			service.GrantRefreshToken,
+			service.GrantClientCredentials,
+			service.GrantTokenExchange,
+		},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  h.svc.SigningAlgorithms(),
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{service.PKCEMethodS256},
+		ClaimsSupported:                   service.OIDCClaims,
+
+		AuthorizationResponseIssParameterSupported: iss != "",
+	})
+}
+
+// UserInfo returns the claims about the signed-in user that the scopes of the
+// access token allow. Errors are reported as RFC 6750 bearer token errors.
+func (h *OIDCHandler) UserInfo(ctx *gin.Context) {
+	info, err := h.svc.UserInfo(ctx.Request.Context(), middleware.PrincipalFrom(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidScope):
+			ctx.Header("WWW-Authenticate", `Bearer realm="auth", error="insufficient_scope", scope="openid"`)
+			response.RespondWithError(ctx, http.StatusForbidden,
+				"токен выдан без scope openid", nil)
+		case errors.Is(err, domainErr.ErrInvalidToken), errors.Is(err, domainErr.ErrUserDisabled):
+			ctx.Header("WWW-Authenticate", `Bearer realm="auth", error="invalid_token"`)
+			response.RespondWithError(ctx, http.StatusUnauthorized,
+				"неверный токен", nil)
+		default:
+			response.RespondWithError(ctx, http.StatusInternalServerError,
+				"внутренняя ошибка сервера", nil)
+		}
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, info)
+}
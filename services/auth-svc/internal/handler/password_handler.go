@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+type PasswordHandler struct {
+	svc *service.PasswordService
+}
+
+func NewPasswordHandler(svc *service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{svc: svc}
+}
+
+// Forgot answers 202 for a well-formed request, whether or not the address
+// belongs to an account, and 429 once the address or the caller made too
+// many requests.
+func (h *PasswordHandler) Forgot(ctx *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.Forgot(ctx.Request.Context(), req.Email); err != nil {
+		var lockErr *domainErr.LockoutError
+		if errors.As(err, &lockErr) {
+			ctx.Header("Retry-After", retryAfter(lockErr.Until))
+			response.RespondWithError(ctx, http.StatusTooManyRequests,
+				"слишком много запросов на сброс пароля, попробуйте позже", nil)
+			return
+		}
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+func (h *PasswordHandler) Reset(ctx *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.Reset(ctx.Request.Context(), req.Token, req.Password); err != nil {
+		if respondPasswordPolicy(ctx, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidToken):
+			response.BadRequest(ctx, "ссылка для сброса пароля недействительна или устарела", nil)
+			return
+		default:
+			response.RespondWithError(ctx, http.StatusInternalServerError,
+				"внутренняя ошибка сервера", nil)
+			return
+		}
+	}
+	ctx.Status(http.StatusNoContent)
+}
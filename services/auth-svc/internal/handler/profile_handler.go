@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// ProfileHandler lets the signed-in user see its account and change its
+// password and email.
+type ProfileHandler struct {
+	svc *service.AuthService
+}
+
+func NewProfileHandler(svc *service.AuthService) *ProfileHandler {
+	return &ProfileHandler{svc: svc}
+}
+
+func (h *ProfileHandler) Me(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	u, err := h.svc.Profile(ctx.Request.Context(), id)
+	if err != nil {
+		respondProfileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.ProfileResponse{
+		ID:            u.ID.String(),
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Roles:         u.Roles,
+		MFAEnabled:    u.MFAEnabled,
+		CreatedAt:     u.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// ChangePassword sets a new password and signs the user out everywhere but
+// in the session of the access token. Tokens without a session ID sign the
+// user out of every session.
+func (h *ProfileHandler) ChangePassword(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	sessionID, _ := uuid.Parse(middleware.PrincipalFrom(ctx).SessionID)
+	if err := h.svc.ChangePassword(ctx.Request.Context(), id, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		respondProfileError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ChangeEmail answers 202: the address changes once the user follows the
+// link mailed to it.
+func (h *ProfileHandler) ChangeEmail(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	var req dto.ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.ChangeEmail(ctx.Request.Context(), id, req.Password, req.Email); err != nil {
+		respondProfileError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+func respondProfileError(ctx *gin.Context, err error) {
+	if respondLockout(ctx, err) || respondPasswordPolicy(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domainErr.ErrInvalidCredentials):
+		response.RespondWithError(ctx, http.StatusUnauthorized,
+			"неверный текущий пароль", nil)
+	case errors.Is(err, domainErr.ErrUserAlreadyExists):
+		response.RespondWithError(ctx, http.StatusConflict,
+			"пользователь с таким email уже зарегистрирован", nil)
+	case errors.Is(err, domainErr.ErrEmailUnchanged):
+		response.BadRequest(ctx, "новый email совпадает с текущим", nil)
+	case errors.Is(err, domainErr.ErrUserDisabled):
+		response.RespondWithError(ctx, http.StatusForbidden,
+			"учетная запись заблокирована", nil)
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"пользователь не найден", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// RoleHandler serves the admin API for roles, permissions and role
+// assignments of users and clients.
+type RoleHandler struct {
+	svc *service.RoleService
+}
+
+func NewRoleHandler(svc *service.RoleService) *RoleHandler {
+	return &RoleHandler{svc: svc}
+}
+
+func (h *RoleHandler) List(ctx *gin.Context) {
+	roles, err := h.svc.List(ctx.Request.Context())
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.RolesResponse{Roles: make([]dto.RoleResponse, len(roles))}
+	for i := range roles {
+		resp.Roles[i] = toRoleResponse(&roles[i])
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *RoleHandler) Get(ctx *gin.Context) {
+	role, err := h.svc.Get(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toRoleResponse(role))
+}
+
+func (h *RoleHandler) Create(ctx *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	role, err := h.svc.Create(ctx.Request.Context(), req.Name, req.Permissions, actor(ctx))
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toRoleResponse(role))
+}
+
+func (h *RoleHandler) Delete(ctx *gin.Context) {
+	if err := h.svc.Delete(ctx.Request.Context(), ctx.Param("name"), actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) AddPermission(ctx *gin.Context) {
+	var req dto.PermissionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.AddPermission(ctx.Request.Context(), ctx.Param("name"), req.Permission, actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) RemovePermission(ctx *gin.Context) {
+	err := h.svc.RemovePermission(ctx.Request.Context(), ctx.Param("name"), ctx.Param("permission"), actor(ctx))
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) GrantToUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req dto.RoleAssignmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.GrantToUser(ctx.Request.Context(), id, req.Role, actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) RevokeFromUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := h.svc.RevokeFromUser(ctx.Request.Context(), id, ctx.Param("role"), actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) GrantToClient(ctx *gin.Context) {
+	var req dto.RoleAssignmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.GrantToClient(ctx.Request.Context(), ctx.Param("id"), req.Role, actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) RevokeFromClient(ctx *gin.Context) {
+	if err := h.svc.RevokeFromClient(ctx.Request.Context(), ctx.Param("id"), ctx.Param("role"), actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *RoleHandler) SetClientRoles(ctx *gin.Context) {
+	var req dto.UpdateClientRolesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.SetClientRoles(ctx.Request.Context(), ctx.Param("id"), req.Roles, actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// SetMFA requires or waives a second factor for the users of a role.
+func (h *RoleHandler) SetMFA(ctx *gin.Context) {
+	var req dto.RoleMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		handleValidationError(ctx, err)
+		return
+	}
+	if err := h.svc.SetMFARequired(ctx.Request.Context(), ctx.Param("name"), *req.Required, actor(ctx)); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Changes lists the recorded role changes, newest first.
+func (h *RoleHandler) Changes(ctx *gin.Context) {
+	limit, offset, ok := pagination(ctx)
+	if !ok {
+		return
+	}
+	changes, err := h.svc.Changes(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.RoleChangesResponse{Changes: make([]dto.RoleChangeResponse, len(changes))}
+	for i, c := range changes {
+		resp.Changes[i] = dto.RoleChangeResponse{
+			ID:          c.ID,
+			Actor:       c.Actor,
+			Action:      c.Action,
+			Role:        c.Role,
+			SubjectType: c.SubjectType,
+			SubjectID:   c.SubjectID,
+			Permission:  c.Permission,
+			CreatedAt:   c.CreatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func respondRoleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErr.ErrRoleNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"роль не найдена", nil)
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"объект не найден", nil)
+	case errors.Is(err, domainErr.ErrRoleAlreadyExists):
+		response.RespondWithError(ctx, http.StatusConflict,
+			"роль с таким именем уже существует", nil)
+	case errors.Is(err, domainErr.ErrRoleProtected):
+		response.RespondWithError(ctx, http.StatusConflict,
+			"встроенную роль нельзя удалить", nil)
+	case errors.Is(err, domainErr.ErrInvalidRoleName):
+		response.BadRequest(ctx, "некорректное имя роли", nil)
+	case errors.Is(err, domainErr.ErrInvalidPermission):
+		response.BadRequest(ctx, "некорректное имя разрешения", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
+
+func toRoleResponse(r *user.Role) dto.RoleResponse {
+	perms := r.Permissions
+	if perms == nil {
+		perms = []string{}
+	}
+	return dto.RoleResponse{ID: r.ID, Name: r.Name, Permissions: perms, MFARequired: r.MFARequired}
+}
+
+// actor identifies the admin making a change: the subject of their token.
+func actor(ctx *gin.Context) string {
+	if p := middleware.PrincipalFrom(ctx); p != nil {
+		return p.Subject
+	}
+	return ""
+}
@@ -7,16 +7,134 @@ import (
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
 )
 
-func RegisterRoutes(router *gin.Engine, authService *service.AuthService, cfg *config.Config) {
+// Services are the application services the HTTP handlers delegate to.
+type Services struct {
+	Auth     *service.AuthService
+	Clients  *service.ClientService
+	Users    *service.UserService
+	Roles    *service.RoleService
+	Password *service.PasswordService
+	Lockouts *service.LockoutService
+	Audit    *service.AuditService
+}
+
+func RegisterRoutes(router *gin.Engine, svc Services, cfg *config.Config) {
 	router.Use(middleware.ApiErrorMiddleware())
+	router.Use(middleware.RequestInfo())
 
-	authHandler := NewAuthHandler(authService, cfg)
+	authHandler := NewAuthHandler(svc.Auth, cfg)
+	passwordHandler := NewPasswordHandler(svc.Password)
+	mfaHandler := NewMFAHandler(svc.Auth)
+	apiKeyHandler := NewAPIKeyHandler(svc.Auth)
+	oidcHandler := NewOIDCHandler(svc.Auth, cfg)
+	authorizeHandler := NewAuthorizeHandler(svc.Auth, cfg)
 	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+	router.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
 	api := router.Group("/api/v1/auth")
 	{
 		api.POST("/register", authHandler.Register)
 		api.POST("/login", authHandler.Login)
+		api.POST("/login/mfa", mfaHandler.Verify)
+		api.POST("/login/mfa/totp", mfaHandler.StartChallengeEnrollment)
+		api.POST("/login/mfa/totp/confirm", mfaHandler.ConfirmChallengeEnrollment)
+		api.POST("/api-keys/token", apiKeyHandler.Exchange)
 		api.POST("/refresh", authHandler.Refresh)
-		api.POST("/token", authHandler.ClientToken)
+		api.GET("/authorize", authorizeHandler.Show)
+		api.POST("/authorize", authorizeHandler.Submit)
+		api.POST("/token", authHandler.Token)
+		api.POST("/logout", authHandler.Logout)
+		api.POST("/logout-all", authHandler.LogoutAll)
+		api.GET("/revoked", authHandler.RevokedTokens)
+		api.GET("/revoked/:jti", authHandler.TokenRevocationStatus)
+		api.POST("/introspect", authHandler.Introspect)
+		api.POST("/password/forgot", passwordHandler.Forgot)
+		api.POST("/password/reset", passwordHandler.Reset)
+		api.POST("/verify-email", authHandler.VerifyEmail)
+		api.POST("/verify-email/resend", authHandler.ResendVerification)
+	}
+
+	mfa := api.Group("/mfa", middleware.BearerAuth(svc.Auth), middleware.RejectAPIKeyTokens())
+	{
+		mfa.GET("", mfaHandler.Status)
+		mfa.POST("/totp", mfaHandler.StartEnrollment)
+		mfa.POST("/totp/confirm", mfaHandler.ConfirmEnrollment)
+		mfa.POST("/totp/disable", mfaHandler.Disable)
+		mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	}
+
+	userinfo := middleware.BearerAuth(svc.Auth)
+	api.GET("/userinfo", userinfo, oidcHandler.UserInfo)
+	api.POST("/userinfo", userinfo, oidcHandler.UserInfo)
+
+	profileHandler := NewProfileHandler(svc.Auth)
+	me := api.Group("/me", middleware.BearerAuth(svc.Auth))
+	{
+		me.GET("", profileHandler.Me)
+		me.POST("/password", profileHandler.ChangePassword)
+		me.POST("/email", profileHandler.ChangeEmail)
+	}
+
+	sessionHandler := NewSessionHandler(svc.Auth)
+	sessions := api.Group("/sessions", middleware.BearerAuth(svc.Auth), middleware.RejectAPIKeyTokens())
+	{
+		sessions.GET("", sessionHandler.List)
+		sessions.DELETE("/:sessionId", sessionHandler.Revoke)
+	}
+
+	apiKeys := api.Group("/api-keys", middleware.BearerAuth(svc.Auth), middleware.RejectAPIKeyTokens())
+	{
+		apiKeys.POST("", apiKeyHandler.Create)
+		apiKeys.GET("", apiKeyHandler.List)
+		apiKeys.DELETE("/:keyId", apiKeyHandler.Revoke)
+	}
+
+	clientHandler := NewClientHandler(svc.Clients)
+	userHandler := NewUserHandler(svc.Users)
+	roleHandler := NewRoleHandler(svc.Roles)
+	lockoutHandler := NewLockoutHandler(svc.Lockouts)
+	auditHandler := NewAuditHandler(svc.Audit)
+	admin := api.Group("/admin", middleware.BearerAuth(svc.Auth), middleware.RequireRole("admin"))
+	{
+		admin.POST("/clients", clientHandler.Create)
+		admin.GET("/clients", clientHandler.List)
+		admin.GET("/clients/:id", clientHandler.Get)
+		admin.PUT("/clients/:id/roles", roleHandler.SetClientRoles)
+		admin.POST("/clients/:id/roles", roleHandler.GrantToClient)
+		admin.DELETE("/clients/:id/roles/:role", roleHandler.RevokeFromClient)
+		admin.POST("/clients/:id/disable", clientHandler.Disable)
+		admin.POST("/clients/:id/enable", clientHandler.Enable)
+		admin.POST("/clients/:id/secret", clientHandler.RotateSecret)
+		admin.PUT("/clients/:id/redirect-uris", clientHandler.SetRedirectURIs)
+		admin.PUT("/clients/:id/exchange-audiences", clientHandler.SetExchangeAudiences)
+		admin.POST("/clients/:id/unlock", lockoutHandler.UnlockClient)
+		admin.DELETE("/clients/:id", clientHandler.Delete)
+
+		admin.GET("/users", userHandler.List)
+		admin.GET("/users/:id", userHandler.Get)
+		admin.POST("/users/:id/disable", userHandler.Disable)
+		admin.POST("/users/:id/enable", userHandler.Enable)
+		admin.POST("/users/:id/unlock", lockoutHandler.UnlockUser)
+		admin.DELETE("/users/:id", userHandler.Delete)
+		admin.POST("/users/:id/roles", roleHandler.GrantToUser)
+		admin.DELETE("/users/:id/roles/:role", roleHandler.RevokeFromUser)
+		admin.DELETE("/users/:id/mfa", mfaHandler.Reset)
+		admin.GET("/users/:id/sessions", sessionHandler.ListForUser)
+		admin.DELETE("/users/:id/sessions/:sessionId", sessionHandler.RevokeForUser)
+		admin.GET("/users/:id/api-keys", apiKeyHandler.ListForUser)
+		admin.DELETE("/users/:id/api-keys/:keyId", apiKeyHandler.RevokeForUser)
+
+		admin.GET("/roles", roleHandler.List)
+		admin.POST("/roles", roleHandler.Create)
+		admin.GET("/roles/:name", roleHandler.Get)
+		admin.DELETE("/roles/:name", roleHandler.Delete)
+		admin.POST("/roles/:name/permissions", roleHandler.AddPermission)
+		admin.DELETE("/roles/:name/permissions/:permission", roleHandler.RemovePermission)
+		admin.PUT("/roles/:name/mfa", roleHandler.SetMFA)
+		admin.GET("/role-changes", roleHandler.Changes)
+
+		admin.POST("/ips/:ip/unlock", lockoutHandler.UnlockIP)
+		admin.GET("/lockouts", lockoutHandler.Events)
+
+		admin.GET("/audit-events", auditHandler.Events)
 	}
 }
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/middleware"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// SessionHandler lists and revokes the sessions of the signed-in user and,
+// for admins, of any user.
+type SessionHandler struct {
+	svc *service.AuthService
+}
+
+func NewSessionHandler(svc *service.AuthService) *SessionHandler {
+	return &SessionHandler{svc: svc}
+}
+
+func (h *SessionHandler) List(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	h.list(ctx, id)
+}
+
+func (h *SessionHandler) Revoke(ctx *gin.Context) {
+	id, ok := principalUserID(ctx)
+	if !ok {
+		return
+	}
+	h.revoke(ctx, id)
+}
+
+func (h *SessionHandler) ListForUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.list(ctx, id)
+}
+
+func (h *SessionHandler) RevokeForUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.revoke(ctx, id)
+}
+
+func (h *SessionHandler) list(ctx *gin.Context, userID uuid.UUID) {
+	sessions, err := h.svc.ListSessions(ctx.Request.Context(), userID)
+	if err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	var current string
+	if p := middleware.PrincipalFrom(ctx); p != nil {
+		current = p.SessionID
+	}
+	resp := dto.SessionsResponse{Sessions: make([]dto.SessionResponse, len(sessions))}
+	for i, s := range sessions {
+		resp.Sessions[i] = toSessionResponse(&s, current)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *SessionHandler) revoke(ctx *gin.Context, userID uuid.UUID) {
+	sessionID, err := uuid.Parse(ctx.Param("sessionId"))
+	if err != nil {
+		response.BadRequest(ctx, "некорректный идентификатор сессии", nil)
+		return
+	}
+	if err := h.svc.RevokeSession(ctx.Request.Context(), userID, sessionID, actor(ctx)); err != nil {
+		respondSessionError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func respondSessionError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"сессия не найдена", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
+
+func toSessionResponse(s *user.Session, current string) dto.SessionResponse {
+	return dto.SessionResponse{
+		ID:              s.ID.String(),
+		UserAgent:       s.UserAgent,
+		IP:              s.IP,
+		CreatedAt:       s.CreatedAt.UTC().Format(time.RFC3339),
+		LastRefreshedAt: s.LastRefreshedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:       s.ExpiresAt.UTC().Format(time.RFC3339),
+		Current:         current != "" && s.ID.String() == current,
+	}
+}
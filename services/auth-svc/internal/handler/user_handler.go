@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	domainErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/service"
+)
+
+// UserHandler serves the admin user management API.
+type UserHandler struct {
+	svc *service.UserService
+}
+
+func NewUserHandler(svc *service.UserService) *UserHandler {
+	return &UserHandler{svc: svc}
+}
+
+// List pages through users. Supported filters: email (prefix), status, role,
+// createdAfter and createdBefore (RFC 3339).
+func (h *UserHandler) List(ctx *gin.Context) {
+	limit, offset, ok := pagination(ctx)
+	if !ok {
+		return
+	}
+	f, ok := userFilter(ctx)
+	if !ok {
+		return
+	}
+	users, err := h.svc.List(ctx.Request.Context(), f, limit, offset)
+	if err != nil {
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+		return
+	}
+	resp := dto.UsersResponse{Users: make([]dto.UserResponse, len(users))}
+	for i := range users {
+		resp.Users[i] = toUserResponse(&users[i])
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) Get(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	u, err := h.svc.Get(ctx.Request.Context(), id)
+	if err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toUserResponse(u))
+}
+
+func (h *UserHandler) Disable(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := h.svc.Disable(ctx.Request.Context(), id, actor(ctx)); err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) Enable(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := h.svc.Enable(ctx.Request.Context(), id, actor(ctx)); err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) Delete(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(ctx.Request.Context(), id, actor(ctx)); err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func respondUserError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErr.ErrNotFound):
+		response.RespondWithError(ctx, http.StatusNotFound,
+			"пользователь не найден", nil)
+	default:
+		response.RespondWithError(ctx, http.StatusInternalServerError,
+			"внутренняя ошибка сервера", nil)
+	}
+}
+
+func userIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		response.BadRequest(ctx, "некорректный идентификатор пользователя", nil)
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+func userFilter(ctx *gin.Context) (user.UserFilter, bool) {
+	f := user.UserFilter{
+		EmailPrefix: ctx.Query("email"),
+		Role:        ctx.Query("role"),
+	}
+	if raw := ctx.Query("status"); raw != "" {
+		status, err := strconv.ParseInt(raw, 10, 16)
+		if err != nil || (int16(status) != user.UserStatusActive && int16(status) != user.UserStatusDisabled) {
+			response.BadRequest(ctx, "некорректный параметр status", nil)
+			return f, false
+		}
+		f.Status = int16(status)
+	}
+	for param, dst := range map[string]*time.Time{
+		"createdAfter":  &f.CreatedAfter,
+		"createdBefore": &f.CreatedBefore,
+	} {
+		raw := ctx.Query(param)
+		if raw == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			response.BadRequest(ctx, "некорректный параметр "+param, nil)
+			return f, false
+		}
+		*dst = ts
+	}
+	return f, true
+}
+
+func toUserResponse(u *user.User) dto.UserResponse {
+	roles := u.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return dto.UserResponse{
+		ID:        u.ID.String(),
+		Email:     u.Email,
+		Status:    u.Status,
+		Roles:     roles,
+		CreatedAt: u.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"net/http"
 
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/dto"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passpolicy"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
 )
 
@@ -40,3 +43,37 @@ func handleValidationError(ctx *gin.Context, err error) {
 	response.RespondWithError(ctx, http.StatusBadRequest,
 		"некорректный запрос", nil)
 }
+
+// respondPasswordPolicy answers 400 listing the broken rules if err is a
+// *passpolicy.Error, and tells whether it did.
+func respondPasswordPolicy(ctx *gin.Context, err error) bool {
+	var pe *passpolicy.Error
+	if !errors.As(err, &pe) {
+		return false
+	}
+	violations := make([]dto.PasswordViolation, len(pe.Violations))
+	for i, v := range pe.Violations {
+		violations[i] = dto.PasswordViolation{Code: v.Rule, Message: passwordViolationMessage(v), Limit: v.Limit}
+	}
+	response.BadRequest(ctx, "пароль не соответствует требованиям", violations)
+	return true
+}
+
+func passwordViolationMessage(v passpolicy.Violation) string {
+	switch v.Rule {
+	case passpolicy.RuleMinLength:
+		return fmt.Sprintf("пароль должен содержать не менее %d символов", v.Limit)
+	case passpolicy.RuleMaxLength:
+		return fmt.Sprintf("пароль должен содержать не более %d символов", v.Limit)
+	case passpolicy.RuleCharClasses:
+		return fmt.Sprintf("пароль должен сочетать не менее %d видов символов из строчных и заглавных букв, цифр и прочих символов", v.Limit)
+	case passpolicy.RuleContainsEmail:
+		return "пароль не должен содержать email"
+	case passpolicy.RuleBreached:
+		return "пароль найден в утечках данных, выберите другой"
+	case passpolicy.RuleReused:
+		return fmt.Sprintf("пароль не должен совпадать с последними %d паролями", v.Limit)
+	default:
+		return "пароль не соответствует требованиям"
+	}
+}
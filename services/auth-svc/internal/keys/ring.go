@@ -0,0 +1,295 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/rs/zerolog"
+)
+
+// MetadataFile is the name of the file inside the keys directory that lists
+// the keys and their status.
+const MetadataFile = "keys.json"
+
+type Status string
+
+const (
+	// StatusActive marks the single key new tokens are signed with.
+	StatusActive Status = "active"
+	// StatusVerifyOnly keys are still published and accepted, but no longer
+	// used for signing.
+	StatusVerifyOnly Status = "verify"
+	// StatusRetired keys are neither published nor accepted.
+	StatusRetired Status = "retired"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrNoActive   = errors.New("key ring has no active key")
+)
+
+// Algorithms are the signing algorithms keys can have. The algorithm of a key
+// follows from its type: RS256 for RSA, ES256 for ECDSA P-256 and EdDSA for
+// Ed25519 keys.
+var Algorithms = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+type Key struct {
+	ID      string
+	Status  Status
+	Private crypto.Signer
+	// Method signs and verifies tokens with the key.
+	Method jwt.SigningMethod
+}
+
+type metadata struct {
+	Keys []struct {
+		ID     string `json:"kid"`
+		File   string `json:"file"`
+		Status Status `json:"status"`
+	} `json:"keys"`
+}
+
+// Ring holds the signing keys of the service. It is safe for concurrent use
+// and can be reloaded while tokens are being signed and verified.
+type Ring struct {
+	cfg config.JWTConfig
+	log zerolog.Logger
+
+	mu     sync.RWMutex
+	active *Key
+	keys   map[string]*Key
+	jwks   json.RawMessage
+	algs   []string
+}
+
+// NewRing loads the keys described by cfg. When KeysDir is set the keys are
+// read from its keys.json, otherwise PrivateKeyPath is used as the only,
+// active key under KeyID.
+func NewRing(cfg config.JWTConfig, log zerolog.Logger) (*Ring, error) {
+	r := &Ring{cfg: cfg, log: log}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the keys from disk. On error the previously loaded keys
+// stay in use.
+func (r *Ring) Reload() error {
+	var (
+		loaded []*Key
+		err    error
+	)
+	if r.cfg.KeysDir != "" {
+		loaded, err = loadDir(r.cfg.KeysDir)
+	} else {
+		loaded, err = loadSingle(r.cfg.PrivateKeyPath, r.cfg.KeyID)
+	}
+	if err != nil {
+		return err
+	}
+
+	var (
+		active *Key
+		algs   []string
+	)
+	byID := make(map[string]*Key, len(loaded))
+	set := jwk.NewSet()
+	for _, k := range loaded {
+		if _, dup := byID[k.ID]; dup {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		byID[k.ID] = k
+		switch k.Status {
+		case StatusActive:
+			if active != nil {
+				return fmt.Errorf("more than one active key: %q and %q", active.ID, k.ID)
+			}
+			active = k
+		case StatusVerifyOnly, StatusRetired:
+		default:
+			return fmt.Errorf("key %q: unknown status %q", k.ID, k.Status)
+		}
+		if k.Status == StatusRetired {
+			continue
+		}
+		pub, err := publicJWK(k)
+		if err != nil {
+			return err
+		}
+		if err := set.AddKey(pub); err != nil {
+			return fmt.Errorf("add key %q to set: %w", k.ID, err)
+		}
+		if !slices.Contains(algs, k.Method.Alg()) {
+			algs = append(algs, k.Method.Alg())
+		}
+	}
+	if active == nil {
+		return ErrNoActive
+	}
+	buf, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("marshal jwks: %w", err)
+	}
+
+	r.mu.Lock()
+	r.active, r.keys, r.jwks, r.algs = active, byID, buf, algs
+	r.mu.Unlock()
+
+	r.log.Info().
+		Str("active_kid", active.ID).
+		Str("active_alg", active.Method.Alg()).
+		Int("keys", len(byID)).
+		Msg("JWT keys loaded successfully")
+	return nil
+}
+
+// Active returns the key new tokens must be signed with.
+func (r *Ring) Active() *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// PublicKey returns the verification key for kid. Retired and unknown keys
+// are rejected, and so are tokens signed with another algorithm than that of
+// the key, so that a token cannot pick how its signature is checked.
+func (r *Ring) PublicKey(kid, alg string) (crypto.PublicKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[kid]
+	if !ok || k.Status == StatusRetired {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if k.Method.Alg() != alg {
+		return nil, fmt.Errorf("key %q is for %s, not %s", kid, k.Method.Alg(), alg)
+	}
+	return k.Private.Public(), nil
+}
+
+// SigningAlgorithms returns the algorithms of the published keys, the one of
+// the active key first.
+func (r *Ring) SigningAlgorithms() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	algs := make([]string, 0, len(r.algs))
+	algs = append(algs, r.active.Method.Alg())
+	for _, alg := range r.algs {
+		if alg != algs[0] {
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWKS returns the JSON Web Key Set with every non-retired public key.
+func (r *Ring) JWKS() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.jwks
+}
+
+func loadDir(dir string) ([]*Key, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("read key metadata: %w", err)
+	}
+	var meta metadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, fmt.Errorf("parse key metadata: %w", err)
+	}
+	keys := make([]*Key, 0, len(meta.Keys))
+	for _, m := range meta.Keys {
+		if m.ID == "" || m.File == "" {
+			return nil, errors.New("key metadata entries need kid and file")
+		}
+		path := m.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		priv, method, err := readPrivateKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", m.ID, err)
+		}
+		keys = append(keys, &Key{ID: m.ID, Status: m.Status, Private: priv, Method: method})
+	}
+	return keys, nil
+}
+
+func loadSingle(path, kid string) ([]*Key, error) {
+	priv, method, err := readPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return []*Key{{ID: kid, Status: StatusActive, Private: priv, Method: method}}, nil
+}
+
+// readPrivateKey reads a PEM encoded PKCS #8, PKCS #1 or SEC 1 private key
+// and returns it with the signing method for its type.
+func readPrivateKey(path string) (crypto.Signer, jwt.SigningMethod, error) {
+	privPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(privPEM)
+	if block == nil {
+		return nil, nil, errors.New("failed to decode private key PEM")
+	}
+	var parsedKey any
+	if parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if parsedKey, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return nil, nil, errors.New("failed to parse private key")
+			}
+		}
+	}
+	return signerOf(parsedKey)
+}
+
+func signerOf(key any) (crypto.Signer, jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, nil, fmt.Errorf("unsupported ECDSA curve %s, only P-256 is supported", k.Curve.Params().Name)
+		}
+		return k, jwt.SigningMethodES256, nil
+	case ed25519.PrivateKey:
+		return k, jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// publicJWK returns the public half of k as a JWK. The key type and curve
+// (kty and crv) follow from the key, alg from its signing method.
+func publicJWK(k *Key) (jwk.Key, error) {
+	jwkKey, err := jwk.FromRaw(k.Private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("create JWK from public key %q: %w", k.ID, err)
+	}
+	_ = jwkKey.Set(jwk.KeyIDKey, k.ID)
+	_ = jwkKey.Set(jwk.AlgorithmKey, k.Method.Alg())
+	_ = jwkKey.Set(jwk.KeyUsageKey, "sig")
+	return jwkKey, nil
+}
@@ -0,0 +1,152 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir, name string) {
+	t.Helper()
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, name, privKey)
+}
+
+func writePrivateKey(t *testing.T, dir, name string, privKey any) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(privKey)
+	require.NoError(t, err)
+	privPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), privPem, 0600))
+}
+
+func writeMetadata(t *testing.T, dir, body string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, MetadataFile), []byte(body), 0644))
+}
+
+func TestRing_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "old.pem")
+	writeKey(t, dir, "current.pem")
+	writeKey(t, dir, "ancient.pem")
+	writeMetadata(t, dir, `{"keys": [
+		{"kid": "old", "file": "old.pem", "status": "verify"},
+		{"kid": "current", "file": "current.pem", "status": "active"},
+		{"kid": "ancient", "file": "ancient.pem", "status": "retired"}
+	]}`)
+
+	ring, err := NewRing(config.JWTConfig{KeysDir: dir}, zerolog.Nop())
+	require.NoError(t, err)
+
+	assert.Equal(t, "current", ring.Active().ID)
+
+	_, err = ring.PublicKey("old", "RS256")
+	assert.NoError(t, err, "ключ verify-only должен приниматься при проверке")
+	_, err = ring.PublicKey("ancient", "RS256")
+	assert.ErrorIs(t, err, ErrUnknownKey, "отозванный ключ не должен приниматься")
+
+	set, err := jwk.Parse(ring.JWKS())
+	require.NoError(t, err)
+	assert.Equal(t, 2, set.Len(), "в JWKS публикуются только не отозванные ключи")
+	_, ok := set.LookupKeyID("ancient")
+	assert.False(t, ok)
+}
+
+func TestRing_ReloadKeepsKeysOnError(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "a.pem")
+	writeKey(t, dir, "b.pem")
+	writeMetadata(t, dir, `{"keys": [{"kid": "a", "file": "a.pem", "status": "active"}]}`)
+
+	ring, err := NewRing(config.JWTConfig{KeysDir: dir}, zerolog.Nop())
+	require.NoError(t, err)
+
+	writeMetadata(t, dir, `{"keys": [
+		{"kid": "a", "file": "a.pem", "status": "active"},
+		{"kid": "b", "file": "b.pem", "status": "active"}
+	]}`)
+	assert.Error(t, ring.Reload(), "два активных ключа недопустимы")
+	assert.Equal(t, "a", ring.Active().ID)
+
+	writeMetadata(t, dir, `{"keys": [
+		{"kid": "a", "file": "a.pem", "status": "verify"},
+		{"kid": "b", "file": "b.pem", "status": "active"}
+	]}`)
+	require.NoError(t, ring.Reload())
+	assert.Equal(t, "b", ring.Active().ID)
+	_, err = ring.PublicKey("a", "RS256")
+	assert.NoError(t, err)
+}
+
+func TestRing_MixedKeyTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "rsa.pem")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ec.pem", ecKey)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ed.pem", edKey)
+	writeMetadata(t, dir, `{"keys": [
+		{"kid": "rsa", "file": "rsa.pem", "status": "verify"},
+		{"kid": "ec", "file": "ec.pem", "status": "active"},
+		{"kid": "ed", "file": "ed.pem", "status": "verify"}
+	]}`)
+
+	ring, err := NewRing(config.JWTConfig{KeysDir: dir}, zerolog.Nop())
+	require.NoError(t, err)
+
+	assert.Equal(t, "ES256", ring.Active().Method.Alg(), "алгоритм выбирается по типу ключа")
+	assert.Equal(t, []string{"ES256", "RS256", "EdDSA"}, ring.SigningAlgorithms())
+
+	_, err = ring.PublicKey("rsa", "RS256")
+	assert.NoError(t, err)
+	_, err = ring.PublicKey("rsa", "ES256")
+	assert.Error(t, err, "токен не может выбрать алгоритм, отличный от алгоритма ключа")
+
+	set, err := jwk.Parse(ring.JWKS())
+	require.NoError(t, err)
+	for kid, want := range map[string][3]string{
+		"rsa": {"RSA", "", "RS256"},
+		"ec":  {"EC", "P-256", "ES256"},
+		"ed":  {"OKP", "Ed25519", "EdDSA"},
+	} {
+		key, ok := set.LookupKeyID(kid)
+		require.True(t, ok, kid)
+		assert.Equal(t, want[0], key.KeyType().String(), kid)
+		crv, _ := key.Get("crv")
+		if want[1] == "" {
+			assert.Nil(t, crv, kid)
+		} else {
+			assert.Equal(t, want[1], fmt.Sprint(crv), kid)
+		}
+		assert.Equal(t, want[2], key.Algorithm().String(), kid)
+	}
+}
+
+func TestRing_RejectsUnsupportedCurves(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "p384.pem", key)
+	writeMetadata(t, dir, `{"keys": [{"kid": "p384", "file": "p384.pem", "status": "active"}]}`)
+
+	_, err = NewRing(config.JWTConfig{KeysDir: dir}, zerolog.Nop())
+	assert.Error(t, err)
+}
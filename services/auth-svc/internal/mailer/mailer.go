@@ -0,0 +1,37 @@
+// Package mailer delivers the e-mails sent by the auth service.
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Mailer selected by cfg.Driver.
+func New(cfg config.MailConfig, log zerolog.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	case DriverLog, "":
+		return NewLogMailer(cfg.Dir, log), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
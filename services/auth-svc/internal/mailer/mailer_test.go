@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMailer_WritesEml(t *testing.T) {
+	dir := t.TempDir()
+	m := NewLogMailer(dir, zerolog.Nop())
+
+	err := m.Send(context.Background(), Message{To: "user@example.com", Subject: "Сброс пароля", Body: "ссылка"})
+	assert.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		data, err := os.ReadFile(dir + "/" + entries[0].Name())
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(entries[0].Name(), ".eml"))
+		assert.Contains(t, string(data), "To: user@example.com\r\n")
+		assert.Contains(t, string(data), "Subject: =?utf-8?q?", "тема должна быть закодирована по RFC 2047")
+		assert.True(t, strings.HasSuffix(string(data), "\r\n\r\nссылка"))
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/response"
+)
+
+const principalKey = "principal"
+
+// TokenVerifier checks an access token and returns the caller it was issued
+// to.
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*model.Principal, error)
+}
+
+// BearerAuth requires a valid access token in the Authorization header and
+// stores the resulting principal in the gin context.
+func BearerAuth(v TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := BearerToken(c)
+		if token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="auth"`)
+			response.RespondWithError(c, http.StatusUnauthorized,
+				"требуется access токен", nil)
+			return
+		}
+		p, err := v.VerifyAccessToken(c.Request.Context(), token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="auth", error="invalid_token"`)
+			response.RespondWithError(c, http.StatusUnauthorized,
+				"неверный токен", nil)
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+// RequireRole lets the request through only if the principal set by
+// BearerAuth has one of the given roles. Tokens obtained with an API key are
+// refused whatever they carry.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := PrincipalFrom(c)
+		if p == nil {
+			response.RespondWithError(c, http.StatusUnauthorized,
+				"требуется access токен", nil)
+			return
+		}
+		if p.FromAPIKey() {
+			response.RespondWithError(c, http.StatusForbidden,
+				"недостаточно прав", nil)
+			return
+		}
+		for _, r := range roles {
+			if p.HasRole(r) {
+				c.Next()
+				return
+			}
+		}
+		response.RespondWithError(c, http.StatusForbidden,
+			"недостаточно прав", nil)
+	}
+}
+
+// RequirePermission lets the request through only if the principal set by
+// BearerAuth has the given permission. Tokens obtained with an API key are
+// refused whatever they carry.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := PrincipalFrom(c)
+		if p == nil {
+			response.RespondWithError(c, http.StatusUnauthorized,
+				"требуется access токен", nil)
+			return
+		}
+		if p.FromAPIKey() || !p.HasPermission(permission) {
+			response.RespondWithError(c, http.StatusForbidden,
+				"недостаточно прав", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectAPIKeyTokens refuses tokens obtained with an API key. Keys are meant
+// for scripts, so such tokens must not manage the account they belong to:
+// its second factor, sessions and keys.
+func RejectAPIKeyTokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := PrincipalFrom(c)
+		if p == nil {
+			response.RespondWithError(c, http.StatusUnauthorized,
+				"требуется access токен", nil)
+			return
+		}
+		if p.FromAPIKey() {
+			response.RespondWithError(c, http.StatusForbidden,
+				"токен, выданный по API-ключу, не может управлять учетной записью", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the principal stored by BearerAuth, or nil.
+func PrincipalFrom(c *gin.Context) *model.Principal {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	p, _ := v.(*model.Principal)
+	return p
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer" header.
+func BearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[len("Bearer "):])
+}
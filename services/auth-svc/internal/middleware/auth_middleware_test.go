@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticVerifier accepts any token as the principal p.
+type staticVerifier struct {
+	p *model.Principal
+}
+
+func (v staticVerifier) VerifyAccessToken(context.Context, string) (*model.Principal, error) {
+	return v.p, nil
+}
+
+// adminRouter mounts a route the way the admin group of the auth API is.
+func adminRouter(p *model.Principal) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ApiErrorMiddleware())
+	admin := r.Group("/admin", BearerAuth(staticVerifier{p}), RequireRole("admin"))
+	admin.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	admin.DELETE("/users/:id/mfa", RequirePermission("mfa:reset"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r
+}
+
+func serve(r *gin.Engine, method, path string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireRole_APIKeyTokenForbidden(t *testing.T) {
+	admin := &model.Principal{Subject: "1", Roles: []string{"admin"}, Permissions: []string{"mfa:reset"}, AMR: []string{"pwd"}}
+	assert.Equal(t, http.StatusOK, serve(adminRouter(admin), http.MethodGet, "/admin/users"))
+	assert.Equal(t, http.StatusNoContent, serve(adminRouter(admin), http.MethodDelete, "/admin/users/1/mfa"))
+
+	// Even a token that claims the role must not open the admin API if it
+	// was obtained with an API key.
+	key := &model.Principal{Subject: "1", Roles: []string{"admin"}, Permissions: []string{"mfa:reset"}, AMR: []string{model.AMRAPIKey}}
+	assert.Equal(t, http.StatusForbidden, serve(adminRouter(key), http.MethodGet, "/admin/users"),
+		"токен по API-ключу не должен проходить проверку роли")
+
+	r := gin.New()
+	r.Use(ApiErrorMiddleware())
+	r.DELETE("/users/:id/mfa", BearerAuth(staticVerifier{key}), RequirePermission("mfa:reset"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodDelete, "/users/1/mfa"),
+		"токен по API-ключу не должен проходить проверку разрешения")
+}
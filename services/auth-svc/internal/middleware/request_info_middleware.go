@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+)
+
+// RequestInfo makes the caller's IP address and user agent available to
+// services through the request context.
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := requestinfo.WithClientIP(c.Request.Context(), c.ClientIP())
+		ctx = requestinfo.WithUserAgent(ctx, c.Request.UserAgent())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
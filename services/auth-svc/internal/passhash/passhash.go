@@ -0,0 +1,106 @@
+// Package passhash hashes passwords and client secrets. New hashes use the
+// configured algorithm, argon2id by default, and are encoded in the PHC
+// string format; bcrypt hashes are still verified so that existing rows keep
+// working, and Verify tells when a stored hash should be upgraded.
+package passhash
+
+import (
+	"strings"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported algorithms.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// Defaults used for zero-valued fields of config.HashConfig. The argon2id
+// parameters follow the second recommended option of RFC 9106.
+const (
+	DefaultMemory      = 64 * 1024
+	DefaultIterations  = 3
+	DefaultParallelism = 2
+	DefaultBcryptCost  = bcrypt.DefaultCost
+
+	saltLength = 16
+	keyLength  = 32
+)
+
+// Hasher hashes and verifies passwords with fixed parameters.
+type Hasher struct {
+	algorithm   string
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	bcryptCost  int
+}
+
+// New returns a Hasher for cfg. Zero-valued fields select the defaults.
+func New(cfg config.HashConfig) *Hasher {
+	h := &Hasher{
+		algorithm:   cfg.Algorithm,
+		memory:      cfg.Argon2Memory,
+		iterations:  cfg.Argon2Iterations,
+		parallelism: cfg.Argon2Parallelism,
+		bcryptCost:  cfg.BcryptCost,
+	}
+	if h.algorithm == "" {
+		h.algorithm = Argon2id
+	}
+	if h.memory == 0 {
+		h.memory = DefaultMemory
+	}
+	if h.iterations == 0 {
+		h.iterations = DefaultIterations
+	}
+	if h.parallelism == 0 {
+		h.parallelism = DefaultParallelism
+	}
+	if h.bcryptCost == 0 {
+		h.bcryptCost = DefaultBcryptCost
+	}
+	return h
+}
+
+// Hash hashes password with the configured algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == Bcrypt {
+		b, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return h.hashArgon2id(password)
+}
+
+// Verify reports whether password matches encoded and, if it does, whether
+// encoded was made with another algorithm or other parameters than Hash
+// would use now and should be replaced. Malformed hashes never match.
+func (h *Hasher) Verify(password, encoded string) (match, rehash bool) {
+	switch {
+	case strings.HasPrefix(encoded, "$"+Argon2id+"$"):
+		p, err := decodeArgon2id(encoded)
+		if err != nil || !p.matches(password) {
+			return false, false
+		}
+		return true, h.algorithm != Argon2id ||
+			p.memory != h.memory || p.iterations != h.iterations || p.parallelism != h.parallelism ||
+			len(p.key) != keyLength
+	case isBcrypt(encoded):
+		if bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return true, h.algorithm != Bcrypt || err != nil || cost != h.bcryptCost
+	default:
+		return false, false
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,98 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testConfig keeps argon2id cheap enough for tests.
+func testConfig() config.HashConfig {
+	return config.HashConfig{Argon2Memory: 1024, Argon2Iterations: 1, Argon2Parallelism: 1}
+}
+
+func TestHasher_Argon2idRoundTrip(t *testing.T) {
+	h := New(testConfig())
+
+	hash, err := h.Hash("s3cret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), "хеш должен быть в формате PHC")
+
+	match, rehash := h.Verify("s3cret", hash)
+	assert.True(t, match)
+	assert.False(t, rehash, "хеш с текущими параметрами не нужно обновлять")
+
+	match, _ = h.Verify("wrong", hash)
+	assert.False(t, match)
+
+	other, err := h.Hash("s3cret")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "соль должна быть случайной")
+}
+
+func TestHasher_VerifiesBcryptAndAsksForUpgrade(t *testing.T) {
+	h := New(testConfig())
+	legacy, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	match, rehash := h.Verify("s3cret", string(legacy))
+	assert.True(t, match, "старые bcrypt-хеши должны продолжать работать")
+	assert.True(t, rehash)
+
+	match, rehash = h.Verify("wrong", string(legacy))
+	assert.False(t, match)
+	assert.False(t, rehash)
+}
+
+func TestHasher_RehashOnParameterChange(t *testing.T) {
+	old := New(testConfig())
+	hash, err := old.Hash("s3cret")
+	require.NoError(t, err)
+
+	cfg := testConfig()
+	cfg.Argon2Iterations = 2
+	match, rehash := New(cfg).Verify("s3cret", hash)
+	assert.True(t, match, "хеш проверяется с параметрами из самой строки")
+	assert.True(t, rehash, "при смене параметров хеш нужно обновить")
+}
+
+func TestHasher_BcryptAlgorithm(t *testing.T) {
+	h := New(config.HashConfig{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost})
+
+	hash, err := h.Hash("s3cret")
+	require.NoError(t, err)
+	match, rehash := h.Verify("s3cret", hash)
+	assert.True(t, match)
+	assert.False(t, rehash)
+
+	cheaper, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost+1)
+	require.NoError(t, err)
+	_, rehash = h.Verify("s3cret", string(cheaper))
+	assert.True(t, rehash, "при смене стоимости хеш нужно обновить")
+
+	argon, err := New(testConfig()).Hash("s3cret")
+	require.NoError(t, err)
+	match, rehash = h.Verify("s3cret", argon)
+	assert.True(t, match)
+	assert.True(t, rehash)
+}
+
+func TestHasher_MalformedHash(t *testing.T) {
+	h := New(testConfig())
+	for _, encoded := range []string{
+		"",
+		"plaintext",
+		"$argon2id$v=19$m=1024,t=1,p=1$not-base64$",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$2a$10$short",
+	} {
+		match, rehash := h.Verify("s3cret", encoded)
+		assert.False(t, match, "некорректный хеш %q не должен совпадать", encoded)
+		assert.False(t, rehash)
+	}
+}
@@ -0,0 +1,62 @@
+package passpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// rangePrefixLen is the length of the hash prefix a range file is named by.
+const rangePrefixLen = 5
+
+// breachedList looks passwords up in a directory of range files, see
+// config.PasswordConfig.BreachedDir. Only the file of the prefix of a
+// password's hash is read, so the list can be the full multi-gigabyte one.
+type breachedList struct {
+	dir string
+	log zerolog.Logger
+}
+
+// contains tells whether password is on the list. A list that cannot be
+// read lets every password through: sign-ups should not fail because of it.
+func (b *breachedList) contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:rangePrefixLen], hash[rangePrefixLen:]
+
+	f, err := b.open(prefix)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			b.log.Error().Err(err).Str("prefix", prefix).Msg("open breached password range")
+		}
+		return false
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		b.log.Error().Err(err).Str("prefix", prefix).Msg("read breached password range")
+	}
+	return false
+}
+
+func (b *breachedList) open(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(b.dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = os.Open(filepath.Join(b.dir, prefix+".txt"))
+	}
+	return f, err
+}
@@ -0,0 +1,160 @@
+// Package passpolicy checks new passwords against the configured policy:
+// length, mixed character classes, no relation to the email, no reuse of
+// recent passwords and no appearance in a local list of breached passwords.
+package passpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/rs/zerolog"
+)
+
+// Codes of the rules a password can violate.
+const (
+	RuleMinLength     = "min_length"
+	RuleMaxLength     = "max_length"
+	RuleCharClasses   = "character_classes"
+	RuleContainsEmail = "contains_email"
+	RuleBreached      = "breached"
+	RuleReused        = "reused"
+)
+
+// minLocalPartLen is the shortest local part of an email that passwords may
+// not contain. Shorter ones, like "a" or "jo", would reject too much.
+const minLocalPartLen = 3
+
+// Violation is a rule a password broke. Limit is the configured bound of
+// the length, character class and reuse rules.
+type Violation struct {
+	Rule  string
+	Limit int
+}
+
+// Error lists the rules a password broke. It wraps ErrWeakPassword.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		rules[i] = v.Rule
+	}
+	return fmt.Sprintf("%s: %s", AppErr.ErrWeakPassword, strings.Join(rules, ", "))
+}
+
+func (e *Error) Unwrap() error {
+	return AppErr.ErrWeakPassword
+}
+
+// Policy checks passwords against config.PasswordConfig.
+type Policy struct {
+	cfg      config.PasswordConfig
+	hasher   *passhash.Hasher
+	breached *breachedList
+}
+
+// New returns the policy of cfg. Previous passwords are compared with
+// hasher.
+func New(cfg config.PasswordConfig, hasher *passhash.Hasher, log zerolog.Logger) *Policy {
+	p := &Policy{cfg: cfg, hasher: hasher}
+	if cfg.BreachedDir != "" {
+		p.breached = &breachedList{dir: cfg.BreachedDir, log: log}
+	}
+	return p
+}
+
+// Check returns an *Error if password may not be set for the account with
+// the given email. previous are the hashes of the latest passwords of the
+// account, newest first; only as many as the policy remembers are compared.
+func (p *Policy) Check(password, email string, previous []string) error {
+	var violations []Violation
+	n := utf8.RuneCountInString(password)
+	if p.cfg.MinLength > 0 && n < p.cfg.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Limit: p.cfg.MinLength})
+	}
+	if p.cfg.MaxLength > 0 && n > p.cfg.MaxLength {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Limit: p.cfg.MaxLength})
+		// Long passwords are not hashed against the history or the list.
+		return &Error{Violations: violations}
+	}
+	if p.cfg.MinClasses > 0 && charClasses(password) < p.cfg.MinClasses {
+		violations = append(violations, Violation{Rule: RuleCharClasses, Limit: p.cfg.MinClasses})
+	}
+	if containsEmail(password, email) {
+		violations = append(violations, Violation{Rule: RuleContainsEmail})
+	}
+	if p.breached != nil && p.breached.contains(password) {
+		violations = append(violations, Violation{Rule: RuleBreached})
+	}
+	if p.reused(password, previous) {
+		violations = append(violations, Violation{Rule: RuleReused, Limit: p.cfg.History})
+	}
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+// History is how many previous password hashes Check compares.
+func (p *Policy) History() int {
+	return p.cfg.History
+}
+
+func (p *Policy) reused(password string, previous []string) bool {
+	if len(previous) > p.cfg.History {
+		previous = previous[:p.cfg.History]
+	}
+	for _, hash := range previous {
+		if match, _ := p.hasher.Verify(password, hash); match {
+			return true
+		}
+	}
+	return false
+}
+
+// charClasses counts which of lowercase letters, uppercase letters, digits
+// and other characters password contains.
+func charClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			n++
+		}
+	}
+	return n
+}
+
+// containsEmail tells whether password contains the email or its local
+// part, ignoring case.
+func containsEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	password = strings.ToLower(password)
+	if strings.Contains(password, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(local) >= minLocalPartLen && strings.Contains(password, local)
+}
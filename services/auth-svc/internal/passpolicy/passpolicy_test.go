@@ -0,0 +1,99 @@
+package passpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rules(err error) []string {
+	var pe *Error
+	if !errors.As(err, &pe) {
+		return nil
+	}
+	var out []string
+	for _, v := range pe.Violations {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestPolicy_Check(t *testing.T) {
+	hasher := passhash.New(config.HashConfig{Algorithm: "bcrypt", BcryptCost: 4})
+	old, err := hasher.Hash("Winter-2023")
+	require.NoError(t, err)
+	older, err := hasher.Hash("Autumn-2022")
+	require.NoError(t, err)
+	p := New(config.PasswordConfig{MinLength: 8, MaxLength: 20, MinClasses: 3, History: 2}, hasher, zerolog.Nop())
+
+	tests := []struct {
+		name     string
+		password string
+		previous []string
+		want     []string
+	}{
+		{name: "strong", password: "Correct-Horse-9", previous: []string{old, older}},
+		{name: "short", password: "Ab1-", want: []string{RuleMinLength}},
+		{name: "long", password: strings.Repeat("Ab1-", 6), want: []string{RuleMaxLength}},
+		{name: "length in characters", password: "Пароль-Надежный-1"},
+		{name: "one class", password: "correcthorse", want: []string{RuleCharClasses}},
+		{name: "email", password: "Buyer@Example.com1", want: []string{RuleContainsEmail}},
+		{name: "local part", password: "my-BUYER-9", want: []string{RuleContainsEmail}},
+		{name: "current password", password: "Winter-2023", previous: []string{old, older}, want: []string{RuleReused}},
+		{name: "older password", password: "Autumn-2022", previous: []string{old, older}, want: []string{RuleReused}},
+		{name: "forgotten password", password: "Autumn-2022", previous: []string{old, old, older}},
+		{name: "several rules", password: "buyer", want: []string{RuleMinLength, RuleCharClasses, RuleContainsEmail}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.password, "buyer@example.com", tt.previous)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, AppErr.ErrWeakPassword)
+			assert.Equal(t, tt.want, rules(err))
+		})
+	}
+}
+
+func TestPolicy_Check_ContainsEmail(t *testing.T) {
+	p := New(config.PasswordConfig{}, nil, zerolog.Nop())
+
+	assert.Equal(t, []string{RuleContainsEmail}, rules(p.Check("x-Buyer@Example.com-x", "buyer@example.com", nil)))
+	assert.NoError(t, p.Check("jo-password", "jo@example.com", nil),
+		"короткая локальная часть email не должна запрещать пароли")
+	assert.NoError(t, p.Check("anything", "", nil))
+}
+
+func TestPolicy_Check_Breached(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha1.Sum([]byte("P@ssw0rd"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	range1 := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":52256\r\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(range1), 0o600))
+
+	p := New(config.PasswordConfig{BreachedDir: dir}, nil, zerolog.Nop())
+	assert.Equal(t, []string{RuleBreached}, rules(p.Check("P@ssw0rd", "", nil)))
+	assert.NoError(t, p.Check("p@ssw0rd", "", nil), "диапазона для хэша нет — пароль не считается утекшим")
+
+	require.NoError(t, os.Rename(filepath.Join(dir, hash[:5]+".txt"), filepath.Join(dir, hash[:5])))
+	assert.Equal(t, []string{RuleBreached}, rules(p.Check("P@ssw0rd", "", nil)),
+		"файлы диапазонов могут быть без расширения")
+}
+
+func TestPolicy_Check_BreachedListMissing(t *testing.T) {
+	p := New(config.PasswordConfig{BreachedDir: filepath.Join(t.TempDir(), "gone")}, nil, zerolog.Nop())
+	assert.NoError(t, p.Check("P@ssw0rd", "", nil), "недоступный список не должен блокировать пароли")
+}
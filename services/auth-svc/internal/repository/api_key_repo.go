@@ -0,0 +1,123 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	appErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// APIKeyRepository stores the API keys of users, the secrets only by hash.
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *model.APIKey) error
+	// Get returns the key with the given prefix, revoked and expired ones
+	// included, or ErrNotFound.
+	Get(ctx context.Context, prefix string) (*model.APIKey, error)
+	// List returns the keys of the user that were not revoked, newest first.
+	List(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error)
+	// CountActive counts the keys of the user that are neither revoked nor
+	// expired.
+	CountActive(ctx context.Context, userID uuid.UUID) (int, error)
+	// Revoke returns ErrNotFound if the user has no such key that is not
+	// revoked yet.
+	Revoke(ctx context.Context, userID, keyID uuid.UUID) error
+	Touch(ctx context.Context, keyID uuid.UUID) error
+	// DeleteEnded removes the keys that were revoked or expired and returns
+	// how many were deleted.
+	DeleteEnded(ctx context.Context) (int64, error)
+}
+
+type APIKeyRepo struct {
+	q *db.Queries
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool) APIKeyRepository {
+	return &APIKeyRepo{q: db.New(pool)}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *model.APIKey) error {
+	params := db.CreateAPIKeyParams{
+		ID:         pgtype.UUID{Bytes: k.ID, Valid: true},
+		UserID:     pgtype.UUID{Bytes: k.UserID, Valid: true},
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		SecretHash: k.SecretHash,
+		Scopes:     nonNil(k.Scopes),
+	}
+	if k.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *k.ExpiresAt, Valid: true}
+	}
+	if err := r.q.CreateAPIKey(ctx, params); err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) Get(ctx context.Context, prefix string) (*model.APIKey, error) {
+	row, err := r.q.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("get api key by prefix: %w", err)
+	}
+	k := toDomainFromAPIKey(row)
+	return &k, nil
+}
+
+func (r *APIKeyRepo) List(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	rows, err := r.q.ListUserAPIKeys(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("list user api keys: %w", err)
+	}
+	keys := make([]model.APIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = toDomainFromAPIKey(row)
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepo) CountActive(ctx context.Context, userID uuid.UUID) (int, error) {
+	n, err := r.q.CountActiveUserAPIKeys(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("count active user api keys: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	n, err := r.q.RevokeUserAPIKey(ctx, db.RevokeUserAPIKeyParams{
+		ID:     pgtype.UUID{Bytes: keyID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("revoke user api key: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) Touch(ctx context.Context, keyID uuid.UUID) error {
+	if err := r.q.TouchAPIKey(ctx, pgtype.UUID{Bytes: keyID, Valid: true}); err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) DeleteEnded(ctx context.Context) (int64, error) {
+	n, err := r.q.DeleteEndedAPIKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete ended api keys: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,90 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+)
+
+// AuditRepository keeps the append-only audit log.
+type AuditRepository interface {
+	audit.Store
+	// List returns up to limit events matching f, newest first.
+	List(ctx context.Context, f model.AuditFilter, limit int32) ([]model.AuditEvent, error)
+	// DeleteBefore purges the events created before t and returns how many
+	// there were.
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type AuditRepo struct {
+	q *db.Queries
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) AuditRepository {
+	return &AuditRepo{q: db.New(pool)}
+}
+
+func (r *AuditRepo) Append(ctx context.Context, e *model.AuditEvent) error {
+	details := []byte("{}")
+	if len(e.Details) > 0 {
+		var err error
+		if details, err = json.Marshal(e.Details); err != nil {
+			return fmt.Errorf("marshal audit details: %w", err)
+		}
+	}
+	err := r.q.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+		Type:      e.Type,
+		Outcome:   e.Outcome,
+		Actor:     pgtype.Text{String: e.Actor, Valid: e.Actor != ""},
+		Subject:   pgtype.Text{String: e.Subject, Valid: e.Subject != ""},
+		Ip:        pgtype.Text{String: e.IP, Valid: e.IP != ""},
+		UserAgent: pgtype.Text{String: e.UserAgent, Valid: e.UserAgent != ""},
+		Details:   details,
+	})
+	if err != nil {
+		return fmt.Errorf("create audit event: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditRepo) List(ctx context.Context, f model.AuditFilter, limit int32) ([]model.AuditEvent, error) {
+	rows, err := r.q.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		BeforeID: pgtype.Int8{Int64: f.BeforeID, Valid: f.BeforeID != 0},
+		Type:     pgtype.Text{String: f.Type, Valid: f.Type != ""},
+		Outcome:  pgtype.Text{String: f.Outcome, Valid: f.Outcome != ""},
+		Actor:    pgtype.Text{String: f.Actor, Valid: f.Actor != ""},
+		Subject:  pgtype.Text{String: f.Subject, Valid: f.Subject != ""},
+		Ip:       pgtype.Text{String: f.IP, Valid: f.IP != ""},
+		Since:    pgtype.Timestamptz{Time: f.Since, Valid: !f.Since.IsZero()},
+		Until:    pgtype.Timestamptz{Time: f.Until, Valid: !f.Until.IsZero()},
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list audit events: %w", err)
+	}
+	events := make([]model.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		e, err := toDomainFromAuditEvent(row)
+		if err != nil {
+			return nil, fmt.Errorf("convert to domain model: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (r *AuditRepo) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	n, err := r.q.DeleteAuditEventsBefore(ctx, pgtype.Timestamptz{Time: t, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("delete audit events: %w", err)
+	}
+	return n, nil
+}
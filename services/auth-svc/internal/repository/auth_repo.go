@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
@@ -22,6 +25,31 @@ const (
 type AuthRepository interface {
 	CreateIfNotExists(ctx context.Context, email, hash string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	// GetByIDs returns the users that exist among ids, in no particular
+	// order. Their permissions and MFA state are not loaded.
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error)
+	List(ctx context.Context, f model.UserFilter, limit, offset int32) ([]model.User, error)
+	SetStatus(ctx context.Context, id uuid.UUID, status int16) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error
+	MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error)
+	Rehash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error
+	// ChangePassword replaces the password hash oldHash of the user with
+	// newHash and revokes the refresh tokens of every session but
+	// keepSession in one transaction. It fails with ErrNotFound if the
+	// stored hash is no longer oldHash.
+	ChangePassword(ctx context.Context, id uuid.UUID, oldHash, newHash string, keepSession uuid.UUID) error
+	// ChangeEmail replaces the email oldEmail of the user with the verified
+	// address newEmail. It fails with ErrNotFound if the email is no longer
+	// oldEmail and with ErrUserAlreadyExists if newEmail is taken.
+	ChangeEmail(ctx context.Context, id uuid.UUID, oldEmail, newEmail string) error
+	// PasswordHistory returns the hashes of up to limit previous passwords
+	// of the user, newest first. The current one is not among them.
+	PasswordHistory(ctx context.Context, id uuid.UUID, limit int) ([]string, error)
+	// AddPasswordHistory records hash as a previous password of the user
+	// and forgets all but the newest keep ones.
+	AddPasswordHistory(ctx context.Context, id uuid.UUID, hash string, keep int) error
 }
 
 type Repository struct {
@@ -53,6 +81,237 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*model.User,
 	return &u, nil
 }
 
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	dbUser, err := r.q.GetUserByID(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+
+	u, err := toDomainFromGetUserByIDRow(dbUser)
+	if err != nil {
+		return nil, fmt.Errorf("convert to domain model: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (r *Repository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error) {
+	pgIDs := make([]pgtype.UUID, len(ids))
+	for i, id := range ids {
+		pgIDs[i] = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	rows, err := r.q.GetUsersByIDs(ctx, pgIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get users by ids: %w", err)
+	}
+
+	users := make([]model.User, 0, len(rows))
+	for _, row := range rows {
+		u, err := toDomainFromSearchUsersRow(db.SearchUsersRow(row))
+		if err != nil {
+			return nil, fmt.Errorf("convert to domain model: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (r *Repository) List(ctx context.Context, f model.UserFilter, limit, offset int32) ([]model.User, error) {
+	rows, err := r.q.SearchUsers(ctx, db.SearchUsersParams{
+		EmailPrefix:   pgtype.Text{String: f.EmailPrefix, Valid: f.EmailPrefix != ""},
+		Status:        pgtype.Int2{Int16: f.Status, Valid: f.Status != 0},
+		CreatedAfter:  pgtype.Timestamptz{Time: f.CreatedAfter, Valid: !f.CreatedAfter.IsZero()},
+		CreatedBefore: pgtype.Timestamptz{Time: f.CreatedBefore, Valid: !f.CreatedBefore.IsZero()},
+		Role:          pgtype.Text{String: f.Role, Valid: f.Role != ""},
+		Limit:         limit,
+		Offset:        offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search users: %w", err)
+	}
+
+	users := make([]model.User, 0, len(rows))
+	for _, row := range rows {
+		u, err := toDomainFromSearchUsersRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("convert to domain model: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (r *Repository) SetStatus(ctx context.Context, id uuid.UUID, status int16) error {
+	n, err := r.q.UpdateUserStatus(ctx, db.UpdateUserStatusParams{
+		ID:     pgtype.UUID{Bytes: id, Valid: true},
+		Status: status,
+	})
+	if err != nil {
+		return fmt.Errorf("update user status: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the user. Role assignments and refresh tokens go with it
+// through ON DELETE CASCADE.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	n, err := r.q.DeleteUser(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+// Rehash replaces the password hash of the user with an upgraded hash of the
+// same password. It fails with ErrNotFound, leaving the row alone, if the
+// stored hash is no longer oldHash, e.g. because the password was reset in
+// the meantime.
+func (r *Repository) Rehash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	n, err := r.q.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		NewHash: newHash,
+		ID:      pgtype.UUID{Bytes: id, Valid: true},
+		OldHash: oldHash,
+	})
+	if err != nil {
+		return fmt.Errorf("rehash user password: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) ChangePassword(ctx context.Context, id uuid.UUID, oldHash, newHash string, keepSession uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+	uid := pgtype.UUID{Bytes: id, Valid: true}
+	n, err := qtx.RehashUserPassword(ctx, db.RehashUserPasswordParams{NewHash: newHash, ID: uid, OldHash: oldHash})
+	if err != nil {
+		return fmt.Errorf("change user password: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	if err := qtx.RevokeOtherUserRefreshTokens(ctx, db.RevokeOtherUserRefreshTokensParams{
+		UserID:   uid,
+		FamilyID: pgtype.UUID{Bytes: keepSession, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("revoke other refresh tokens: %w", err)
+	}
+	if err := qtx.InvalidateUserPasswordResetTokens(ctx, uid); err != nil {
+		return fmt.Errorf("invalidate password reset tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ChangeEmail(ctx context.Context, id uuid.UUID, oldEmail, newEmail string) error {
+	n, err := r.q.ChangeUserEmail(ctx, db.ChangeUserEmailParams{
+		NewEmail: newEmail,
+		ID:       pgtype.UUID{Bytes: id, Valid: true},
+		OldEmail: oldEmail,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return appErr.ErrUserAlreadyExists
+		}
+		return fmt.Errorf("change user email: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) PasswordHistory(ctx context.Context, id uuid.UUID, limit int) ([]string, error) {
+	hashes, err := r.q.ListPasswordHistory(ctx, db.ListPasswordHistoryParams{
+		UserID: pgtype.UUID{Bytes: id, Valid: true},
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list password history: %w", err)
+	}
+	return hashes, nil
+}
+
+func (r *Repository) AddPasswordHistory(ctx context.Context, id uuid.UUID, hash string, keep int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+	uid := pgtype.UUID{Bytes: id, Valid: true}
+	if err := qtx.AddPasswordHistory(ctx, db.AddPasswordHistoryParams{UserID: uid, PasswordHash: hash}); err != nil {
+		return fmt.Errorf("add password history: %w", err)
+	}
+	if err := qtx.TrimPasswordHistory(ctx, db.TrimPasswordHistoryParams{UserID: uid, Keep: int32(keep)}); err != nil {
+		return fmt.Errorf("trim password history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified marks the email of the user as verified. It fails with
+// ErrNotFound if the user is gone or its email is no longer email.
+func (r *Repository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	n, err := r.q.MarkUserEmailVerified(ctx, db.MarkUserEmailVerifiedParams{
+		ID:    pgtype.UUID{Bytes: id, Valid: true},
+		Email: email,
+	})
+	if err != nil {
+		return fmt.Errorf("mark email verified: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+// MarkVerificationSent records that a verification mail is being sent to an
+// unverified user. It reports false, and records nothing, if the user is
+// verified or the previous mail was sent after notBefore.
+func (r *Repository) MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error) {
+	n, err := r.q.MarkVerificationSent(ctx, db.MarkVerificationSentParams{
+		ID:                 pgtype.UUID{Bytes: id, Valid: true},
+		VerificationSentAt: pgtype.Timestamptz{Time: notBefore, Valid: true},
+	})
+	if err != nil {
+		return false, fmt.Errorf("mark verification sent: %w", err)
+	}
+	return n > 0, nil
+}
+
 func (r *Repository) CreateIfNotExists(ctx context.Context, email, hash string) (*model.User, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
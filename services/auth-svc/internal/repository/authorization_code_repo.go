@@ -0,0 +1,92 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	appErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// AuthorizationCodeRepository stores the codes of the authorization code
+// grant by their hash.
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, c *model.AuthorizationCode) error
+	// Get returns the code with the given hash, used and expired ones
+	// included, or ErrNotFound.
+	Get(ctx context.Context, codeHash string) (*model.AuthorizationCode, error)
+	// Use marks a code as exchanged. It returns ErrTokenReused if the code
+	// has been exchanged before.
+	Use(ctx context.Context, codeHash string) error
+	// DeleteExpired removes the codes past their expiry and returns how many
+	// were deleted.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type AuthorizationCodeRepo struct {
+	q *db.Queries
+}
+
+func NewAuthorizationCodeRepository(pool *pgxpool.Pool) AuthorizationCodeRepository {
+	return &AuthorizationCodeRepo{q: db.New(pool)}
+}
+
+func (r *AuthorizationCodeRepo) Create(ctx context.Context, c *model.AuthorizationCode) error {
+	if err := r.q.CreateAuthorizationCode(ctx, db.CreateAuthorizationCodeParams{
+		CodeHash:            c.CodeHash,
+		ClientID:            c.ClientID,
+		UserID:              pgtype.UUID{Bytes: c.UserID, Valid: true},
+		SessionID:           pgtype.UUID{Bytes: c.SessionID, Valid: true},
+		RedirectUri:         c.RedirectURI,
+		Scope:               strings.Join(c.Scopes, " "),
+		CodeChallenge:       c.CodeChallenge,
+		CodeChallengeMethod: c.CodeChallengeMethod,
+		Nonce:               c.Nonce,
+		Amr:                 nonNil(c.AMR),
+		ExpiresAt:           pgtype.Timestamptz{Time: c.ExpiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("create authorization code: %w", err)
+	}
+	return nil
+}
+
+func (r *AuthorizationCodeRepo) Get(ctx context.Context, codeHash string) (*model.AuthorizationCode, error) {
+	row, err := r.q.GetAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("get authorization code: %w", err)
+	}
+	c, err := toDomainFromAuthorizationCode(row)
+	if err != nil {
+		return nil, fmt.Errorf("convert to domain model: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *AuthorizationCodeRepo) Use(ctx context.Context, codeHash string) error {
+	n, err := r.q.UseAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		return fmt.Errorf("use authorization code: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrTokenReused
+	}
+	return nil
+}
+
+func (r *AuthorizationCodeRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	n, err := r.q.DeleteExpiredAuthorizationCodes(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired authorization codes: %w", err)
+	}
+	return n, nil
+}
@@ -3,15 +3,29 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
 	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"time"
 )
 
 type ClientRepository interface {
 	GetById(ctx context.Context, id string) (*model.Client, error)
+	Create(ctx context.Context, c *model.Client) (*model.Client, error)
+	List(ctx context.Context, limit, offset int32) ([]model.Client, error)
+	SetStatus(ctx context.Context, id string, status int16) error
+	SetRedirectURIs(ctx context.Context, id string, uris []string) error
+	SetExchangeAudiences(ctx context.Context, id string, audiences []string) error
+	// ExchangeAudiences returns the audiences active clients may request
+	// tokens for with the token exchange grant.
+	ExchangeAudiences(ctx context.Context) ([]string, error)
+	RotateSecret(ctx context.Context, id, hash string, previousExpiresAt time.Time) error
+	RehashSecret(ctx context.Context, id, oldHash, newHash string) error
+	Delete(ctx context.Context, id string) error
 }
 
 type CliRepository struct {
@@ -34,6 +48,130 @@ func (r CliRepository) GetById(ctx context.Context, id string) (*model.Client, e
 		}
 		return nil, err
 	}
-	c := toDomainFromGetClientById(client)
+	c := toDomainFromGetByIdRow(client)
 	return &c, nil
 }
+
+// Create inserts a new client. It returns ErrClientAlreadyExists if the id is
+// taken.
+func (r CliRepository) Create(ctx context.Context, c *model.Client) (*model.Client, error) {
+	row, err := r.q.CreateClient(ctx, db.CreateClientParams{
+		ID:            c.ID,
+		SecretHash:    c.Secret,
+		Roles:         nonNil(c.Roles),
+		AllowedScopes: nonNil(c.AllowedScopes),
+		GrantTypes:    nonNil(c.GrantTypes),
+		RedirectUris:  nonNil(c.RedirectURIs),
+		Public:        c.Public,
+
+		ExchangeAudiences: nonNil(c.ExchangeAudiences),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, AppErr.ErrClientAlreadyExists
+		}
+		return nil, fmt.Errorf("create client: %w", err)
+	}
+	created := toDomainFromGetClientById(row)
+	return &created, nil
+}
+
+func (r CliRepository) List(ctx context.Context, limit, offset int32) ([]model.Client, error) {
+	rows, err := r.q.ListClients(ctx, db.ListClientsParams{Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, fmt.Errorf("list clients: %w", err)
+	}
+	clients := make([]model.Client, len(rows))
+	for i, row := range rows {
+		clients[i] = toDomainFromGetClientById(row)
+	}
+	return clients, nil
+}
+
+func (r CliRepository) SetStatus(ctx context.Context, id string, status int16) error {
+	n, err := r.q.UpdateClientStatus(ctx, db.UpdateClientStatusParams{ID: id, Status: status})
+	if err != nil {
+		return fmt.Errorf("update client status: %w", err)
+	}
+	if n == 0 {
+		return AppErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r CliRepository) SetRedirectURIs(ctx context.Context, id string, uris []string) error {
+	n, err := r.q.UpdateClientRedirectURIs(ctx, db.UpdateClientRedirectURIsParams{ID: id, RedirectUris: nonNil(uris)})
+	if err != nil {
+		return fmt.Errorf("update client redirect uris: %w", err)
+	}
+	if n == 0 {
+		return AppErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r CliRepository) SetExchangeAudiences(ctx context.Context, id string, audiences []string) error {
+	n, err := r.q.UpdateClientExchangeAudiences(ctx, db.UpdateClientExchangeAudiencesParams{ID: id, ExchangeAudiences: nonNil(audiences)})
+	if err != nil {
+		return fmt.Errorf("update client exchange audiences: %w", err)
+	}
+	if n == 0 {
+		return AppErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r CliRepository) ExchangeAudiences(ctx context.Context) ([]string, error) {
+	audiences, err := r.q.ListExchangeAudiences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list exchange audiences: %w", err)
+	}
+	return audiences, nil
+}
+
+// RehashSecret replaces the secret hash of the client with an upgraded hash
+// of the same secret. It fails with ErrNotFound, leaving the row alone, if
+// the stored hash is no longer oldHash, e.g. because the secret was rotated
+// in the meantime.
+func (r CliRepository) RehashSecret(ctx context.Context, id, oldHash, newHash string) error {
+	n, err := r.q.RehashClientSecret(ctx, db.RehashClientSecretParams{
+		NewHash: newHash,
+		ID:      id,
+		OldHash: oldHash,
+	})
+	if err != nil {
+		return fmt.Errorf("rehash client secret: %w", err)
+	}
+	if n == 0 {
+		return AppErr.ErrNotFound
+	}
+	return nil
+}
+
+// RotateSecret replaces the secret hash and keeps the old one valid until
+// previousExpiresAt.
+func (r CliRepository) RotateSecret(ctx context.Context, id, hash string, previousExpiresAt time.Time) error {
+	n, err := r.q.RotateClientSecret(ctx, db.RotateClientSecretParams{
+		ID:                      id,
+		SecretHash:              hash,
+		PreviousSecretExpiresAt: pgtype.Timestamptz{Time: previousExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("rotate client secret: %w", err)
+	}
+	if n == 0 {
+		return AppErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r CliRepository) Delete(ctx context.Context, id string) error {
+	n, err := r.q.DeleteClient(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete client: %w", err)
+	}
+	if n == 0 {
+		return AppErr.ErrNotFound
+	}
+	return nil
+}
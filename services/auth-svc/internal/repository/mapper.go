@@ -1,9 +1,13 @@
 package user
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
 	domain "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
 )
@@ -15,12 +19,13 @@ func toDomainFromAuthUserAndRole(u db.User, role db.Role) (domain.User, error) {
 	}
 
 	return domain.User{
-		ID:        id,
-		Email:     u.Email,
-		Password:  u.PasswordHash,
-		Status:    u.Status,
-		Roles:     []string{role.Name},
-		CreatedAt: u.CreatedAt.Time,
+		ID:            id,
+		Email:         u.Email,
+		Password:      u.PasswordHash,
+		Status:        u.Status,
+		Roles:         []string{role.Name},
+		EmailVerified: u.EmailVerifiedAt.Valid,
+		CreatedAt:     u.CreatedAt.Time,
 	}, nil
 }
 
@@ -31,21 +36,277 @@ func toDomainFromGetUserByEmailRow(row db.GetUserByEmailRow) (domain.User, error
 	}
 
 	return domain.User{
-		ID:        id,
-		Email:     row.Email,
-		Password:  row.PasswordHash,
-		Status:    row.Status,
-		Roles:     row.Roles,
-		CreatedAt: row.CreatedAt.Time,
+		ID:            id,
+		Email:         row.Email,
+		Password:      row.PasswordHash,
+		Status:        row.Status,
+		Roles:         row.Roles,
+		Permissions:   row.Permissions,
+		EmailVerified: row.EmailVerifiedAt.Valid,
+		MFAEnabled:    row.MfaEnabled,
+		MFARequired:   row.MfaRequired,
+		CreatedAt:     row.CreatedAt.Time,
+	}, nil
+}
+
+func toDomainFromGetUserByIDRow(row db.GetUserByIDRow) (domain.User, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return domain.User{}, fmt.Errorf("invalid UUID from GetUserByIDRow.ID: %w", err)
+	}
+
+	return domain.User{
+		ID:            id,
+		Email:         row.Email,
+		Password:      row.PasswordHash,
+		Status:        row.Status,
+		Roles:         row.Roles,
+		Permissions:   row.Permissions,
+		EmailVerified: row.EmailVerifiedAt.Valid,
+		MFAEnabled:    row.MfaEnabled,
+		MFARequired:   row.MfaRequired,
+		CreatedAt:     row.CreatedAt.Time,
 	}, nil
 }
 
+func toDomainFromSearchUsersRow(row db.SearchUsersRow) (domain.User, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return domain.User{}, fmt.Errorf("invalid UUID from SearchUsersRow.ID: %w", err)
+	}
+
+	return domain.User{
+		ID:            id,
+		Email:         row.Email,
+		Password:      row.PasswordHash,
+		Status:        row.Status,
+		Roles:         row.Roles,
+		EmailVerified: row.EmailVerifiedAt.Valid,
+		CreatedAt:     row.CreatedAt.Time,
+	}, nil
+}
+
+func toDomainFromGetByIdRow(row db.GetByIdRow) domain.Client {
+	c := toDomainFromGetClientById(db.Client{
+		ID:                      row.ID,
+		SecretHash:              row.SecretHash,
+		Roles:                   row.Roles,
+		Status:                  row.Status,
+		CreatedAt:               row.CreatedAt,
+		AllowedScopes:           row.AllowedScopes,
+		GrantTypes:              row.GrantTypes,
+		PreviousSecretHash:      row.PreviousSecretHash,
+		PreviousSecretExpiresAt: row.PreviousSecretExpiresAt,
+		RedirectUris:            row.RedirectUris,
+		Public:                  row.Public,
+		ExchangeAudiences:       row.ExchangeAudiences,
+	})
+	c.Permissions = row.Permissions
+	return c
+}
+
 func toDomainFromGetClientById(row db.Client) domain.Client {
 	return domain.Client{
+		ID:            row.ID,
+		Secret:        row.SecretHash,
+		Roles:         row.Roles,
+		Status:        row.Status,
+		AllowedScopes: row.AllowedScopes,
+		GrantTypes:    row.GrantTypes,
+		CreatedAt:     row.CreatedAt.Time,
+
+		PreviousSecret:          row.PreviousSecretHash.String,
+		PreviousSecretExpiresAt: timePtr(row.PreviousSecretExpiresAt),
+
+		RedirectURIs: row.RedirectUris,
+		Public:       row.Public,
+
+		ExchangeAudiences: row.ExchangeAudiences,
+	}
+}
+
+func toDomainFromRefreshToken(row db.RefreshToken) (domain.RefreshToken, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return domain.RefreshToken{}, fmt.Errorf("invalid UUID from RefreshToken.ID: %w", err)
+	}
+	familyID, err := uuid.FromBytes(row.FamilyID.Bytes[:])
+	if err != nil {
+		return domain.RefreshToken{}, fmt.Errorf("invalid UUID from RefreshToken.FamilyID: %w", err)
+	}
+	userID, err := uuid.FromBytes(row.UserID.Bytes[:])
+	if err != nil {
+		return domain.RefreshToken{}, fmt.Errorf("invalid UUID from RefreshToken.UserID: %w", err)
+	}
+
+	return domain.RefreshToken{
+		ID:        id,
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: row.ExpiresAt.Time,
+		CreatedAt: row.CreatedAt.Time,
+		RotatedAt: timePtr(row.RotatedAt),
+		RevokedAt: timePtr(row.RevokedAt),
+	}, nil
+}
+
+func toDomainFromAuthorizationCode(row db.AuthorizationCode) (domain.AuthorizationCode, error) {
+	userID, err := uuid.FromBytes(row.UserID.Bytes[:])
+	if err != nil {
+		return domain.AuthorizationCode{}, fmt.Errorf("invalid UUID from AuthorizationCode.UserID: %w", err)
+	}
+	sessionID, err := uuid.FromBytes(row.SessionID.Bytes[:])
+	if err != nil {
+		return domain.AuthorizationCode{}, fmt.Errorf("invalid UUID from AuthorizationCode.SessionID: %w", err)
+	}
+
+	return domain.AuthorizationCode{
+		CodeHash:            row.CodeHash,
+		ClientID:            row.ClientID,
+		UserID:              userID,
+		SessionID:           sessionID,
+		RedirectURI:         row.RedirectUri,
+		Scopes:              strings.Fields(row.Scope),
+		CodeChallenge:       row.CodeChallenge,
+		CodeChallengeMethod: row.CodeChallengeMethod,
+		Nonce:               row.Nonce,
+		AMR:                 row.Amr,
+		ExpiresAt:           row.ExpiresAt.Time,
+		CreatedAt:           row.CreatedAt.Time,
+		UsedAt:              timePtr(row.UsedAt),
+	}, nil
+}
+
+func toDomainFromListUserSessionsRow(row db.ListUserSessionsRow) (domain.Session, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("invalid UUID from Session.ID: %w", err)
+	}
+	userID, err := uuid.FromBytes(row.UserID.Bytes[:])
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("invalid UUID from Session.UserID: %w", err)
+	}
+
+	return domain.Session{
+		ID:              id,
+		UserID:          userID,
+		UserAgent:       row.UserAgent,
+		IP:              row.Ip,
+		CreatedAt:       row.CreatedAt.Time,
+		LastRefreshedAt: row.LastRefreshedAt.Time,
+		ExpiresAt:       row.ExpiresAt.Time,
+	}, nil
+}
+
+func toDomainFromRole(id int16, name string, permissions []string, mfaRequired bool) domain.Role {
+	return domain.Role{
+		ID:          id,
+		Name:        name,
+		Permissions: permissions,
+		MFARequired: mfaRequired,
+	}
+}
+
+func toDomainFromRoleChange(row db.RoleChange) domain.RoleChange {
+	return domain.RoleChange{
+		ID:          row.ID,
+		Actor:       row.Actor,
+		Action:      row.Action,
+		Role:        row.RoleName,
+		SubjectType: row.SubjectType,
+		SubjectID:   row.SubjectID,
+		Permission:  row.Permission,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
+
+func toDomainFromAuditEvent(row db.AuditEvent) (domain.AuditEvent, error) {
+	var details map[string]string
+	if err := json.Unmarshal(row.Details, &details); err != nil {
+		return domain.AuditEvent{}, fmt.Errorf("invalid AuditEvent.Details: %w", err)
+	}
+	return domain.AuditEvent{
 		ID:        row.ID,
-		Secret:    row.SecretHash,
-		Roles:     row.Roles,
-		Status:    row.Status,
+		Type:      row.Type,
+		Outcome:   row.Outcome,
+		Actor:     row.Actor.String,
+		Subject:   row.Subject.String,
+		IP:        row.Ip.String,
+		UserAgent: row.UserAgent.String,
+		Details:   details,
 		CreatedAt: row.CreatedAt.Time,
+	}, nil
+}
+
+func toDomainFromLockoutEvent(row db.LockoutEvent) domain.LockoutEvent {
+	return domain.LockoutEvent{
+		ID:          row.ID,
+		Kind:        row.Kind,
+		Subject:     row.Subject,
+		Action:      row.Action,
+		Actor:       row.Actor.String,
+		IP:          row.Ip.String,
+		Failures:    int(row.Failures),
+		LockedUntil: timePtr(row.LockedUntil),
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
+
+func toDomainFromAPIKey(row db.ApiKey) domain.APIKey {
+	return domain.APIKey{
+		ID:         uuid.UUID(row.ID.Bytes),
+		UserID:     uuid.UUID(row.UserID.Bytes),
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		SecretHash: row.SecretHash,
+		Scopes:     row.Scopes,
+		ExpiresAt:  timePtr(row.ExpiresAt),
+		LastUsedAt: timePtr(row.LastUsedAt),
+		CreatedAt:  row.CreatedAt.Time,
+		RevokedAt:  timePtr(row.RevokedAt),
+	}
+}
+
+func toDomainFromRevokedToken(row db.RevokedToken) domain.RevokedToken {
+	return domain.RevokedToken{
+		JTI:       row.Jti,
+		ExpiresAt: row.ExpiresAt.Time,
+		RevokedAt: row.RevokedAt.Time,
+	}
+}
+
+func toCreateRefreshTokenParams(t *domain.RefreshToken) db.CreateRefreshTokenParams {
+	return db.CreateRefreshTokenParams{
+		ID:        pgtype.UUID{Bytes: t.ID, Valid: true},
+		FamilyID:  pgtype.UUID{Bytes: t.FamilyID, Valid: true},
+		UserID:    pgtype.UUID{Bytes: t.UserID, Valid: true},
+		ExpiresAt: pgtype.Timestamptz{Time: t.ExpiresAt, Valid: true},
+	}
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
+
+// nonNil turns a nil slice into an empty one so that it is stored as '{}'
+// rather than NULL in NOT NULL array columns.
+func nonNil(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+	return v
+}
+
+func toDomainFromUserTotp(row db.UserTotp) domain.TOTP {
+	return domain.TOTP{
+		UserID:       uuid.UUID(row.UserID.Bytes),
+		Secret:       row.Secret,
+		Confirmed:    row.ConfirmedAt.Valid,
+		LastUsedStep: row.LastUsedStep,
+		CreatedAt:    row.CreatedAt.Time,
 	}
 }
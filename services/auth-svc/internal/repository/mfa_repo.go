@@ -0,0 +1,185 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	appErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// MFARepository stores the TOTP authenticators of users and their recovery
+// codes, the latter only by hash.
+type MFARepository interface {
+	// GetTOTP returns ErrNotFound if the user never started enrolment.
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*model.TOTP, error)
+	// StartTOTP stores an unconfirmed authenticator, replacing a previous
+	// unconfirmed one. It returns ErrMFAAlreadyEnabled if the user already
+	// has a confirmed authenticator.
+	StartTOTP(ctx context.Context, userID uuid.UUID, secret string) error
+	// ConfirmTOTP confirms the authenticator with the code of step and
+	// replaces the recovery codes in one transaction. It returns ErrNotFound
+	// if there is no unconfirmed authenticator.
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryHashes []string) error
+	// UseStep records that the code of step was accepted. It returns
+	// ErrNotFound if that or a later step was accepted before.
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	// Disable removes the authenticator and the recovery codes. It returns
+	// ErrNotFound if the user has no authenticator.
+	Disable(ctx context.Context, userID uuid.UUID) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error
+	// UseRecoveryCode consumes a recovery code. It returns ErrNotFound if the
+	// code is unknown or was used before.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type MFARepo struct {
+	q  *db.Queries
+	db *pgxpool.Pool
+}
+
+func NewMFARepository(pool *pgxpool.Pool) MFARepository {
+	return &MFARepo{
+		q:  db.New(pool),
+		db: pool,
+	}
+}
+
+func (r *MFARepo) GetTOTP(ctx context.Context, userID uuid.UUID) (*model.TOTP, error) {
+	row, err := r.q.GetUserTOTP(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("get user totp: %w", err)
+	}
+	t := toDomainFromUserTotp(row)
+	return &t, nil
+}
+
+func (r *MFARepo) StartTOTP(ctx context.Context, userID uuid.UUID, secret string) error {
+	n, err := r.q.StartUserTOTP(ctx, db.StartUserTOTPParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Secret: secret,
+	})
+	if err != nil {
+		return fmt.Errorf("start user totp: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *MFARepo) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryHashes []string) error {
+	return r.inTx(ctx, func(q *db.Queries) error {
+		uid := pgtype.UUID{Bytes: userID, Valid: true}
+		n, err := q.ConfirmUserTOTP(ctx, db.ConfirmUserTOTPParams{UserID: uid, LastUsedStep: step})
+		if err != nil {
+			return fmt.Errorf("confirm user totp: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		return replaceRecoveryCodes(ctx, q, uid, recoveryHashes)
+	})
+}
+
+func (r *MFARepo) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	n, err := r.q.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		UserID:       pgtype.UUID{Bytes: userID, Valid: true},
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return fmt.Errorf("use totp step: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *MFARepo) Disable(ctx context.Context, userID uuid.UUID) error {
+	return r.inTx(ctx, func(q *db.Queries) error {
+		uid := pgtype.UUID{Bytes: userID, Valid: true}
+		n, err := q.DeleteUserTOTP(ctx, uid)
+		if err != nil {
+			return fmt.Errorf("delete user totp: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		if err := q.DeleteRecoveryCodes(ctx, uid); err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *MFARepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	return r.inTx(ctx, func(q *db.Queries) error {
+		return replaceRecoveryCodes(ctx, q, pgtype.UUID{Bytes: userID, Valid: true}, hashes)
+	})
+}
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error {
+	n, err := r.q.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		CodeHash: hash,
+		UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *MFARepo) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	n, err := r.q.CountRecoveryCodes(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *MFARepo) inTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	if err := fn(r.q.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *db.Queries, uid pgtype.UUID, hashes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, uid); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	for _, h := range hashes {
+		if err := q.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{CodeHash: h, UserID: uid}); err != nil {
+			return fmt.Errorf("create recovery code: %w", err)
+		}
+	}
+	return nil
+}
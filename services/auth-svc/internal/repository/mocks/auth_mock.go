@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: C:\Users\user\GolandProjects\e-commerce\services\auth-svc\internal\repository\auth_repo.go
+// Source: ./auth_repo.go
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -7,8 +7,10 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
 )
 
@@ -35,6 +37,48 @@ func (m *MockAuthRepository) EXPECT() *MockAuthRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddPasswordHistory mocks base method.
+func (m *MockAuthRepository) AddPasswordHistory(ctx context.Context, id uuid.UUID, hash string, keep int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPasswordHistory", ctx, id, hash, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPasswordHistory indicates an expected call of AddPasswordHistory.
+func (mr *MockAuthRepositoryMockRecorder) AddPasswordHistory(ctx, id, hash, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPasswordHistory", reflect.TypeOf((*MockAuthRepository)(nil).AddPasswordHistory), ctx, id, hash, keep)
+}
+
+// ChangeEmail mocks base method.
+func (m *MockAuthRepository) ChangeEmail(ctx context.Context, id uuid.UUID, oldEmail, newEmail string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeEmail", ctx, id, oldEmail, newEmail)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeEmail indicates an expected call of ChangeEmail.
+func (mr *MockAuthRepositoryMockRecorder) ChangeEmail(ctx, id, oldEmail, newEmail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeEmail", reflect.TypeOf((*MockAuthRepository)(nil).ChangeEmail), ctx, id, oldEmail, newEmail)
+}
+
+// ChangePassword mocks base method.
+func (m *MockAuthRepository) ChangePassword(ctx context.Context, id uuid.UUID, oldHash, newHash string, keepSession uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, id, oldHash, newHash, keepSession)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthRepositoryMockRecorder) ChangePassword(ctx, id, oldHash, newHash, keepSession interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthRepository)(nil).ChangePassword), ctx, id, oldHash, newHash, keepSession)
+}
+
 // CreateIfNotExists mocks base method.
 func (m *MockAuthRepository) CreateIfNotExists(ctx context.Context, email, hash string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +94,20 @@ func (mr *MockAuthRepositoryMockRecorder) CreateIfNotExists(ctx, email, hash int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIfNotExists", reflect.TypeOf((*MockAuthRepository)(nil).CreateIfNotExists), ctx, email, hash)
 }
 
+// Delete mocks base method.
+func (m *MockAuthRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAuthRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAuthRepository)(nil).Delete), ctx, id)
+}
+
 // GetByEmail mocks base method.
 func (m *MockAuthRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -64,3 +122,120 @@ func (mr *MockAuthRepositoryMockRecorder) GetByEmail(ctx, email interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockAuthRepository)(nil).GetByEmail), ctx, email)
 }
+
+// GetByID mocks base method.
+func (m *MockAuthRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockAuthRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAuthRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDs mocks base method.
+func (m *MockAuthRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockAuthRepositoryMockRecorder) GetByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockAuthRepository)(nil).GetByIDs), ctx, ids)
+}
+
+// List mocks base method.
+func (m *MockAuthRepository) List(ctx context.Context, f model.UserFilter, limit, offset int32) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, f, limit, offset)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAuthRepositoryMockRecorder) List(ctx, f, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAuthRepository)(nil).List), ctx, f, limit, offset)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockAuthRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockAuthRepositoryMockRecorder) MarkEmailVerified(ctx, id, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockAuthRepository)(nil).MarkEmailVerified), ctx, id, email)
+}
+
+// MarkVerificationSent mocks base method.
+func (m *MockAuthRepository) MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkVerificationSent", ctx, id, notBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkVerificationSent indicates an expected call of MarkVerificationSent.
+func (mr *MockAuthRepositoryMockRecorder) MarkVerificationSent(ctx, id, notBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkVerificationSent", reflect.TypeOf((*MockAuthRepository)(nil).MarkVerificationSent), ctx, id, notBefore)
+}
+
+// PasswordHistory mocks base method.
+func (m *MockAuthRepository) PasswordHistory(ctx context.Context, id uuid.UUID, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PasswordHistory", ctx, id, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PasswordHistory indicates an expected call of PasswordHistory.
+func (mr *MockAuthRepositoryMockRecorder) PasswordHistory(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PasswordHistory", reflect.TypeOf((*MockAuthRepository)(nil).PasswordHistory), ctx, id, limit)
+}
+
+// Rehash mocks base method.
+func (m *MockAuthRepository) Rehash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rehash", ctx, id, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rehash indicates an expected call of Rehash.
+func (mr *MockAuthRepositoryMockRecorder) Rehash(ctx, id, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rehash", reflect.TypeOf((*MockAuthRepository)(nil).Rehash), ctx, id, oldHash, newHash)
+}
+
+// SetStatus mocks base method.
+func (m *MockAuthRepository) SetStatus(ctx context.Context, id uuid.UUID, status int16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockAuthRepositoryMockRecorder) SetStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockAuthRepository)(nil).SetStatus), ctx, id, status)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
@@ -35,6 +36,50 @@ func (m *MockClientRepository) EXPECT() *MockClientRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Create mocks base method.
+func (m *MockClientRepository) Create(ctx context.Context, c *model.Client) (*model.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, c)
+	ret0, _ := ret[0].(*model.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockClientRepositoryMockRecorder) Create(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClientRepository)(nil).Create), ctx, c)
+}
+
+// Delete mocks base method.
+func (m *MockClientRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClientRepository)(nil).Delete), ctx, id)
+}
+
+// ExchangeAudiences mocks base method.
+func (m *MockClientRepository) ExchangeAudiences(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeAudiences", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeAudiences indicates an expected call of ExchangeAudiences.
+func (mr *MockClientRepositoryMockRecorder) ExchangeAudiences(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeAudiences", reflect.TypeOf((*MockClientRepository)(nil).ExchangeAudiences), ctx)
+}
+
 // GetById mocks base method.
 func (m *MockClientRepository) GetById(ctx context.Context, id string) (*model.Client, error) {
 	m.ctrl.T.Helper()
@@ -49,3 +94,88 @@ func (mr *MockClientRepositoryMockRecorder) GetById(ctx, id interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockClientRepository)(nil).GetById), ctx, id)
 }
+
+// List mocks base method.
+func (m *MockClientRepository) List(ctx context.Context, limit, offset int32) ([]model.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, limit, offset)
+	ret0, _ := ret[0].([]model.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockClientRepositoryMockRecorder) List(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockClientRepository)(nil).List), ctx, limit, offset)
+}
+
+// RehashSecret mocks base method.
+func (m *MockClientRepository) RehashSecret(ctx context.Context, id, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashSecret", ctx, id, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashSecret indicates an expected call of RehashSecret.
+func (mr *MockClientRepositoryMockRecorder) RehashSecret(ctx, id, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashSecret", reflect.TypeOf((*MockClientRepository)(nil).RehashSecret), ctx, id, oldHash, newHash)
+}
+
+// RotateSecret mocks base method.
+func (m *MockClientRepository) RotateSecret(ctx context.Context, id, hash string, previousExpiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSecret", ctx, id, hash, previousExpiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateSecret indicates an expected call of RotateSecret.
+func (mr *MockClientRepositoryMockRecorder) RotateSecret(ctx, id, hash, previousExpiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSecret", reflect.TypeOf((*MockClientRepository)(nil).RotateSecret), ctx, id, hash, previousExpiresAt)
+}
+
+// SetExchangeAudiences mocks base method.
+func (m *MockClientRepository) SetExchangeAudiences(ctx context.Context, id string, audiences []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExchangeAudiences", ctx, id, audiences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExchangeAudiences indicates an expected call of SetExchangeAudiences.
+func (mr *MockClientRepositoryMockRecorder) SetExchangeAudiences(ctx, id, audiences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeAudiences", reflect.TypeOf((*MockClientRepository)(nil).SetExchangeAudiences), ctx, id, audiences)
+}
+
+// SetRedirectURIs mocks base method.
+func (m *MockClientRepository) SetRedirectURIs(ctx context.Context, id string, uris []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRedirectURIs", ctx, id, uris)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRedirectURIs indicates an expected call of SetRedirectURIs.
+func (mr *MockClientRepositoryMockRecorder) SetRedirectURIs(ctx, id, uris interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRedirectURIs", reflect.TypeOf((*MockClientRepository)(nil).SetRedirectURIs), ctx, id, uris)
+}
+
+// SetStatus mocks base method.
+func (m *MockClientRepository) SetStatus(ctx context.Context, id string, status int16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockClientRepositoryMockRecorder) SetStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockClientRepository)(nil).SetStatus), ctx, id, status)
+}
@@ -0,0 +1,223 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./role_repo.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+)
+
+// MockRoleRepository is a mock of RoleRepository interface.
+type MockRoleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepositoryMockRecorder
+}
+
+// MockRoleRepositoryMockRecorder is the mock recorder for MockRoleRepository.
+type MockRoleRepositoryMockRecorder struct {
+	mock *MockRoleRepository
+}
+
+// NewMockRoleRepository creates a new mock instance.
+func NewMockRoleRepository(ctrl *gomock.Controller) *MockRoleRepository {
+	mock := &MockRoleRepository{ctrl: ctrl}
+	mock.recorder = &MockRoleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepository) EXPECT() *MockRoleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddPermission mocks base method.
+func (m *MockRoleRepository) AddPermission(ctx context.Context, role, permission, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPermission", ctx, role, permission, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPermission indicates an expected call of AddPermission.
+func (mr *MockRoleRepositoryMockRecorder) AddPermission(ctx, role, permission, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPermission", reflect.TypeOf((*MockRoleRepository)(nil).AddPermission), ctx, role, permission, actor)
+}
+
+// Create mocks base method.
+func (m *MockRoleRepository) Create(ctx context.Context, name string, permissions []string, actor string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, name, permissions, actor)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRoleRepositoryMockRecorder) Create(ctx, name, permissions, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRoleRepository)(nil).Create), ctx, name, permissions, actor)
+}
+
+// Delete mocks base method.
+func (m *MockRoleRepository) Delete(ctx context.Context, name, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, name, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRoleRepositoryMockRecorder) Delete(ctx, name, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRoleRepository)(nil).Delete), ctx, name, actor)
+}
+
+// Get mocks base method.
+func (m *MockRoleRepository) Get(ctx context.Context, name string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, name)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRoleRepositoryMockRecorder) Get(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRoleRepository)(nil).Get), ctx, name)
+}
+
+// GrantToClient mocks base method.
+func (m *MockRoleRepository) GrantToClient(ctx context.Context, clientID, role, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantToClient", ctx, clientID, role, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantToClient indicates an expected call of GrantToClient.
+func (mr *MockRoleRepositoryMockRecorder) GrantToClient(ctx, clientID, role, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantToClient", reflect.TypeOf((*MockRoleRepository)(nil).GrantToClient), ctx, clientID, role, actor)
+}
+
+// GrantToUser mocks base method.
+func (m *MockRoleRepository) GrantToUser(ctx context.Context, userID uuid.UUID, role, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantToUser", ctx, userID, role, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantToUser indicates an expected call of GrantToUser.
+func (mr *MockRoleRepositoryMockRecorder) GrantToUser(ctx, userID, role, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantToUser", reflect.TypeOf((*MockRoleRepository)(nil).GrantToUser), ctx, userID, role, actor)
+}
+
+// List mocks base method.
+func (m *MockRoleRepository) List(ctx context.Context) ([]model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRoleRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRoleRepository)(nil).List), ctx)
+}
+
+// ListChanges mocks base method.
+func (m *MockRoleRepository) ListChanges(ctx context.Context, limit, offset int32) ([]model.RoleChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChanges", ctx, limit, offset)
+	ret0, _ := ret[0].([]model.RoleChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChanges indicates an expected call of ListChanges.
+func (mr *MockRoleRepositoryMockRecorder) ListChanges(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChanges", reflect.TypeOf((*MockRoleRepository)(nil).ListChanges), ctx, limit, offset)
+}
+
+// RemovePermission mocks base method.
+func (m *MockRoleRepository) RemovePermission(ctx context.Context, role, permission, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePermission", ctx, role, permission, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePermission indicates an expected call of RemovePermission.
+func (mr *MockRoleRepositoryMockRecorder) RemovePermission(ctx, role, permission, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePermission", reflect.TypeOf((*MockRoleRepository)(nil).RemovePermission), ctx, role, permission, actor)
+}
+
+// RevokeFromClient mocks base method.
+func (m *MockRoleRepository) RevokeFromClient(ctx context.Context, clientID, role, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFromClient", ctx, clientID, role, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFromClient indicates an expected call of RevokeFromClient.
+func (mr *MockRoleRepositoryMockRecorder) RevokeFromClient(ctx, clientID, role, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFromClient", reflect.TypeOf((*MockRoleRepository)(nil).RevokeFromClient), ctx, clientID, role, actor)
+}
+
+// RevokeFromUser mocks base method.
+func (m *MockRoleRepository) RevokeFromUser(ctx context.Context, userID uuid.UUID, role, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFromUser", ctx, userID, role, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFromUser indicates an expected call of RevokeFromUser.
+func (mr *MockRoleRepositoryMockRecorder) RevokeFromUser(ctx, userID, role, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFromUser", reflect.TypeOf((*MockRoleRepository)(nil).RevokeFromUser), ctx, userID, role, actor)
+}
+
+// SetClientRoles mocks base method.
+func (m *MockRoleRepository) SetClientRoles(ctx context.Context, clientID string, roles []string, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetClientRoles", ctx, clientID, roles, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetClientRoles indicates an expected call of SetClientRoles.
+func (mr *MockRoleRepositoryMockRecorder) SetClientRoles(ctx, clientID, roles, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClientRoles", reflect.TypeOf((*MockRoleRepository)(nil).SetClientRoles), ctx, clientID, roles, actor)
+}
+
+// SetMFARequired mocks base method.
+func (m *MockRoleRepository) SetMFARequired(ctx context.Context, role string, required bool, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMFARequired", ctx, role, required, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMFARequired indicates an expected call of SetMFARequired.
+func (mr *MockRoleRepositoryMockRecorder) SetMFARequired(ctx, role, required, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMFARequired", reflect.TypeOf((*MockRoleRepository)(nil).SetMFARequired), ctx, role, required, actor)
+}
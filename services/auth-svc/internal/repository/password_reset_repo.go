@@ -0,0 +1,133 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	appErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// PasswordResetRepository stores password reset tokens by their hash.
+type PasswordResetRepository interface {
+	// Create stores a new reset token for the user and invalidates the ones
+	// issued before it.
+	Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	// Lookup returns the user a token was issued to without consuming it.
+	// It returns ErrInvalidToken if the token is unknown, used or expired.
+	Lookup(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	// ResetPassword consumes the token, sets the new password hash and
+	// revokes all refresh tokens of the user in one transaction. It returns
+	// ErrInvalidToken if the token is unknown, used or expired.
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uuid.UUID, error)
+	// DeleteExpired removes the tokens past their expiry and returns how
+	// many were deleted.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type PasswordResetRepo struct {
+	q  *db.Queries
+	db *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(pool *pgxpool.Pool) PasswordResetRepository {
+	return &PasswordResetRepo{
+		q:  db.New(pool),
+		db: pool,
+	}
+}
+
+func (r *PasswordResetRepo) Create(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+	uid := pgtype.UUID{Bytes: userID, Valid: true}
+	if err := qtx.InvalidateUserPasswordResetTokens(ctx, uid); err != nil {
+		return fmt.Errorf("invalidate password reset tokens: %w", err)
+	}
+	if err := qtx.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		TokenHash: tokenHash,
+		UserID:    uid,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("create password reset token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *PasswordResetRepo) Lookup(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	uid, err := r.q.GetPasswordResetTokenUser(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, appErr.ErrInvalidToken
+		}
+		return uuid.Nil, fmt.Errorf("get password reset token: %w", err)
+	}
+	return uuid.UUID(uid.Bytes), nil
+}
+
+func (r *PasswordResetRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+	uid, err := qtx.ConsumePasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, appErr.ErrInvalidToken
+		}
+		return uuid.Nil, fmt.Errorf("consume password reset token: %w", err)
+	}
+	n, err := qtx.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{ID: uid, PasswordHash: passwordHash})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("update user password: %w", err)
+	}
+	if n == 0 {
+		return uuid.Nil, appErr.ErrInvalidToken
+	}
+	if err := qtx.RevokeUserRefreshTokens(ctx, uid); err != nil {
+		return uuid.Nil, fmt.Errorf("revoke user refresh tokens: %w", err)
+	}
+	if err := qtx.InvalidateUserPasswordResetTokens(ctx, uid); err != nil {
+		return uuid.Nil, fmt.Errorf("invalidate password reset tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return uuid.UUID(uid.Bytes), nil
+}
+
+func (r *PasswordResetRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	n, err := r.q.DeleteExpiredPasswordResetTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired password reset tokens: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,348 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	appErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+const pgForeignKeyViolation = "23503"
+
+// RoleRepository manages roles, their permissions and their assignment to
+// users and clients. Every mutation is recorded as a model.RoleChange made by
+// actor, in the same transaction as the change itself.
+type RoleRepository interface {
+	List(ctx context.Context) ([]model.Role, error)
+	Get(ctx context.Context, name string) (*model.Role, error)
+	// Create adds the role together with its permissions.
+	Create(ctx context.Context, name string, permissions []string, actor string) (*model.Role, error)
+	Delete(ctx context.Context, name, actor string) error
+	AddPermission(ctx context.Context, role, permission, actor string) error
+	RemovePermission(ctx context.Context, role, permission, actor string) error
+	GrantToUser(ctx context.Context, userID uuid.UUID, role, actor string) error
+	RevokeFromUser(ctx context.Context, userID uuid.UUID, role, actor string) error
+	GrantToClient(ctx context.Context, clientID, role, actor string) error
+	RevokeFromClient(ctx context.Context, clientID, role, actor string) error
+	SetClientRoles(ctx context.Context, clientID string, roles []string, actor string) error
+	SetMFARequired(ctx context.Context, role string, required bool, actor string) error
+	ListChanges(ctx context.Context, limit, offset int32) ([]model.RoleChange, error)
+}
+
+type RoleRepo struct {
+	q  *db.Queries
+	db *pgxpool.Pool
+}
+
+func NewRoleRepository(pool *pgxpool.Pool) RoleRepository {
+	return &RoleRepo{
+		q:  db.New(pool),
+		db: pool,
+	}
+}
+
+func (r *RoleRepo) List(ctx context.Context) ([]model.Role, error) {
+	rows, err := r.q.ListRolesWithPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	roles := make([]model.Role, len(rows))
+	for i, row := range rows {
+		roles[i] = toDomainFromRole(row.ID, row.Name, row.Permissions, row.MfaRequired)
+	}
+	return roles, nil
+}
+
+func (r *RoleRepo) Get(ctx context.Context, name string) (*model.Role, error) {
+	row, err := r.q.GetRoleWithPermissions(ctx, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appErr.ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("get role: %w", err)
+	}
+	role := toDomainFromRole(row.ID, row.Name, row.Permissions, row.MfaRequired)
+	return &role, nil
+}
+
+func (r *RoleRepo) Create(ctx context.Context, name string, permissions []string, actor string) (*model.Role, error) {
+	changes := []model.RoleChange{{Actor: actor, Action: model.RoleActionCreate, Role: name}}
+	for _, p := range permissions {
+		changes = append(changes, model.RoleChange{Actor: actor, Action: model.RoleActionAddPermission, Role: name, Permission: p})
+	}
+	var created db.GetRoleWithPermissionsRow
+	err := r.withChanges(ctx, changes, func(q *db.Queries) error {
+		role, err := q.CreateRole(ctx, name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return appErr.ErrRoleAlreadyExists
+		}
+		if err != nil {
+			return fmt.Errorf("create role: %w", err)
+		}
+		for _, p := range permissions {
+			if err := q.AddRolePermission(ctx, db.AddRolePermissionParams{RoleID: role.ID, Permission: p}); err != nil {
+				return fmt.Errorf("add role permission: %w", err)
+			}
+		}
+		created, err = q.GetRoleWithPermissions(ctx, name)
+		if err != nil {
+			return fmt.Errorf("get role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	role := toDomainFromRole(created.ID, created.Name, created.Permissions, created.MfaRequired)
+	return &role, nil
+}
+
+// Delete removes the role together with its permissions and assignments,
+// including the copies held in clients.roles.
+func (r *RoleRepo) Delete(ctx context.Context, name, actor string) error {
+	return r.withChange(ctx, model.RoleChange{Actor: actor, Action: model.RoleActionDelete, Role: name},
+		func(q *db.Queries) error {
+			role, err := getRole(ctx, q, name)
+			if err != nil {
+				return err
+			}
+			if _, err := q.DeleteRole(ctx, role.ID); err != nil {
+				return fmt.Errorf("delete role: %w", err)
+			}
+			if err := q.RemoveRoleFromClients(ctx, name); err != nil {
+				return fmt.Errorf("remove role from clients: %w", err)
+			}
+			return nil
+		})
+}
+
+func (r *RoleRepo) AddPermission(ctx context.Context, role, permission, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionAddPermission, Role: role, Permission: permission}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		dbRole, err := getRole(ctx, q, role)
+		if err != nil {
+			return err
+		}
+		if err := q.AddRolePermission(ctx, db.AddRolePermissionParams{RoleID: dbRole.ID, Permission: permission}); err != nil {
+			return fmt.Errorf("add role permission: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *RoleRepo) RemovePermission(ctx context.Context, role, permission, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionRemovePermission, Role: role, Permission: permission}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		dbRole, err := getRole(ctx, q, role)
+		if err != nil {
+			return err
+		}
+		n, err := q.RemoveRolePermission(ctx, db.RemoveRolePermissionParams{RoleID: dbRole.ID, Permission: permission})
+		if err != nil {
+			return fmt.Errorf("remove role permission: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *RoleRepo) GrantToUser(ctx context.Context, userID uuid.UUID, role, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionGrant, Role: role,
+		SubjectType: model.RoleSubjectUser, SubjectID: userID.String()}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		dbRole, err := getRole(ctx, q, role)
+		if err != nil {
+			return err
+		}
+		err = q.CreateUserRole(ctx, db.CreateUserRoleParams{
+			UserID: pgtype.UUID{Bytes: userID, Valid: true},
+			RoleID: dbRole.ID,
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+				return appErr.ErrNotFound
+			}
+			return fmt.Errorf("create user role: %w", err)
+		}
+		return nil
+	})
+}
+
+// RevokeFromUser returns ErrNotFound if the user does not have the role.
+func (r *RoleRepo) RevokeFromUser(ctx context.Context, userID uuid.UUID, role, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionRevoke, Role: role,
+		SubjectType: model.RoleSubjectUser, SubjectID: userID.String()}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		dbRole, err := getRole(ctx, q, role)
+		if err != nil {
+			return err
+		}
+		n, err := q.DeleteUserRole(ctx, db.DeleteUserRoleParams{
+			UserID: pgtype.UUID{Bytes: userID, Valid: true},
+			RoleID: dbRole.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("delete user role: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *RoleRepo) GrantToClient(ctx context.Context, clientID, role, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionGrant, Role: role,
+		SubjectType: model.RoleSubjectClient, SubjectID: clientID}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		if _, err := getRole(ctx, q, role); err != nil {
+			return err
+		}
+		n, err := q.AddClientRole(ctx, db.AddClientRoleParams{Role: role, ID: clientID})
+		if err != nil {
+			return fmt.Errorf("add client role: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// RevokeFromClient returns ErrNotFound if the client does not have the role.
+func (r *RoleRepo) RevokeFromClient(ctx context.Context, clientID, role, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionRevoke, Role: role,
+		SubjectType: model.RoleSubjectClient, SubjectID: clientID}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		n, err := q.RemoveClientRole(ctx, db.RemoveClientRoleParams{Role: role, ID: clientID})
+		if err != nil {
+			return fmt.Errorf("remove client role: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// SetClientRoles replaces the roles of a client. All roles must exist.
+func (r *RoleRepo) SetClientRoles(ctx context.Context, clientID string, roles []string, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionSet, Role: strings.Join(roles, ","),
+		SubjectType: model.RoleSubjectClient, SubjectID: clientID}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		for _, role := range roles {
+			if _, err := getRole(ctx, q, role); err != nil {
+				return err
+			}
+		}
+		n, err := q.UpdateClientRoles(ctx, db.UpdateClientRolesParams{ID: clientID, Roles: nonNil(roles)})
+		if err != nil {
+			return fmt.Errorf("update client roles: %w", err)
+		}
+		if n == 0 {
+			return appErr.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// SetMFARequired decides whether users with the role must sign in with a
+// second factor.
+func (r *RoleRepo) SetMFARequired(ctx context.Context, role string, required bool, actor string) error {
+	change := model.RoleChange{Actor: actor, Action: model.RoleActionWaiveMFA, Role: role}
+	if required {
+		change.Action = model.RoleActionRequireMFA
+	}
+	return r.withChange(ctx, change, func(q *db.Queries) error {
+		dbRole, err := getRole(ctx, q, role)
+		if err != nil {
+			return err
+		}
+		if required {
+			if err := q.RequireRoleMFA(ctx, dbRole.ID); err != nil {
+				return fmt.Errorf("require role mfa: %w", err)
+			}
+			return nil
+		}
+		if _, err := q.WaiveRoleMFA(ctx, dbRole.ID); err != nil {
+			return fmt.Errorf("waive role mfa: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *RoleRepo) ListChanges(ctx context.Context, limit, offset int32) ([]model.RoleChange, error) {
+	rows, err := r.q.ListRoleChanges(ctx, db.ListRoleChangesParams{Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, fmt.Errorf("list role changes: %w", err)
+	}
+	changes := make([]model.RoleChange, len(rows))
+	for i, row := range rows {
+		changes[i] = toDomainFromRoleChange(row)
+	}
+	return changes, nil
+}
+
+// withChange runs fn and records change in one transaction.
+func (r *RoleRepo) withChange(ctx context.Context, change model.RoleChange, fn func(q *db.Queries) error) error {
+	return r.withChanges(ctx, []model.RoleChange{change}, fn)
+}
+
+// withChanges runs fn and records changes in one transaction.
+func (r *RoleRepo) withChanges(ctx context.Context, changes []model.RoleChange, fn func(q *db.Queries) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+	if err := fn(qtx); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if err := qtx.CreateRoleChange(ctx, db.CreateRoleChangeParams{
+			Actor:       change.Actor,
+			Action:      change.Action,
+			RoleName:    change.Role,
+			SubjectType: change.SubjectType,
+			SubjectID:   change.SubjectID,
+			Permission:  change.Permission,
+		}); err != nil {
+			return fmt.Errorf("record role change: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func getRole(ctx context.Context, q *db.Queries, name string) (db.Role, error) {
+	role, err := q.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Role{}, appErr.ErrRoleNotFound
+		}
+		return db.Role{}, fmt.Errorf("get role by name: %w", err)
+	}
+	return role, nil
+}
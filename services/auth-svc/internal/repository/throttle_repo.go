@@ -0,0 +1,115 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+)
+
+// ThrottleRepository is the Postgres backend of the sign-in throttle and the
+// log of lockouts, which is kept in Postgres whatever the backend.
+type ThrottleRepository interface {
+	throttle.Store
+	throttle.Auditor
+	ListLockouts(ctx context.Context, limit, offset int32) ([]model.LockoutEvent, error)
+	// DeleteExpired removes the failure counters past their expiry and
+	// returns how many were deleted.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type ThrottleRepo struct {
+	q *db.Queries
+}
+
+func NewThrottleRepository(pool *pgxpool.Pool) ThrottleRepository {
+	return &ThrottleRepo{q: db.New(pool)}
+}
+
+func (r *ThrottleRepo) Get(ctx context.Context, key string) (throttle.Entry, error) {
+	row, err := r.q.GetLoginFailure(ctx, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return throttle.Entry{}, nil
+		}
+		return throttle.Entry{}, fmt.Errorf("get login failure: %w", err)
+	}
+	return throttle.Entry{
+		Failures:    int(row.Failures),
+		LockedUntil: row.LockedUntil.Time,
+	}, nil
+}
+
+func (r *ThrottleRepo) AddFailure(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	n, err := r.q.AddLoginFailure(ctx, db.AddLoginFailureParams{
+		Key:       key,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("add login failure: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *ThrottleRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	if err := r.q.LockLoginFailure(ctx, db.LockLoginFailureParams{
+		Key:         key,
+		LockedUntil: pgtype.Timestamptz{Time: until, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("lock login failure: %w", err)
+	}
+	return nil
+}
+
+func (r *ThrottleRepo) Reset(ctx context.Context, key string) error {
+	if err := r.q.DeleteLoginFailure(ctx, key); err != nil {
+		return fmt.Errorf("delete login failure: %w", err)
+	}
+	return nil
+}
+
+func (r *ThrottleRepo) RecordLockout(ctx context.Context, e *model.LockoutEvent) error {
+	params := db.CreateLockoutEventParams{
+		Kind:     e.Kind,
+		Subject:  e.Subject,
+		Action:   e.Action,
+		Actor:    pgtype.Text{String: e.Actor, Valid: e.Actor != ""},
+		Ip:       pgtype.Text{String: e.IP, Valid: e.IP != ""},
+		Failures: int32(e.Failures),
+	}
+	if e.LockedUntil != nil {
+		params.LockedUntil = pgtype.Timestamptz{Time: *e.LockedUntil, Valid: true}
+	}
+	if err := r.q.CreateLockoutEvent(ctx, params); err != nil {
+		return fmt.Errorf("create lockout event: %w", err)
+	}
+	return nil
+}
+
+func (r *ThrottleRepo) ListLockouts(ctx context.Context, limit, offset int32) ([]model.LockoutEvent, error) {
+	rows, err := r.q.ListLockoutEvents(ctx, db.ListLockoutEventsParams{Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, fmt.Errorf("list lockout events: %w", err)
+	}
+	events := make([]model.LockoutEvent, len(rows))
+	for i, row := range rows {
+		events[i] = toDomainFromLockoutEvent(row)
+	}
+	return events, nil
+}
+
+func (r *ThrottleRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	n, err := r.q.DeleteExpiredLoginFailures(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired login failures: %w", err)
+	}
+	return n, nil
+}
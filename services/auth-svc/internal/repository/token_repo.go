@@ -0,0 +1,236 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.com/oidiral/e-commerce/services/auth-svc/db/sqlc"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	appErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+type TokenRepository interface {
+	// CreateSession records a new session together with its first refresh
+	// token, whose FamilyID is the ID of the session.
+	CreateSession(ctx context.Context, s *model.Session, first *model.RefreshToken) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error)
+	// RevokeSession revokes the refresh tokens of a session of the user. It
+	// returns ErrNotFound if the user has no such session.
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	GetRefreshToken(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken, seen *model.Session) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) error
+	RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// ListRevokedTokens returns a page of the unexpired denylist entries
+	// added since the given time, oldest first.
+	ListRevokedTokens(ctx context.Context, since time.Time, limit, offset int32) ([]model.RevokedToken, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type TokenRepo struct {
+	q  *db.Queries
+	db *pgxpool.Pool
+}
+
+func NewTokenRepository(pool *pgxpool.Pool) TokenRepository {
+	return &TokenRepo{
+		q:  db.New(pool),
+		db: pool,
+	}
+}
+
+func (r *TokenRepo) CreateSession(ctx context.Context, s *model.Session, first *model.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+
+	if err = qtx.CreateSession(ctx, db.CreateSessionParams{
+		ID:        pgtype.UUID{Bytes: s.ID, Valid: true},
+		UserID:    pgtype.UUID{Bytes: s.UserID, Valid: true},
+		UserAgent: s.UserAgent,
+		Ip:        s.IP,
+	}); err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+	if err = qtx.CreateRefreshToken(ctx, toCreateRefreshTokenParams(first)); err != nil {
+		return fmt.Errorf("create refresh token: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns the sessions of the user that can still be refreshed,
+// most recently used first.
+func (r *TokenRepo) ListSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	rows, err := r.q.ListUserSessions(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("list user sessions: %w", err)
+	}
+	sessions := make([]model.Session, 0, len(rows))
+	for _, row := range rows {
+		s, err := toDomainFromListUserSessionsRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("convert to domain model: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+func (r *TokenRepo) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	n, err := r.q.RevokeUserSession(ctx, db.RevokeUserSessionParams{
+		FamilyID: pgtype.UUID{Bytes: sessionID, Valid: true},
+		UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("revoke user session: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TokenRepo) GetRefreshToken(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error) {
+	row, err := r.q.GetRefreshToken(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, appErr.ErrNotFound
+		}
+		return nil, fmt.Errorf("get refresh token: %w", err)
+	}
+	t, err := toDomainFromRefreshToken(row)
+	if err != nil {
+		return nil, fmt.Errorf("convert to domain model: %w", err)
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken marks oldID as used and stores next in the same
+// transaction. It returns ErrTokenReused when oldID has already been rotated
+// or revoked, so two concurrent refreshes cannot both succeed. The session of
+// next is updated with the device seen refreshing it.
+func (r *TokenRepo) RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken, seen *model.Session) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	qtx := r.q.WithTx(tx)
+
+	n, err := qtx.RotateRefreshToken(ctx, pgtype.UUID{Bytes: oldID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("rotate refresh token: %w", err)
+	}
+	if n == 0 {
+		return appErr.ErrTokenReused
+	}
+
+	if err = qtx.CreateRefreshToken(ctx, toCreateRefreshTokenParams(next)); err != nil {
+		return fmt.Errorf("create refresh token: %w", err)
+	}
+	if err = qtx.TouchSession(ctx, db.TouchSessionParams{
+		ID:        pgtype.UUID{Bytes: next.FamilyID, Valid: true},
+		UserAgent: seen.UserAgent,
+		Ip:        seen.IP,
+	}); err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *TokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := r.q.RevokeRefreshTokenFamily(ctx, pgtype.UUID{Bytes: familyID, Valid: true}); err != nil {
+		return fmt.Errorf("revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *TokenRepo) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	if err := r.q.RevokeUserRefreshTokens(ctx, pgtype.UUID{Bytes: userID, Valid: true}); err != nil {
+		return fmt.Errorf("revoke user refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *TokenRepo) RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := r.q.RevokeTokenID(ctx, db.RevokeTokenIDParams{
+		Jti:       jti,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("revoke token id: %w", err)
+	}
+	return nil
+}
+
+func (r *TokenRepo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	revoked, err := r.q.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("check token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+func (r *TokenRepo) ListRevokedTokens(ctx context.Context, since time.Time, limit, offset int32) ([]model.RevokedToken, error) {
+	rows, err := r.q.ListRevokedTokens(ctx, db.ListRevokedTokensParams{
+		RevokedAt: pgtype.Timestamptz{Time: since, Valid: true},
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list revoked tokens: %w", err)
+	}
+	tokens := make([]model.RevokedToken, len(rows))
+	for i, row := range rows {
+		tokens[i] = toDomainFromRevokedToken(row)
+	}
+	return tokens, nil
+}
+
+// DeleteExpired removes refresh tokens and denylist entries that are past
+// their expiry and the sessions left without refresh tokens, and returns how
+// many rows were deleted.
+func (r *TokenRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	refresh, err := r.q.DeleteExpiredRefreshTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired refresh tokens: %w", err)
+	}
+	revoked, err := r.q.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		return refresh, fmt.Errorf("delete expired revoked tokens: %w", err)
+	}
+	sessions, err := r.q.DeleteEndedSessions(ctx)
+	if err != nil {
+		return refresh + revoked, fmt.Errorf("delete ended sessions: %w", err)
+	}
+	return refresh + revoked + sessions, nil
+}
@@ -0,0 +1,32 @@
+// Package requestinfo carries facts about the HTTP request that services need
+// but should not take from gin, such as the caller's address and user agent.
+package requestinfo
+
+import "context"
+
+type (
+	clientIPKey  struct{}
+	userAgentKey struct{}
+)
+
+// WithClientIP returns a copy of ctx that carries the caller's IP address.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the caller's IP address, or "" if ctx does not carry one.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// WithUserAgent returns a copy of ctx that carries the caller's User-Agent.
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// UserAgent returns the caller's User-Agent, or "" if ctx does not carry one.
+func UserAgent(ctx context.Context) string {
+	ua, _ := ctx.Value(userAgentKey{}).(string)
+	return ua
+}
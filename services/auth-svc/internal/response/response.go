@@ -28,3 +28,19 @@ func RespondWithError(ctx *gin.Context, code int, message string, details interf
 func BadRequest(ctx *gin.Context, message string, details interface{}) {
 	RespondWithError(ctx, http.StatusBadRequest, message, details)
 }
+
+// OAuthError is the RFC 6749 section 5.2 error body. OAuth endpoints use it
+// instead of ApiError so that standard client libraries can read it.
+type OAuthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func RespondWithOAuthError(ctx *gin.Context, code int, errCode, description string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+	ctx.AbortWithStatusJSON(code, OAuthError{
+		Error:       errCode,
+		Description: description,
+	})
+}
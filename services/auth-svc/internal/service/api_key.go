@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+)
+
+// API keys look like "ak_<prefix>_<secret>". The prefix is hex, so it never
+// contains the separator, and is unique: it is how a presented key is found.
+const (
+	apiKeyMarker      = "ak_"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+// APIKeyToken is the access token an API key was exchanged for. No refresh
+// token is issued: scripts exchange the key again.
+type APIKeyToken struct {
+	AccessToken     string `json:"accessToken"`
+	AccessExpiresAt int64  `json:"accessExpiresAt"`
+	Scope           string `json:"scope"`
+}
+
+// CreateAPIKey creates a key of the user p signed in as, limited to scopes
+// and valid until expiresAt, or for good if it is nil. The plaintext key is
+// returned only here; just its hash is stored.
+//
+// A key may not carry scopes the token of p lacks, and tokens obtained with
+// a key cannot create keys, so that a leaked key cannot be used to mint
+// keys outliving its revocation. Users whose roles require a second factor
+// get ErrMFARequired: a key would bypass it.
+func (s *AuthService) CreateAPIKey(ctx context.Context, p *user.Principal, name string, scopes []string, expiresAt *time.Time) (*user.APIKey, string, error) {
+	if p.FromAPIKey() {
+		return nil, "", AppErr.ErrForbidden
+	}
+	if len(scopes) == 0 || (len(p.Scopes) > 0 && !isSubset(scopes, p.Scopes)) {
+		return nil, "", AppErr.ErrInvalidScope
+	}
+	userID, err := uuid.Parse(p.Subject)
+	if err != nil {
+		return nil, "", AppErr.ErrInvalidToken
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, "", AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return nil, "", err
+	}
+	if u.Status != user.UserStatusActive {
+		return nil, "", AppErr.ErrUserDisabled
+	}
+	if u.MFARequired {
+		return nil, "", AppErr.ErrMFARequired
+	}
+	n, err := s.apiKeys.CountActive(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Msg("count api keys")
+		return nil, "", err
+	}
+	if n >= s.cfg.APIKey.MaxPerUser {
+		return nil, "", AppErr.ErrAPIKeyLimit
+	}
+
+	raw, prefix, err := newAPIKey()
+	if err != nil {
+		s.log.Error().Err(err).Msg("generate api key")
+		return nil, "", err
+	}
+	k := &user.APIKey{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: utils.HashToken(raw),
+		Scopes:     ParseScope(strings.Join(scopes, " ")),
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.apiKeys.Create(ctx, k); err != nil {
+		s.log.Error().Err(err).Msg("store api key")
+		return nil, "", err
+	}
+	s.log.Info().Str("user_id", userID.String()).Str("key_id", k.ID.String()).Msg("api key created")
+	details := map[string]string{"key_id": k.ID.String(), "name": k.Name, "scope": strings.Join(k.Scopes, " ")}
+	if expiresAt != nil {
+		details["expires_at"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditAPIKeyCreated,
+		Actor:   p.Subject,
+		Subject: userID.String(),
+		Details: details,
+	})
+	return k, raw, nil
+}
+
+// ListAPIKeys returns the keys of the user that were not revoked, expired
+// ones included, newest first.
+func (s *AuthService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]user.APIKey, error) {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	keys, err := s.apiKeys.List(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Msg("list api keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key of the user on behalf of actor. Access tokens
+// it was exchanged for run until they expire.
+func (s *AuthService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID, actor string) error {
+	if err := s.apiKeys.Revoke(ctx, userID, keyID); err != nil {
+		return err
+	}
+	s.log.Info().Str("user_id", userID.String()).Str("key_id", keyID.String()).
+		Str("actor", actor).Msg("api key revoked")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditAPIKeyRevoked,
+		Actor:   actor,
+		Subject: userID.String(),
+		Details: map[string]string{"key_id": keyID.String()},
+	})
+	return nil
+}
+
+// ExchangeAPIKey issues a short-lived access token for the owner of the key,
+// carrying the scopes of the key. Like a sign-in, it is refused for disabled
+// users and, under the deny policy, unverified ones. The token carries no
+// roles or permissions: a leaked key must not open the admin API, whatever
+// its scopes. Unknown, revoked and expired keys, and keys of deleted users,
+// count as failed attempts of the IP address.
+func (s *AuthService) ExchangeAPIKey(ctx context.Context, raw string) (*APIKeyToken, error) {
+	ip := requestinfo.ClientIP(ctx)
+	if err := s.guard.Check(ctx, throttle.IP(ip)); err != nil {
+		return nil, err
+	}
+	k, reason := s.lookupAPIKey(ctx, raw)
+	if reason != "" {
+		s.guard.Fail(ctx, ip, throttle.IP(ip))
+		s.auditAPIKeyExchange(ctx, k, reason)
+		return nil, AppErr.ErrInvalidCredentials
+	}
+
+	u, err := s.repo.GetByID(ctx, k.UserID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			s.guard.Fail(ctx, ip, throttle.IP(ip))
+			s.auditAPIKeyExchange(ctx, k, "user not found")
+			return nil, AppErr.ErrInvalidCredentials
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return nil, err
+	}
+	switch {
+	case u.Status != user.UserStatusActive:
+		s.auditAPIKeyExchange(ctx, k, "user disabled")
+		return nil, AppErr.ErrUserDisabled
+	case s.cfg.Verify.UnverifiedPolicy == config.UnverifiedDeny && !u.EmailVerified:
+		s.auditAPIKeyExchange(ctx, k, "email not verified")
+		return nil, AppErr.ErrEmailNotVerified
+	// A role may have started to require a second factor after the key
+	// was created.
+	case u.MFARequired:
+		s.auditAPIKeyExchange(ctx, k, "second factor required")
+		return nil, AppErr.ErrMFARequired
+	}
+
+	now := time.Now()
+	exp := now.Add(s.cfg.APIKey.TokenTTL)
+	jw, err := s.signToken(accessClaims(unprivileged(u), grant{scopes: k.Scopes, amr: []string{amrAPIKey}}, now, exp))
+	if err != nil {
+		s.log.Error().Err(err).Msg("create api key access token")
+		return nil, err
+	}
+	if err := s.apiKeys.Touch(ctx, k.ID); err != nil {
+		s.log.Error().Err(err).Str("key_id", k.ID.String()).Msg("record api key use")
+	}
+	s.auditAPIKeyExchange(ctx, k, "")
+	return &APIKeyToken{AccessToken: jw, AccessExpiresAt: exp.Unix(), Scope: strings.Join(k.Scopes, " ")}, nil
+}
+
+// lookupAPIKey finds the key raw was issued as. If it cannot be used, reason
+// tells why, and k is the key found, if any.
+func (s *AuthService) lookupAPIKey(ctx context.Context, raw string) (k *user.APIKey, reason string) {
+	prefix, ok := apiKeyPrefix(raw)
+	if !ok {
+		return nil, "malformed key"
+	}
+	k, err := s.apiKeys.Get(ctx, prefix)
+	if err != nil {
+		if !errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Err(err).Msg("get api key")
+		}
+		return nil, "unknown key"
+	}
+	switch {
+	case subtle.ConstantTimeCompare([]byte(utils.HashToken(raw)), []byte(k.SecretHash)) != 1:
+		return k, "wrong secret"
+	case k.RevokedAt != nil:
+		return k, "key revoked"
+	case k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()):
+		return k, "key expired"
+	}
+	return k, ""
+}
+
+// auditAPIKeyExchange records an exchange of k, failed for reason unless it
+// is empty. k is nil if no such key exists.
+func (s *AuthService) auditAPIKeyExchange(ctx context.Context, k *user.APIKey, reason string) {
+	e := user.AuditEvent{Type: user.AuditAPIKeyExchanged, Details: map[string]string{}}
+	if k != nil {
+		e.Subject = k.UserID.String()
+		e.Details["key_id"] = k.ID.String()
+		if reason == "" {
+			e.Actor = e.Subject
+		}
+	}
+	if reason != "" {
+		e.Outcome = user.AuditFailure
+		e.Details["reason"] = reason
+	}
+	s.audit.Record(ctx, e)
+}
+
+// newAPIKey returns a new key and its prefix.
+func newAPIKey() (raw, prefix string, err error) {
+	buf := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(buf)
+	secret, err := utils.GenerateSecret(apiKeySecretBytes)
+	if err != nil {
+		return "", "", err
+	}
+	return apiKeyMarker + prefix + "_" + secret, prefix, nil
+}
+
+// apiKeyPrefix returns the prefix of a key in the format of newAPIKey.
+func apiKeyPrefix(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, apiKeyMarker)
+	n := hex.EncodedLen(apiKeyPrefixBytes)
+	if !ok || len(rest) <= n+1 || rest[n] != '_' {
+		return "", false
+	}
+	return rest[:n], true
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAPIKeys(t *testing.T) (*config.Config, *model.User) {
+	t.Helper()
+	cfg, _ := setupRSA(t)
+	cfg.APIKey = config.APIKeyConfig{TokenTTL: 10 * time.Minute, MaxPerUser: 3}
+	u := &model.User{
+		ID:            uuid.New(),
+		Email:         "buyer@example.com",
+		Status:        model.UserStatusActive,
+		Roles:         []string{"customer"},
+		EmailVerified: true,
+	}
+	return cfg, u
+}
+
+// storedAPIKey returns a key in the format of newAPIKey as it would be
+// stored for u.
+func storedAPIKey(t *testing.T, u *model.User) (*model.APIKey, string) {
+	t.Helper()
+	raw, prefix, err := newAPIKey()
+	require.NoError(t, err)
+	return &model.APIKey{
+		ID:         uuid.New(),
+		UserID:     u.ID,
+		Prefix:     prefix,
+		SecretHash: utils.HashToken(raw),
+		Scopes:     []string{"cart:write"},
+	}, raw
+}
+
+func TestAuthService_APIKey_CreateAndExchange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+	cfg, u := setupAPIKeys(t)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, mockKeys, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	var stored *model.APIKey
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+	mockKeys.EXPECT().CountActive(gomock.Any(), u.ID).Return(2, nil)
+	mockKeys.EXPECT().Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, k *model.APIKey) error {
+			stored = k
+			return nil
+		})
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	p := &model.Principal{Subject: u.ID.String(), AMR: []string{amrPassword}}
+	k, raw, err := authService.CreateAPIKey(context.Background(), p, "ci", []string{"cart:write", "catalog:read"}, &expiresAt)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, "ak_"+k.Prefix+"_"))
+	assert.Same(t, stored, k)
+	assert.NotContains(t, stored.SecretHash, raw, "ключ хранится только в виде хэша")
+	assert.Equal(t, []string{"cart:write", "catalog:read"}, stored.Scopes)
+
+	// The owner is an admin: the token must not inherit that.
+	current := *u
+	current.Roles = []string{"customer", "admin"}
+	current.Permissions = []string{"users:write"}
+	mockKeys.EXPECT().Get(gomock.Any(), k.Prefix).Return(stored, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(&current, nil)
+	mockKeys.EXPECT().Touch(gomock.Any(), k.ID).Return(nil)
+
+	token, err := authService.ExchangeAPIKey(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "cart:write catalog:read", token.Scope)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), time.Unix(token.AccessExpiresAt, 0), time.Minute)
+
+	claims, err := authService.parseToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID.String(), claims["sub"])
+	assert.Equal(t, "cart:write catalog:read", claims["scope"])
+	assert.Empty(t, claims["roles"], "токен по API-ключу не должен нести роли")
+	assert.NotContains(t, claims, permissionsClaim)
+	assert.Equal(t, []any{amrAPIKey}, claims[amrClaim])
+	assert.NotContains(t, claims, sessionClaim)
+}
+
+func TestAuthService_CreateAPIKey_Refused(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal func(u *model.User) *model.Principal
+		scopes    []string
+		user      func(u *model.User)
+		count     int
+		err       error
+	}{
+		{
+			name: "token obtained with an api key",
+			principal: func(u *model.User) *model.Principal {
+				return &model.Principal{Subject: u.ID.String(), AMR: []string{amrAPIKey}, Scopes: []string{"cart:write"}}
+			},
+			scopes: []string{"cart:write"},
+			err:    AppErr.ErrForbidden,
+		},
+		{
+			name: "no scopes",
+			err:  AppErr.ErrInvalidScope,
+		},
+		{
+			name: "scope beyond the token",
+			principal: func(u *model.User) *model.Principal {
+				return &model.Principal{Subject: u.ID.String(), Scopes: []string{"catalog:read"}}
+			},
+			scopes: []string{"cart:write"},
+			err:    AppErr.ErrInvalidScope,
+		},
+		{
+			name:   "role requires a second factor",
+			scopes: []string{"cart:write"},
+			user:   func(u *model.User) { u.MFARequired = true },
+			err:    AppErr.ErrMFARequired,
+		},
+		{
+			name:   "disabled user",
+			scopes: []string{"cart:write"},
+			user:   func(u *model.User) { u.Status = model.UserStatusDisabled },
+			err:    AppErr.ErrUserDisabled,
+		},
+		{
+			name:   "too many keys",
+			scopes: []string{"cart:write"},
+			count:  3,
+			err:    AppErr.ErrAPIKeyLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockAuthRepository(ctrl)
+			mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+			cfg, u := setupAPIKeys(t)
+			authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, mockKeys, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+			if tt.user != nil {
+				tt.user(u)
+			}
+			p := &model.Principal{Subject: u.ID.String(), AMR: []string{amrPassword}}
+			if tt.principal != nil {
+				p = tt.principal(u)
+			}
+			mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).AnyTimes()
+			mockKeys.EXPECT().CountActive(gomock.Any(), u.ID).Return(tt.count, nil).AnyTimes()
+
+			_, _, err := authService.CreateAPIKey(context.Background(), p, "ci", tt.scopes, nil)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestAuthService_ExchangeAPIKey_Rejected(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name string
+		// raw replaces the key presented, if set.
+		raw  func(raw string) string
+		key  func(k *model.APIKey)
+		user func(u *model.User)
+		// userErr is returned by the user lookup, if set.
+		userErr error
+		err     error
+	}{
+		{
+			name: "malformed key",
+			raw:  func(string) string { return "not-a-key" },
+			err:  AppErr.ErrInvalidCredentials,
+		},
+		{
+			name: "wrong secret",
+			raw:  func(raw string) string { return raw + "x" },
+			err:  AppErr.ErrInvalidCredentials,
+		},
+		{
+			name: "revoked key",
+			key:  func(k *model.APIKey) { k.RevokedAt = &past },
+			err:  AppErr.ErrInvalidCredentials,
+		},
+		{
+			name: "expired key",
+			key:  func(k *model.APIKey) { k.ExpiresAt = &past },
+			err:  AppErr.ErrInvalidCredentials,
+		},
+		{
+			name:    "deleted user",
+			userErr: AppErr.ErrNotFound,
+			err:     AppErr.ErrInvalidCredentials,
+		},
+		{
+			name: "disabled user",
+			user: func(u *model.User) { u.Status = model.UserStatusDisabled },
+			err:  AppErr.ErrUserDisabled,
+		},
+		{
+			name: "role started to require a second factor",
+			user: func(u *model.User) { u.MFARequired = true },
+			err:  AppErr.ErrMFARequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockAuthRepository(ctrl)
+			mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+			cfg, u := setupAPIKeys(t)
+			authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, mockKeys, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+			k, raw := storedAPIKey(t, u)
+			if tt.key != nil {
+				tt.key(k)
+			}
+			if tt.user != nil {
+				tt.user(u)
+			}
+			if tt.raw != nil {
+				raw = tt.raw(raw)
+			}
+			mockKeys.EXPECT().Get(gomock.Any(), k.Prefix).Return(k, nil).AnyTimes()
+			if tt.userErr != nil {
+				mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(nil, tt.userErr)
+			} else {
+				mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).AnyTimes()
+			}
+
+			token, err := authService.ExchangeAPIKey(context.Background(), raw)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Nil(t, token)
+		})
+	}
+}
+
+func TestAPIKeyPrefix(t *testing.T) {
+	raw, prefix, err := newAPIKey()
+	require.NoError(t, err)
+	got, ok := apiKeyPrefix(raw)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, got)
+
+	for _, bad := range []string{"", "ak_", "ak_" + prefix, "ak_" + prefix + "_", "xx_" + prefix + "_secret", "ak_short_secret"} {
+		_, ok := apiKeyPrefix(bad)
+		assert.False(t, ok, "ключ %q не должен распознаваться", bad)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
+	"github.com/rs/zerolog"
+)
+
+// AuditService lets administrators query the audit log and purges entries
+// past their retention.
+type AuditService struct {
+	repo      repository.AuditRepository
+	log       zerolog.Logger
+	retention time.Duration
+}
+
+func NewAuditService(repo repository.AuditRepository, log zerolog.Logger, retention time.Duration) *AuditService {
+	return &AuditService{repo: repo, log: log, retention: retention}
+}
+
+// AuditPage is a page of audit events, newest first. NextCursor fetches the
+// following page and is empty on the last one.
+type AuditPage struct {
+	Events     []user.AuditEvent
+	NextCursor string
+}
+
+// Events returns up to limit events matching f, starting after cursor, which
+// is empty for the first page. A cursor that was not returned by Events
+// yields ErrInvalidCursor.
+func (s *AuditService) Events(ctx context.Context, f user.AuditFilter, cursor string, limit int32) (*AuditPage, error) {
+	if cursor != "" {
+		id, ok := decodeAuditCursor(cursor)
+		if !ok {
+			return nil, AppErr.ErrInvalidCursor
+		}
+		f.BeforeID = id
+	}
+	// One event more than asked for tells whether there is a next page.
+	events, err := s.repo.List(ctx, f, limit+1)
+	if err != nil {
+		s.log.Error().Err(err).Msg("list audit events")
+		return nil, err
+	}
+	page := &AuditPage{Events: events}
+	if len(events) > int(limit) {
+		page.Events = events[:limit]
+		page.NextCursor = encodeAuditCursor(page.Events[limit-1].ID)
+	}
+	return page, nil
+}
+
+// RunRetention purges audit events older than the retention period every
+// interval until ctx is cancelled.
+func (s *AuditService) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.purge(ctx)
+		}
+	}
+}
+
+func (s *AuditService) purge(ctx context.Context) {
+	n, err := s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		s.log.Error().Err(err).Msg("purge audit events")
+		return
+	}
+	s.log.Info().Int64("deleted", n).Msg("old audit events purged")
+}
+
+// Cursors are opaque to clients, so that paging may later change to another
+// key without breaking them.
+func encodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeAuditCursor(cursor string) (int64, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	return id, err == nil && id > 0
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditRecorder keeps the events recorded through it in memory.
+type auditRecorder struct {
+	events []model.AuditEvent
+}
+
+func (r *auditRecorder) Append(_ context.Context, e *model.AuditEvent) error {
+	r.events = append(r.events, *e)
+	return nil
+}
+
+func TestAuditService_EventsPaging(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	svc := NewAuditService(mockAudit, zerolog.Nop(), time.Hour)
+
+	f := model.AuditFilter{Type: model.AuditLogin, Outcome: model.AuditFailure}
+	mockAudit.EXPECT().List(gomock.Any(), f, int32(3)).Return([]model.AuditEvent{{ID: 30}, {ID: 20}, {ID: 10}}, nil)
+
+	page, err := svc.Events(context.Background(), f, "", 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, page.Events, 2)
+	if !assert.NotEmpty(t, page.NextCursor, "при наличии следующей страницы должен возвращаться курсор") {
+		return
+	}
+
+	next := f
+	next.BeforeID = 20
+	mockAudit.EXPECT().List(gomock.Any(), next, int32(3)).Return([]model.AuditEvent{{ID: 10}}, nil)
+
+	page, err = svc.Events(context.Background(), f, page.NextCursor, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Events, 1)
+	assert.Empty(t, page.NextCursor, "на последней странице курсора нет")
+}
+
+func TestAuditService_Events_InvalidCursor(t *testing.T) {
+	svc := NewAuditService(nil, zerolog.Nop(), time.Hour)
+
+	for _, cursor := range []string{"not base64!", encodeAuditCursor(0), "YWJj"} {
+		_, err := svc.Events(context.Background(), model.AuditFilter{}, cursor, 10)
+		assert.ErrorIs(t, err, AppErr.ErrInvalidCursor, cursor)
+	}
+}
+
+func TestAuditService_PurgeDeletesExpiredEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	svc := NewAuditService(mockAudit, zerolog.Nop(), 24*time.Hour)
+	mockAudit.
+		EXPECT().
+		DeleteBefore(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Minute)
+			return 3, nil
+		})
+
+	svc.purge(context.Background())
+}
+
+func TestAuthService_Login_IsAudited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	cfg, _ := setupRSA(t)
+	recorder := &auditRecorder{}
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil,
+		audit.New(recorder, zerolog.Nop()))
+
+	hash, _ := passhash.New(config.HashConfig{}).Hash("password")
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: hash, Status: model.UserStatusActive, MFAEnabled: true}
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil).Times(2)
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "nobody@example.com").Return(nil, AppErr.ErrNotFound)
+
+	ctx := requestinfo.WithUserAgent(requestinfo.WithClientIP(context.Background(), "203.0.113.7"), "Mozilla/5.0")
+	_, err := authService.Login(ctx, u.Email, "wrong")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidCredentials)
+	_, err = authService.Login(ctx, "nobody@example.com", "password")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidCredentials)
+	_, err = authService.Login(ctx, u.Email, "password")
+	assert.NoError(t, err)
+
+	if !assert.Len(t, recorder.events, 3) {
+		return
+	}
+	wrong, unknown, ok := recorder.events[0], recorder.events[1], recorder.events[2]
+	assert.Equal(t, model.AuditLogin, wrong.Type)
+	assert.Equal(t, model.AuditFailure, wrong.Outcome)
+	assert.Equal(t, u.ID.String(), wrong.Subject)
+	assert.Empty(t, wrong.Actor, "неудачная попытка не должна приписываться владельцу учетной записи")
+	assert.Equal(t, "wrong password", wrong.Details["reason"])
+	assert.Equal(t, "203.0.113.7", wrong.IP)
+	assert.Equal(t, "Mozilla/5.0", wrong.UserAgent)
+
+	assert.Equal(t, "nobody@example.com", unknown.Subject)
+	assert.Equal(t, "unknown email", unknown.Details["reason"])
+
+	assert.Equal(t, model.AuditSuccess, ok.Outcome)
+	assert.Equal(t, u.ID.String(), ok.Actor)
+	assert.Equal(t, "pending", ok.Details["second_factor"], "вход еще ждет второго фактора")
+}
+
+func TestAuthService_Refresh_ReuseIsAudited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, privKey := setupRSA(t)
+	recorder := &auditRecorder{}
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil,
+		audit.New(recorder, zerolog.Nop()))
+
+	rotatedAt := time.Now().Add(-time.Minute)
+	stored := &model.RefreshToken{ID: uuid.New(), FamilyID: uuid.New(), UserID: uuid.New(), RotatedAt: &rotatedAt}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub": stored.UserID.String(),
+		"jti": stored.ID.String(),
+		"typ": "refresh",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	mockTokens.EXPECT().GetRefreshToken(gomock.Any(), stored.ID).Return(stored, nil)
+	mockTokens.EXPECT().RevokeFamily(gomock.Any(), stored.FamilyID).Return(nil)
+
+	_, err := authService.Refresh(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, AppErr.ErrTokenReused)
+	if !assert.Len(t, recorder.events, 1, "повторное использование записывается одним событием") {
+		return
+	}
+	assert.Equal(t, model.AuditTokenReuse, recorder.events[0].Type)
+	assert.Equal(t, stored.UserID.String(), recorder.events[0].Subject)
+	assert.Equal(t, stored.FamilyID.String(), recorder.events[0].Details["session_id"])
+}
+
+func TestUserService_Disable_IsAudited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	recorder := &auditRecorder{}
+	svc := NewUserService(mockRepo, mockTokens, zerolog.Nop(), audit.New(recorder, zerolog.Nop()))
+
+	id := uuid.New()
+	mockRepo.EXPECT().SetStatus(gomock.Any(), id, model.UserStatusDisabled).Return(nil)
+	mockTokens.EXPECT().RevokeUserTokens(gomock.Any(), id).Return(nil)
+
+	assert.NoError(t, svc.Disable(context.Background(), id, "admin-id"))
+	assert.Equal(t, []model.AuditEvent{{
+		Type:    model.AuditUserDisabled,
+		Outcome: model.AuditSuccess,
+		Actor:   "admin-id",
+		Subject: id.String(),
+	}}, recorder.events)
+}
@@ -2,37 +2,106 @@ package service
 
 import (
 	"context"
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/json"
-	"encoding/pem"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
 	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
 	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/keys"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passpolicy"
 	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
-	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
 	"github.com/rs/zerolog"
-	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
 type AuthService struct {
-	repo    repository.AuthRepository
-	log     zerolog.Logger
-	cfg     *config.Config
-	cliRepo repository.ClientRepository
-	privKey *rsa.PrivateKey
-	jwks    json.RawMessage
+	repo      repository.AuthRepository
+	log       zerolog.Logger
+	cfg       *config.Config
+	cliRepo   repository.ClientRepository
+	tokenRepo repository.TokenRepository
+	codes     repository.AuthorizationCodeRepository
+	apiKeys   repository.APIKeyRepository
+	keys      *keys.Ring
+	mailer    mailer.Mailer
+	guard     *throttle.Guard
+	hasher    *passhash.Hasher
+	policy    *passpolicy.Policy
+	mfaRepo   repository.MFARepository
+	audit     *audit.Log
 }
 
-func NewAuthService(repo repository.AuthRepository, log zerolog.Logger, cfg *config.Config, cliRepo repository.ClientRepository) *AuthService {
-	svc := &AuthService{repo: repo, log: log, cfg: cfg, cliRepo: cliRepo}
-	svc.loadKeys()
-	return svc
+func NewAuthService(repo repository.AuthRepository, log zerolog.Logger, cfg *config.Config, cliRepo repository.ClientRepository, tokenRepo repository.TokenRepository, codes repository.AuthorizationCodeRepository, apiKeys repository.APIKeyRepository, keyRing *keys.Ring, m mailer.Mailer, guard *throttle.Guard, mfaRepo repository.MFARepository, auditLog *audit.Log) *AuthService {
+	hasher := passhash.New(cfg.Hash)
+	return &AuthService{repo: repo, log: log, cfg: cfg, cliRepo: cliRepo, tokenRepo: tokenRepo, codes: codes, apiKeys: apiKeys, keys: keyRing, mailer: m, guard: guard,
+		hasher: hasher, policy: passpolicy.New(cfg.Password, hasher, log), mfaRepo: mfaRepo, audit: auditLog}
+}
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+	tokenTypeClient  = "client"
+)
+
+const (
+	// permissionsClaim carries the effective permissions of the subject's roles.
+	permissionsClaim = "perms"
+	// emailVerifiedClaim tells whether the user has confirmed their email.
+	emailVerifiedClaim = "email_verified"
+	// amrClaim lists how the user authenticated (RFC 8176).
+	amrClaim = "amr"
+	// sessionClaim identifies the session a user token was issued in.
+	sessionClaim = "sid"
+	// authTimeClaim is when the user authenticated, carried over refreshes.
+	authTimeClaim = "auth_time"
+)
+
+// Authentication method references recorded in the amr claim.
+const (
+	amrPassword = "pwd"
+	amrOTP      = "otp"
+	amrMFA      = "mfa"
+	amrAPIKey   = user.AMRAPIKey
+)
+
+// grant describes what tokens are issued for besides the subject itself: the
+// OAuth client that asked for them, the scopes they carry, how and when the
+// user authenticated and the session they belong to. The nonce of an OpenID
+// Connect authorization request only goes into the ID token.
+type grant struct {
+	clientID  string
+	scopes    []string
+	amr       []string
+	authTime  int64
+	sessionID uuid.UUID
+	nonce     string
+}
+
+func (g grant) apply(claims jwt.MapClaims) {
+	if g.clientID != "" {
+		claims["client_id"] = g.clientID
+	}
+	if len(g.scopes) > 0 {
+		claims["scope"] = strings.Join(g.scopes, " ")
+	}
+	if len(g.amr) > 0 {
+		claims[amrClaim] = g.amr
+	}
+	if g.authTime != 0 {
+		claims[authTimeClaim] = g.authTime
+	}
+	if g.sessionID != uuid.Nil {
+		claims[sessionClaim] = g.sessionID.String()
+	}
 }
 
 type TokenPair struct {
@@ -40,14 +109,25 @@ type TokenPair struct {
 	RefreshToken     string `json:"refreshToken"`
 	AccessExpiresAt  int64  `json:"accessExpiresAt"`
 	RefreshExpiresAt int64  `json:"refreshExpiresAt"`
+	Scope            string `json:"scope,omitempty"`
+	// IDToken is the OpenID Connect ID token, set if the openid scope was
+	// granted.
+	IDToken string `json:"idToken,omitempty"`
 }
 type TokenService struct {
 	AccessToken     string `json:"accessToken"`
 	AccessExpiresAt int64  `json:"accessExpiresAt"`
 }
 
+// RegisterUser creates an account with an unverified email and mails the
+// verification link to it. Under the deny policy for unverified users no
+// tokens are issued and the returned pair is nil. Passwords breaking the
+// policy yield a *passpolicy.Error.
 func (s *AuthService) RegisterUser(ctx context.Context, email, password string) (*TokenPair, error) {
-	hash, err := utils.HashPassword(password)
+	if err := s.policy.Check(password, email, nil); err != nil {
+		return nil, err
+	}
+	hash, err := s.hasher.Hash(password)
 	if err != nil {
 		s.log.Error().Err(err).Msg("hash pwd")
 		return nil, err
@@ -55,139 +135,561 @@ func (s *AuthService) RegisterUser(ctx context.Context, email, password string)
 
 	u, err := s.repo.CreateIfNotExists(ctx, email, hash)
 	if err != nil {
+		if errors.Is(err, AppErr.ErrUserAlreadyExists) {
+			s.audit.Record(ctx, user.AuditEvent{
+				Type:    user.AuditUserRegistered,
+				Outcome: user.AuditFailure,
+				Subject: email,
+				Details: map[string]string{"reason": "email taken"},
+			})
+		}
+		return nil, err
+	}
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditUserRegistered,
+		Actor:   u.ID.String(),
+		Subject: u.ID.String(),
+		Details: map[string]string{"email": u.Email},
+	})
+
+	if err := s.sendVerification(ctx, u); err != nil {
+		s.log.Error().Err(err).Str("user_id", u.ID.String()).Msg("send verification mail")
+	}
+	if s.cfg.Verify.UnverifiedPolicy == config.UnverifiedDeny && !u.EmailVerified {
+		return nil, nil
+	}
+	return s.issueTokenPair(ctx, u, uuid.New(), grant{amr: []string{amrPassword}})
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is rotated: it can be used exactly once, and presenting it again revokes the
+// whole token family, because that means either the client or an attacker is
+// holding a stale copy.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	return s.refresh(ctx, refreshToken, "", nil)
+}
+
+// refresh implements Refresh for a given OAuth client. A token issued to a
+// client can only be refreshed by that client, and the requested scopes, if
+// any, must be a subset of the ones originally granted. Every attempt is
+// audited.
+func (s *AuthService) refresh(ctx context.Context, refreshToken, clientID string, requested []string) (pair *TokenPair, err error) {
+	var subject string
+	defer func() { s.auditRefresh(ctx, subject, clientID, err) }()
+
+	claims, err := s.parseTypedToken(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		s.log.Error().Err(err).Msg("parse refresh token")
+		return nil, AppErr.ErrInvalidToken
+	}
+	jti, err := uuidClaim(claims, "jti")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token id")
+		return nil, AppErr.ErrInvalidToken
+	}
+	subUUID, err := uuidClaim(claims, "sub")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token subject")
+		return nil, AppErr.ErrInvalidToken
+	}
+	subject = subUUID.String()
+
+	stored, err := s.tokenRepo.GetRefreshToken(ctx, jti)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Str("jti", jti.String()).Msg("refresh token not found")
+			return nil, AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get refresh token")
+		return nil, err
+	}
+	if stored.UserID != subUUID {
+		s.log.Error().Str("jti", jti.String()).Msg("refresh token subject mismatch")
+		return nil, AppErr.ErrInvalidToken
+	}
+	if stored.RevokedAt != nil {
+		s.log.Error().Str("jti", jti.String()).Msg("refresh token revoked")
+		return nil, AppErr.ErrInvalidToken
+	}
+	if stored.RotatedAt != nil {
+		return nil, s.handleReuse(ctx, stored)
+	}
+
+	current, err := s.repo.GetByID(ctx, subUUID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Str("user_id", subUUID.String()).Msg("refresh token user not found")
+			return nil, AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get user by id")
 		return nil, err
 	}
+	if current.Status != user.UserStatusActive {
+		s.log.Warn().Str("user_id", subUUID.String()).Msg("refresh attempted for disabled user")
+		return nil, AppErr.ErrUserDisabled
+	}
+	if s.cfg.Verify.UnverifiedPolicy == config.UnverifiedDeny && !current.EmailVerified {
+		return nil, AppErr.ErrEmailNotVerified
+	}
+	// A role may have started to require a second factor after the session
+	// was opened without one.
+	amr := stringsClaim(claims, amrClaim)
+	if current.MFARequired && !slices.Contains(amr, amrMFA) {
+		s.log.Warn().Str("user_id", subUUID.String()).Msg("refresh without required second factor")
+		return nil, AppErr.ErrMFARequired
+	}
 
-	access, accExp, err := s.createAccessToken(u)
+	tokenClient, _ := claims["client_id"].(string)
+	if tokenClient != clientID {
+		s.log.Error().Str("jti", jti.String()).Msg("refresh token was issued to another client")
+		return nil, AppErr.ErrInvalidToken
+	}
+	scopes := ParseScope(stringClaim(claims, "scope"))
+	if len(requested) > 0 {
+		if !isSubset(requested, scopes) {
+			return nil, AppErr.ErrInvalidScope
+		}
+		scopes = requested
+	}
+	authTime, _ := claims[authTimeClaim].(float64)
+	g := grant{clientID: tokenClient, scopes: scopes, amr: amr, authTime: int64(authTime), sessionID: stored.FamilyID}
+
+	// Everything about the user comes from the account rather than from the
+	// refresh token, so that changed roles and addresses reach the tokens of
+	// every session at the next refresh. Refresh tokens issued before they
+	// were slimmed down still carry email and roles claims; these are
+	// ignored.
+	u := s.restrictUnverified(current)
+
+	access, accExp, err := s.createAccessToken(u, g)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to create access token")
+		return nil, err
+	}
+	next := &user.RefreshToken{
+		ID:       uuid.New(),
+		FamilyID: stored.FamilyID,
+		UserID:   u.ID,
+	}
+	refresh, refExp, err := s.createRefreshToken(u, next.ID, g)
 	if err != nil {
 		return nil, err
 	}
-	refresh, refExp, err := s.createRefreshToken(u)
+	next.ExpiresAt = refExp
+	idToken, err := s.createIDTokenFor(u, g)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.tokenRepo.RotateRefreshToken(ctx, stored.ID, next, seenSession(ctx)); err != nil {
+		if errors.Is(err, AppErr.ErrTokenReused) {
+			return nil, s.handleReuse(ctx, stored)
+		}
+		s.log.Error().Err(err).Msg("rotate refresh token")
+		return nil, err
+	}
 
 	return &TokenPair{
 		AccessToken:      access,
 		RefreshToken:     refresh,
 		AccessExpiresAt:  accExp.Unix(),
 		RefreshExpiresAt: refExp.Unix(),
+		Scope:            strings.Join(g.scopes, " "),
+		IDToken:          idToken,
 	}, nil
 }
 
-func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		return &s.privKey.PublicKey, nil
+// auditRefresh records a refresh of subject's tokens by clientID. Reuse is
+// recorded by handleReuse.
+func (s *AuthService) auditRefresh(ctx context.Context, subject, clientID string, err error) {
+	if errors.Is(err, AppErr.ErrTokenReused) {
+		return
+	}
+	e := user.AuditEvent{Type: user.AuditTokenRefreshed, Actor: subject, Subject: subject, Details: map[string]string{}}
+	if clientID != "" {
+		e.Details["client_id"] = clientID
+	}
+	if err != nil {
+		e.Outcome = user.AuditFailure
+		e.Actor = ""
+		e.Details["reason"] = err.Error()
+	}
+	s.audit.Record(ctx, e)
+}
+
+// handleReuse revokes the family of a refresh token that was presented after
+// it had already been rotated.
+func (s *AuthService) handleReuse(ctx context.Context, t *user.RefreshToken) error {
+	s.log.Warn().
+		Str("user_id", t.UserID.String()).
+		Str("family_id", t.FamilyID.String()).
+		Str("jti", t.ID.String()).
+		Msg("refresh token reuse detected, revoking token family")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditTokenReuse,
+		Outcome: user.AuditFailure,
+		Subject: t.UserID.String(),
+		Details: map[string]string{"session_id": t.FamilyID.String(), "jti": t.ID.String()},
 	})
-	if !token.Valid {
-		s.log.Error().Msg("invalid refresh token")
-		return nil, AppErr.ErrInvalidToken
+	if err := s.tokenRepo.RevokeFamily(ctx, t.FamilyID); err != nil {
+		s.log.Error().Err(err).Msg("revoke refresh token family")
+		return err
 	}
+	return AppErr.ErrTokenReused
+}
+
+// Logout revokes the token family of refreshToken. If the caller also sends
+// its access token, the token is put on the denylist so that it stops working
+// before it expires.
+func (s *AuthService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	claims, err := s.parseTypedToken(refreshToken, tokenTypeRefresh)
 	if err != nil {
 		s.log.Error().Err(err).Msg("parse refresh token")
-		return nil, AppErr.ErrInvalidToken
+		return AppErr.ErrInvalidToken
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		s.log.Error().Msg("invalid token claims")
-		return nil, AppErr.ErrInvalidToken
+	jti, err := uuidClaim(claims, "jti")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token id")
+		return AppErr.ErrInvalidToken
 	}
-	if exp, ok := claims["exp"].(float64); !ok || time.Unix(int64(exp), 0).Before(time.Now()) {
-		s.log.Error().Msg("refresh token expired")
-		return nil, AppErr.ErrInvalidToken
+	sub, err := uuidClaim(claims, "sub")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token subject")
+		return AppErr.ErrInvalidToken
 	}
-	subStr, ok := claims["sub"].(string)
-	subUUID, err := uuid.Parse(subStr)
+
+	stored, err := s.tokenRepo.GetRefreshToken(ctx, jti)
 	if err != nil {
-		s.log.Error().Err(err).Msg("invalid token subject format")
-		return nil, AppErr.ErrInvalidToken
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get refresh token")
+		return err
 	}
-	if !ok {
-		s.log.Error().Msg("invalid token subject")
-		return nil, AppErr.ErrInvalidToken
+	if stored.UserID != sub {
+		s.log.Error().Str("jti", jti.String()).Msg("refresh token subject mismatch")
+		return AppErr.ErrInvalidToken
 	}
-	email, ok := claims["email"].(string)
-	if !ok {
-		s.log.Error().Msg("invalid token email")
+	if err := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		s.log.Error().Err(err).Msg("revoke refresh token family")
+		return err
+	}
+
+	if accessToken == "" {
+		return nil
+	}
+	accessClaims, err := s.parseTypedToken(accessToken, tokenTypeAccess)
+	if err != nil {
+		s.log.Error().Err(err).Msg("parse access token")
+		return AppErr.ErrInvalidToken
+	}
+	if accSub, _ := accessClaims["sub"].(string); accSub != sub.String() {
+		s.log.Error().Msg("access token subject mismatch")
+		return AppErr.ErrInvalidToken
+	}
+	return s.revokeToken(ctx, accessClaims)
+}
+
+// LogoutAll revokes every refresh token of the owner of accessToken and puts
+// the access token itself on the denylist. Access tokens of the other sessions
+// stay valid until they expire. Tokens obtained with an API key get
+// ErrForbidden: a leaked key must not sign its owner out.
+func (s *AuthService) LogoutAll(ctx context.Context, accessToken string) error {
+	claims, err := s.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(stringsClaim(claims, amrClaim), amrAPIKey) {
+		return AppErr.ErrForbidden
+	}
+	sub, err := uuidClaim(claims, "sub")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token subject")
+		return AppErr.ErrInvalidToken
+	}
+	if err := s.tokenRepo.RevokeUserTokens(ctx, sub); err != nil {
+		s.log.Error().Err(err).Msg("revoke user refresh tokens")
+		return err
+	}
+	return s.revokeToken(ctx, claims)
+}
+
+// exchangeAudiences returns the audiences the token exchange grant may issue
+// tokens for, which this service introspects and exchanges again.
+func (s *AuthService) exchangeAudiences(ctx context.Context) ([]string, error) {
+	audiences, err := s.cliRepo.ExchangeAudiences(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("list exchange audiences")
+		return nil, err
+	}
+	return audiences, nil
+}
+
+// IsTokenRevoked reports whether the token with the given jti is on the
+// denylist.
+func (s *AuthService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	revoked, err := s.tokenRepo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		s.log.Error().Err(err).Msg("check token revocation")
+		return false, err
+	}
+	return revoked, nil
+}
+
+// RevokedTokens returns a page of the denylist entries added since the given
+// time that have not expired yet.
+func (s *AuthService) RevokedTokens(ctx context.Context, since time.Time, limit, offset int32) ([]user.RevokedToken, error) {
+	tokens, err := s.tokenRepo.ListRevokedTokens(ctx, since, limit, offset)
+	if err != nil {
+		s.log.Error().Err(err).Msg("list revoked tokens")
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RunCleanup periodically deletes expired refresh tokens, denylist entries
+// and authorization codes and the API keys that ended until ctx is cancelled.
+func (s *AuthService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+func (s *AuthService) cleanup(ctx context.Context) {
+	for _, c := range []struct {
+		what          string
+		deleteExpired func(context.Context) (int64, error)
+	}{
+		{"tokens", s.tokenRepo.DeleteExpired},
+		{"authorization codes", s.codes.DeleteExpired},
+		{"api keys", s.apiKeys.DeleteEnded},
+	} {
+		n, err := c.deleteExpired(ctx)
+		if err != nil {
+			s.log.Error().Err(err).Msg("delete expired " + c.what)
+			continue
+		}
+		s.log.Debug().Int64("deleted", n).Msg("expired " + c.what + " cleaned up")
+	}
+}
+
+// VerifyAccessToken checks the signature, expiry and revocation state of a
+// user access token.
+func (s *AuthService) VerifyAccessToken(ctx context.Context, raw string) (*user.Principal, error) {
+	claims, err := s.verifyAccessToken(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	p := &user.Principal{
+		Roles:       stringsClaim(claims, "roles"),
+		Permissions: stringsClaim(claims, permissionsClaim),
+		Scopes:      ParseScope(stringClaim(claims, "scope")),
+	}
+	p.Subject, _ = claims["sub"].(string)
+	p.Email, _ = claims["email"].(string)
+	p.ClientID, _ = claims["client_id"].(string)
+	p.TokenID, _ = claims["jti"].(string)
+	p.EmailVerified, _ = claims[emailVerifiedClaim].(bool)
+	p.AMR = stringsClaim(claims, amrClaim)
+	p.SessionID, _ = claims[sessionClaim].(string)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		p.ExpiresAt = exp.Time
+	}
+	return p, nil
+}
+
+// verifyAccessToken returns the claims of an access token that is valid and
+// not revoked. Exchanged tokens for one of exchangeAudiences are accepted as
+// well, see parseToken.
+func (s *AuthService) verifyAccessToken(ctx context.Context, raw string, exchangeAudiences ...string) (jwt.MapClaims, error) {
+	claims, err := s.parseTypedToken(raw, tokenTypeAccess, exchangeAudiences...)
+	if err != nil {
+		s.log.Error().Err(err).Msg("parse access token")
 		return nil, AppErr.ErrInvalidToken
 	}
-	rolesClaim, ok := claims["roles"].([]interface{})
-	if !ok {
-		s.log.Error().Msg("invalid token roles")
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
 		return nil, AppErr.ErrInvalidToken
 	}
-	roles := make([]string, len(rolesClaim))
-	for i, v := range rolesClaim {
-		r, ok := v.(string)
-		if !ok {
-			s.log.Error().Msg("invalid token roles")
-			return nil, AppErr.ErrInvalidToken
-		}
-		roles[i] = r
+	revoked, err := s.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, AppErr.ErrInvalidToken
 	}
-	u := user.User{
-		ID:    subUUID,
-		Email: email,
-		Roles: roles,
+	return claims, nil
+}
+
+// revokeToken puts the token with the given claims on the denylist until it
+// expires.
+func (s *AuthService) revokeToken(ctx context.Context, claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return AppErr.ErrInvalidToken
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return AppErr.ErrInvalidToken
+	}
+	if err := s.tokenRepo.RevokeTokenID(ctx, jti, exp.Time); err != nil {
+		s.log.Error().Err(err).Msg("revoke token")
+		return err
 	}
-	access, accExp, err := s.createAccessToken(&u)
+	return nil
+}
+
+// LoginResult is the outcome of Login: a token pair, or a challenge for the
+// second factor of users who have one or whose roles require one.
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *MFAChallenge
+}
+
+func (s *AuthService) Login(ctx context.Context, email, password string) (*LoginResult, error) {
+	u, err := s.authenticateUser(ctx, email, password)
 	if err != nil {
-		s.log.Error().Err(err).Msg("failed to create access token")
 		return nil, err
 	}
+	if u.MFAEnabled || u.MFARequired {
+		challenge, err := s.createMFAChallenge(u)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Challenge: challenge}, nil
+	}
 
-	return &TokenPair{
-		AccessToken:      access,
-		RefreshToken:     refreshToken,
-		AccessExpiresAt:  accExp.Unix(),
-		RefreshExpiresAt: time.Unix(int64(claims["exp"].(float64)), 0).Unix(),
-	}, nil
+	tokens, err := s.issueTokenPair(ctx, u, uuid.New(), grant{amr: []string{amrPassword}})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+// authenticateUser checks a user's email and password. Disabled users get
+// ErrUserDisabled, and unverified ones ErrEmailNotVerified under the deny
+// policy, but only once the password has been verified. Failed attempts are
+// counted per account and per IP address, and while either is locked the
+// password is not even checked: the caller gets an *AppErr.LockoutError.
+func (s *AuthService) authenticateUser(ctx context.Context, email, password string) (*user.User, error) {
+	ip := requestinfo.ClientIP(ctx)
+	account := throttle.Account(email)
+	if err := s.guard.Check(ctx, account, throttle.IP(ip)); err != nil {
+		s.auditLogin(ctx, email, nil, "locked")
+		return nil, err
+	}
+
 	u, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, AppErr.ErrNotFound) {
+			s.guard.Fail(ctx, ip, account, throttle.IP(ip))
+			s.auditLogin(ctx, email, nil, "unknown email")
 			return nil, AppErr.ErrInvalidCredentials
 		}
 		s.log.Error().Err(err).Msg("get user by email")
 		return nil, err
 	}
 
-	if !utils.CheckPasswordHash(password, u.Password) {
+	match, rehash := s.hasher.Verify(password, u.Password)
+	if !match {
+		s.guard.Fail(ctx, ip, account, throttle.IP(ip))
+		s.auditLogin(ctx, email, u, "wrong password")
 		return nil, AppErr.ErrInvalidCredentials
 	}
+	s.guard.Succeed(ctx, account)
+	if u.Status != user.UserStatusActive {
+		s.log.Warn().Str("user_id", u.ID.String()).Msg("disabled user tried to log in")
+		s.auditLogin(ctx, email, u, "user disabled")
+		return nil, AppErr.ErrUserDisabled
+	}
+	if s.cfg.Verify.UnverifiedPolicy == config.UnverifiedDeny && !u.EmailVerified {
+		s.auditLogin(ctx, email, u, "email not verified")
+		return nil, AppErr.ErrEmailNotVerified
+	}
+	if rehash {
+		s.upgradePasswordHash(ctx, u, password)
+	}
+	s.auditLogin(ctx, email, u, "")
+	return u, nil
+}
 
-	access, accExp, err := s.createAccessToken(u)
+// auditLogin records a password sign-in as email, failed for reason unless
+// it is empty. u is nil if no such user exists. Users who still have to pass
+// a second factor are marked so.
+func (s *AuthService) auditLogin(ctx context.Context, email string, u *user.User, reason string) {
+	e := user.AuditEvent{Type: user.AuditLogin, Subject: email, Details: map[string]string{"email": email}}
+	if u != nil {
+		e.Subject = u.ID.String()
+		if reason == "" {
+			e.Actor = e.Subject
+			if u.MFAEnabled || u.MFARequired {
+				e.Details["second_factor"] = "pending"
+			}
+		}
+	}
+	if reason != "" {
+		e.Outcome = user.AuditFailure
+		e.Details["reason"] = reason
+	}
+	s.audit.Record(ctx, e)
+}
+
+// issueTokenPair opens a session for u, who has just authenticated, and
+// mints an access token and a refresh token in it, plus an ID token if the
+// openid scope was granted. The refresh token is stored as the first member
+// of familyID, which becomes the ID of the session. The authentication time
+// defaults to now.
+func (s *AuthService) issueTokenPair(ctx context.Context, u *user.User, familyID uuid.UUID, g grant) (*TokenPair, error) {
+	u = s.restrictUnverified(u)
+	g.sessionID = familyID
+	if g.authTime == 0 {
+		g.authTime = time.Now().Unix()
+	}
+	access, accExp, err := s.createAccessToken(u, g)
+	if err != nil {
+		return nil, err
+	}
+	rt := &user.RefreshToken{
+		ID:       uuid.New(),
+		FamilyID: familyID,
+		UserID:   u.ID,
+	}
+	refresh, refExp, err := s.createRefreshToken(u, rt.ID, g)
 	if err != nil {
 		return nil, err
 	}
-	refresh, refExp, err := s.createRefreshToken(u)
+	rt.ExpiresAt = refExp
+	idToken, err := s.createIDTokenFor(u, g)
 	if err != nil {
 		return nil, err
 	}
+	sess := seenSession(ctx)
+	sess.ID, sess.UserID = familyID, u.ID
+	if err := s.tokenRepo.CreateSession(ctx, sess, rt); err != nil {
+		s.log.Error().Err(err).Msg("store session")
+		return nil, err
+	}
 
 	return &TokenPair{
 		AccessToken:      access,
 		RefreshToken:     refresh,
 		AccessExpiresAt:  accExp.Unix(),
 		RefreshExpiresAt: refExp.Unix(),
+		Scope:            strings.Join(g.scopes, " "),
+		IDToken:          idToken,
 	}, nil
 }
 
-func (s *AuthService) createAccessToken(u *user.User) (string, time.Time, error) {
+func (s *AuthService) createAccessToken(u *user.User, g grant) (string, time.Time, error) {
 	now := time.Now()
 	exp := now.Add(s.cfg.JWT.AccessTokenTTL)
-	claims := jwt.MapClaims{
-		"sub":   u.ID,
-		"email": u.Email,
-		"roles": u.Roles,
-		"exp":   exp.Unix(),
-		"iat":   now.Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = s.cfg.JWT.KeyID
-	jw, err := token.SignedString(s.privKey)
+	jw, err := s.signToken(accessClaims(u, g, now, exp))
 	if err != nil {
 		s.log.Error().Err(err).Msg("create access token")
 		return "", time.Time{}, err
@@ -195,19 +697,56 @@ func (s *AuthService) createAccessToken(u *user.User) (string, time.Time, error)
 	return jw, exp, nil
 }
 
-func (s *AuthService) createRefreshToken(u *user.User) (string, time.Time, error) {
-	now := time.Now()
-	exp := now.Add(s.cfg.JWT.RefreshTokenTTL)
+// unprivileged returns u without its roles and permissions, for tokens that
+// act on behalf of the user with a limited set of scopes only.
+func unprivileged(u *user.User) *user.User {
+	limited := *u
+	limited.Roles = []string{}
+	limited.Permissions = nil
+	return &limited
+}
+
+// accessClaims are the claims of an access token for u.
+func accessClaims(u *user.User, g grant, now, exp time.Time) jwt.MapClaims {
 	claims := jwt.MapClaims{
 		"sub":   u.ID,
+		"jti":   uuid.NewString(),
+		"typ":   tokenTypeAccess,
 		"email": u.Email,
 		"roles": u.Roles,
 		"exp":   exp.Unix(),
 		"iat":   now.Unix(),
+
+		emailVerifiedClaim: u.EmailVerified,
+	}
+	if len(u.Permissions) > 0 {
+		claims[permissionsClaim] = u.Permissions
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = s.cfg.JWT.KeyID
-	jw, err := token.SignedString(s.privKey)
+	g.apply(claims)
+	return claims
+}
+
+// createRefreshToken mints a refresh token that only references the stored
+// token jti and its subject, besides the grant it continues. The user is
+// loaded again when it is used, and the session is that of the stored token.
+func (s *AuthService) createRefreshToken(u *user.User, jti uuid.UUID, g grant) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(s.cfg.JWT.RefreshTokenTTL)
+	claims := jwt.MapClaims{
+		"sub": u.ID,
+		"jti": jti.String(),
+		"typ": tokenTypeRefresh,
+		"exp": exp.Unix(),
+		"iat": now.Unix(),
+	}
+	if s.cfg.JWT.LegacyRefreshClaims {
+		claims["email"] = u.Email
+		claims["roles"] = u.Roles
+		claims[emailVerifiedClaim] = u.EmailVerified
+	}
+	g.sessionID = uuid.Nil
+	g.apply(claims)
+	jw, err := s.signToken(claims)
 	if err != nil {
 		s.log.Error().Err(err).Msg("create refresh token")
 		return "", time.Time{}, err
@@ -215,29 +754,23 @@ func (s *AuthService) createRefreshToken(u *user.User) (string, time.Time, error
 	return jw, exp, nil
 }
 
-func (s *AuthService) ClientToken(ctx context.Context, id string, secret string) (*TokenService, error) {
-	cli, err := s.cliRepo.GetById(ctx, id)
-	if err != nil {
-		if errors.Is(err, AppErr.ErrNotFound) {
-			return nil, AppErr.ErrInvalidCredentials
-		}
-		s.log.Error().Err(err).Msg("get client by id")
-		return nil, err
-	}
-	if !utils.CheckPasswordHash(secret, cli.Secret) {
-		return nil, AppErr.ErrInvalidCredentials
-	}
+// ClientToken mints an access token for an already authenticated client.
+func (s *AuthService) ClientToken(cli *user.Client, scopes []string) (*TokenService, error) {
 	now := time.Now()
 	exp := now.Add(s.cfg.JWT.AccessTokenTTL)
 	claims := jwt.MapClaims{
 		"sub":   cli.ID,
+		"jti":   uuid.NewString(),
+		"typ":   tokenTypeClient,
 		"roles": cli.Roles,
 		"exp":   exp.Unix(),
 		"iat":   now.Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = s.cfg.JWT.KeyID
-	jw, err := token.SignedString(s.privKey)
+	if len(cli.Permissions) > 0 {
+		claims[permissionsClaim] = cli.Permissions
+	}
+	grant{scopes: scopes}.apply(claims)
+	jw, err := s.signToken(claims)
 	if err != nil {
 		s.log.Error().Err(err).Msg("create client token")
 		return nil, err
@@ -248,44 +781,186 @@ func (s *AuthService) ClientToken(ctx context.Context, id string, secret string)
 	}, nil
 }
 
-func (s *AuthService) JWKS() []byte {
-	return s.jwks
+// AuthenticateClient checks the credentials of a service client. Unknown,
+// disabled and wrong-secret clients all yield ErrInvalidCredentials. Failed
+// attempts are throttled like those of users.
+func (s *AuthService) AuthenticateClient(ctx context.Context, id, secret string) (*user.Client, error) {
+	ip := requestinfo.ClientIP(ctx)
+	key := throttle.Client(id)
+	if err := s.guard.Check(ctx, key, throttle.IP(ip)); err != nil {
+		s.auditClientAuthFailure(ctx, id, "locked")
+		return nil, err
+	}
+
+	cli, err := s.cliRepo.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			s.guard.Fail(ctx, ip, key, throttle.IP(ip))
+			s.auditClientAuthFailure(ctx, id, "unknown client")
+			return nil, AppErr.ErrInvalidCredentials
+		}
+		s.log.Error().Err(err).Msg("get client by id")
+		return nil, err
+	}
+	match, rehash := s.hasher.Verify(secret, cli.Secret)
+	if !match && !s.previousSecretMatches(cli, secret) {
+		s.guard.Fail(ctx, ip, key, throttle.IP(ip))
+		s.auditClientAuthFailure(ctx, id, "wrong secret")
+		return nil, AppErr.ErrInvalidCredentials
+	}
+	s.guard.Succeed(ctx, key)
+	if cli.Status != user.ClientStatusActive {
+		s.log.Warn().Str("client_id", cli.ID).Msg("disabled client tried to authenticate")
+		s.auditClientAuthFailure(ctx, id, "client disabled")
+		return nil, AppErr.ErrInvalidCredentials
+	}
+	if match && rehash {
+		s.upgradeSecretHash(ctx, cli, secret)
+	}
+	return cli, nil
+}
+
+// auditClientAuthFailure records a failed authentication as client id.
+// Successful ones are not recorded, as every introspection makes one.
+func (s *AuthService) auditClientAuthFailure(ctx context.Context, id, reason string) {
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditClientAuth,
+		Outcome: user.AuditFailure,
+		Subject: id,
+		Details: map[string]string{"reason": reason},
+	})
 }
 
-func (s *AuthService) loadKeys() error {
-	privPEM, err := os.ReadFile(s.cfg.JWT.PrivateKeyPath)
+// previousSecretMatches checks secret against the secret replaced by the last
+// rotation while its grace period lasts. Such hashes are never upgraded,
+// since they are about to expire anyway.
+func (s *AuthService) previousSecretMatches(cli *user.Client, secret string) bool {
+	if cli.PreviousSecret == "" || cli.PreviousSecretExpiresAt == nil || time.Now().After(*cli.PreviousSecretExpiresAt) {
+		return false
+	}
+	match, _ := s.hasher.Verify(secret, cli.PreviousSecret)
+	return match
+}
+
+// upgradePasswordHash replaces an outdated password hash of u after a
+// successful sign-in, the only time the plain password is at hand. Failures
+// are logged and otherwise ignored, as the old hash still works.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, u *user.User, password string) {
+	hash, err := s.hasher.Hash(password)
 	if err != nil {
-		return err
+		s.log.Error().Err(err).Msg("hash pwd")
+		return
 	}
-	block, _ := pem.Decode(privPEM)
-	if block == nil {
-		return errors.New("failed to decode private key PEM")
+	if err := s.repo.Rehash(ctx, u.ID, u.Password, hash); err != nil {
+		if !errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Err(err).Str("user_id", u.ID.String()).Msg("upgrade password hash")
+		}
+		return
 	}
-	var parsedKey any
-	if parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
-		parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-		if err != nil {
-			return errors.New("failed to parse private key")
+	u.Password = hash
+	s.log.Info().Str("user_id", u.ID.String()).Msg("password hash upgraded")
+}
+
+// upgradeSecretHash is upgradePasswordHash for client secrets.
+func (s *AuthService) upgradeSecretHash(ctx context.Context, cli *user.Client, secret string) {
+	hash, err := s.hasher.Hash(secret)
+	if err != nil {
+		s.log.Error().Err(err).Msg("hash client secret")
+		return
+	}
+	if err := s.cliRepo.RehashSecret(ctx, cli.ID, cli.Secret, hash); err != nil {
+		if !errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Err(err).Str("client_id", cli.ID).Msg("upgrade client secret hash")
 		}
+		return
 	}
-	s.privKey = parsedKey.(*rsa.PrivateKey)
-	jwkKey, err := jwk.FromRaw(s.privKey.Public())
+	cli.Secret = hash
+	s.log.Info().Str("client_id", cli.ID).Msg("client secret hash upgraded")
+}
+
+// parseToken verifies the signature and expiry of a token minted by this
+// service for its own audience, which rules out ID tokens, and returns its
+// claims. Tokens the token exchange grant issued for one of exchangeAudiences
+// are accepted as well: this service validates them on behalf of those
+// audiences.
+func (s *AuthService) parseToken(raw string, exchangeAudiences ...string) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(keys.Algorithms),
+		jwt.WithExpirationRequired(),
+	}
+	if s.cfg.JWT.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.cfg.JWT.Issuer))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.keys.PublicKey(kid, token.Method.Alg())
+	}, opts...)
 	if err != nil {
-		return errors.New("failed to create JWK from public key")
+		return nil, err
 	}
+	if s.cfg.JWT.Audience == "" {
+		return claims, nil
+	}
+	aud, _ := claims.GetAudience()
+	if slices.Contains(aud, s.cfg.JWT.Audience) {
+		return claims, nil
+	}
+	if _, exchanged := claims[actClaim]; exchanged && slices.ContainsFunc(aud, func(a string) bool {
+		return slices.Contains(exchangeAudiences, a)
+	}) {
+		return claims, nil
+	}
+	return nil, jwt.ErrTokenInvalidAudience
+}
 
-	_ = jwkKey.Set(jwk.KeyIDKey, s.cfg.JWT.KeyID)
-	_ = jwkKey.Set(jwk.AlgorithmKey, "RS256")
-	_ = jwkKey.Set(jwk.KeyUsageKey, "sig")
+// parseTypedToken is parseToken that also requires the typ claim to match.
+func (s *AuthService) parseTypedToken(raw, typ string, exchangeAudiences ...string) (jwt.MapClaims, error) {
+	claims, err := s.parseToken(raw, exchangeAudiences...)
+	if err != nil {
+		return nil, err
+	}
+	if got, _ := claims["typ"].(string); got != typ {
+		return nil, fmt.Errorf("unexpected token type %q", got)
+	}
+	return claims, nil
+}
 
-	keySet := jwk.NewSet()
-	keySet.AddKey(jwkKey)
+func stringClaim(claims jwt.MapClaims, name string) string {
+	v, _ := claims[name].(string)
+	return v
+}
 
-	buf, err := json.Marshal(keySet)
-	if err != nil {
-		return err
+func uuidClaim(claims jwt.MapClaims, name string) (uuid.UUID, error) {
+	v, ok := claims[name].(string)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("claim %q is missing", name)
 	}
-	s.jwks = buf
-	s.log.Info().Msg("JWT keys loaded successfully")
-	return nil
+	return uuid.Parse(v)
+}
+
+// signToken signs claims with the active key of the ring, using the algorithm
+// of that key, and records its id in the kid header. The issuer is added to
+// the claims, and so is the audience unless the claims name one already.
+func (s *AuthService) signToken(claims jwt.MapClaims) (string, error) {
+	if s.cfg.JWT.Issuer != "" {
+		claims["iss"] = s.cfg.JWT.Issuer
+	}
+	if _, ok := claims["aud"]; !ok && s.cfg.JWT.Audience != "" {
+		claims["aud"] = s.cfg.JWT.Audience
+	}
+	key := s.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+func (s *AuthService) JWKS() []byte {
+	return s.keys.JWKS()
+}
+
+// SigningAlgorithms returns the algorithms tokens may currently be signed
+// with.
+func (s *AuthService) SigningAlgorithms() []string {
+	return s.keys.SigningAlgorithms()
 }
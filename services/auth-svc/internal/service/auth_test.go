@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,12 +20,17 @@ import (
 	"github.com/oidiral/e-commerce/services/auth-svc/config"
 	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
 	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/keys"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
 	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const testKeyID = "test-key-id"
+
 func setupRSA(t *testing.T) (*config.Config, *rsa.PrivateKey) {
 	t.Helper()
 	dir := t.TempDir()
@@ -53,7 +61,7 @@ func setupRSA(t *testing.T) (*config.Config, *rsa.PrivateKey) {
 		JWT: config.JWTConfig{
 			PrivateKeyPath:  privPath,
 			PublicKeyPath:   pubPath,
-			KeyID:           "test-key-id",
+			KeyID:           testKeyID,
 			AccessTokenTTL:  15 * time.Minute,
 			RefreshTokenTTL: 24 * time.Hour,
 		},
@@ -61,12 +69,22 @@ func setupRSA(t *testing.T) (*config.Config, *rsa.PrivateKey) {
 	return cfg, privKey
 }
 
+func newKeyRing(t *testing.T, cfg *config.Config) *keys.Ring {
+	t.Helper()
+	ring, err := keys.NewRing(cfg.JWT, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to load key ring: %v", err)
+	}
+	return ring
+}
+
 func TestAuthService_Login_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAuthRepository(ctrl)
 	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
 	cfg, _ := setupRSA(t)
 	logger := zerolog.Nop()
 
@@ -88,12 +106,25 @@ func TestAuthService_Login_Success(t *testing.T) {
 		EXPECT().
 		GetByEmail(gomock.Any(), gomock.Eq("test@example.com")).
 		Return(mockUser, nil)
+	mockRepo.
+		EXPECT().
+		Rehash(gomock.Any(), userId, string(hashedPassword), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _, newHash string) error {
+			assert.True(t, strings.HasPrefix(newHash, "$argon2id$"), "bcrypt-хеш должен обновляться до argon2id")
+			return nil
+		})
+	mockTokens.
+		EXPECT().
+		CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
 
-	authService := NewAuthService(mockRepo, logger, cfg, mockClient)
+	authService := NewAuthService(mockRepo, logger, cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
 
-	resp, loginErr := authService.Login(context.Background(), "test@example.com", password)
+	res, loginErr := authService.Login(context.Background(), "test@example.com", password)
 
 	assert.NoError(t, loginErr)
+	assert.Nil(t, res.Challenge, "без 2FA второй шаг не нужен")
+	resp := res.Tokens
 	assert.NotNil(t, resp)
 	assert.NotEmpty(t, resp.AccessToken, "должен быть сгенерирован AccessToken")
 	assert.NotEmpty(t, resp.RefreshToken, "должен быть сгенерирован RefreshToken")
@@ -101,12 +132,92 @@ func TestAuthService_Login_Success(t *testing.T) {
 	assert.True(t, resp.RefreshExpiresAt > resp.AccessExpiresAt, "RefreshExpiresAt > AccessExpiresAt")
 }
 
+// TestAuthService_Login_MixedKeyRing covers a migration from RSA to ECDSA:
+// new tokens are signed with ES256 while RS256 tokens stay valid.
+func TestAuthService_Login_MixedKeyRing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, rsaKey := setupRSA(t)
+	dir := filepath.Dir(cfg.JWT.PrivateKeyPath)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("failed to marshal EC key: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ec.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write EC key: %v", err)
+	}
+	meta := `{"keys": [
+		{"kid": "` + testKeyID + `", "file": "private.pem", "status": "verify"},
+		{"kid": "ec", "file": "ec.pem", "status": "active"}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, keys.MetadataFile), []byte(meta), 0644); err != nil {
+		t.Fatalf("failed to write key metadata: %v", err)
+	}
+	cfg.JWT.KeysDir = dir
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	hash, _ := passhash.New(config.HashConfig{}).Hash("password")
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: hash, Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	mockTokens.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+
+	res, err := authService.Login(context.Background(), u.Email, "password")
+	if !assert.NoError(t, err) {
+		return
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(res.Tokens.AccessToken, jwt.MapClaims{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ES256", token.Method.Alg(), "токен подписывается алгоритмом активного ключа")
+		assert.Equal(t, "ec", token.Header["kid"])
+	}
+	_, err = authService.VerifyAccessToken(context.Background(), res.Tokens.AccessToken)
+	assert.NoError(t, err)
+
+	old := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": u.ID.String(),
+		"jti": uuid.NewString(),
+		"typ": "access",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	old.Header["kid"] = testKeyID
+	raw, err := old.SignedString(rsaKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	_, err = authService.VerifyAccessToken(context.Background(), raw)
+	assert.NoError(t, err, "токены прежнего RSA ключа должны приниматься до его отзыва")
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"sub": u.ID.String(),
+		"jti": uuid.NewString(),
+		"typ": "access",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	forged.Header["kid"] = testKeyID
+	raw, err = forged.SignedString(ecKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	_, err = authService.VerifyAccessToken(context.Background(), raw)
+	assert.ErrorIs(t, err, AppErr.ErrInvalidToken, "алгоритм токена должен совпадать с алгоритмом ключа")
+}
+
 func TestAuthService_Login_InvalidPassword(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAuthRepository(ctrl)
 	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
 	cfg, _ := setupRSA(t)
 	logger := zerolog.Nop()
 
@@ -124,19 +235,46 @@ func TestAuthService_Login_InvalidPassword(t *testing.T) {
 		GetByEmail(gomock.Any(), gomock.Eq("test2@example.com")).
 		Return(mockUser, nil)
 
-	authService := NewAuthService(mockRepo, logger, cfg, mockClient)
+	authService := NewAuthService(mockRepo, logger, cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
 
 	_, err := authService.Login(context.Background(), "test2@example.com", "wrongPassword")
 
 	assert.ErrorIs(t, err, AppErr.ErrInvalidCredentials)
 }
 
+func TestAuthService_Login_DisabledUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	cfg, _ := setupRSA(t)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockRepo.
+		EXPECT().
+		GetByEmail(gomock.Any(), gomock.Eq("blocked@example.com")).
+		Return(&model.User{
+			ID:       uuid.New(),
+			Email:    "blocked@example.com",
+			Password: string(hash),
+			Roles:    []string{"user"},
+			Status:   model.UserStatusDisabled,
+		}, nil)
+
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	_, err := authService.Login(context.Background(), "blocked@example.com", "password123")
+
+	assert.ErrorIs(t, err, AppErr.ErrUserDisabled, "заблокированный пользователь не должен входить")
+}
+
 func TestAuthService_Login_UserNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAuthRepository(ctrl)
 	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
 	cfg, _ := setupRSA(t)
 	logger := zerolog.Nop()
 
@@ -145,7 +283,7 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 		GetByEmail(gomock.Any(), gomock.Eq("nouser@example.com")).
 		Return(nil, AppErr.ErrNotFound)
 
-	authService := NewAuthService(mockRepo, logger, cfg, mockClient)
+	authService := NewAuthService(mockRepo, logger, cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
 
 	_, err := authService.Login(context.Background(), "nouser@example.com", "anyPassword")
 
@@ -158,6 +296,7 @@ func TestAuthService_RegisterUser_Success(t *testing.T) {
 
 	mockRepo := mocks.NewMockAuthRepository(ctrl)
 	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
 	cfg, _ := setupRSA(t)
 	logger := zerolog.Nop()
 
@@ -172,8 +311,17 @@ func TestAuthService_RegisterUser_Success(t *testing.T) {
 		EXPECT().
 		CreateIfNotExists(gomock.Any(), "newuser@example.com", gomock.Any()).
 		Return(mockUser, nil)
+	mockRepo.
+		EXPECT().
+		MarkVerificationSent(gomock.Any(), userId, gomock.Any()).
+		Return(true, nil)
+	mockTokens.
+		EXPECT().
+		CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
 
-	authService := NewAuthService(mockRepo, logger, cfg, mockClient)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	authService := NewAuthService(mockRepo, logger, cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), mail, nil, nil, nil)
 
 	resp, err := authService.RegisterUser(context.Background(), "newuser@example.com", "plainPassword")
 
@@ -183,6 +331,28 @@ func TestAuthService_RegisterUser_Success(t *testing.T) {
 	assert.NotEmpty(t, resp.RefreshToken)
 	assert.True(t, resp.AccessExpiresAt > time.Now().Unix())
 	assert.True(t, resp.RefreshExpiresAt > resp.AccessExpiresAt)
+
+	select {
+	case msg := <-mail.sent:
+		assert.Equal(t, "newuser@example.com", msg.To)
+	case <-time.After(time.Second):
+		t.Fatal("письмо с подтверждением не отправлено")
+	}
+}
+
+func TestAuthService_RegisterUser_WeakPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	cfg, _ := setupRSA(t)
+	cfg.Password.MinLength = 8
+	cfg.Password.MinClasses = 3
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	resp, err := authService.RegisterUser(context.Background(), "newuser@example.com", "newuser2024")
+	assert.ErrorIs(t, err, AppErr.ErrWeakPassword)
+	assert.Nil(t, resp)
 }
 
 func TestAuthService_RegisterUser_UserAlreadyExists(t *testing.T) {
@@ -191,6 +361,7 @@ func TestAuthService_RegisterUser_UserAlreadyExists(t *testing.T) {
 
 	mockRepo := mocks.NewMockAuthRepository(ctrl)
 	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
 	cfg, _ := setupRSA(t)
 	logger := zerolog.Nop()
 
@@ -199,59 +370,303 @@ func TestAuthService_RegisterUser_UserAlreadyExists(t *testing.T) {
 		CreateIfNotExists(gomock.Any(), "existing@example.com", gomock.Any()).
 		Return(nil, AppErr.ErrUserAlreadyExists)
 
-	authService := NewAuthService(mockRepo, logger, cfg, mockClient)
+	authService := NewAuthService(mockRepo, logger, cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
 
 	_, err := authService.RegisterUser(context.Background(), "existing@example.com", "anyPassword")
 
 	assert.ErrorIs(t, err, AppErr.ErrUserAlreadyExists)
 }
 
+func signRefreshToken(t *testing.T, privKey *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	tokenObj := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tokenObj.Header["kid"] = testKeyID
+	signed, err := tokenObj.SignedString(privKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
 func TestAuthService_Refresh_Success(t *testing.T) {
-	// No mocks needed for repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
 	cfg, privKey := setupRSA(t)
 	logger := zerolog.Nop()
-	authService := NewAuthService(nil, logger, cfg, nil)
+	authService := NewAuthService(mockRepo, logger, cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
 
 	userId := uuid.New()
-	claims := jwt.MapClaims{
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
 		"sub":   userId.String(),
+		"jti":   stored.ID.String(),
+		"typ":   "refresh",
 		"email": "user@example.com",
 		"roles": []string{"user"},
 		"exp":   time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
 		"iat":   time.Now().Unix(),
-	}
-	tokenObj := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	refreshToken, err := tokenObj.SignedString(privKey)
-	if err != nil {
-		t.Fatalf("failed to sign token: %v", err)
-	}
+	})
 
-	resp, err := authService.Refresh(refreshToken)
+	mockTokens.
+		EXPECT().
+		GetRefreshToken(gomock.Any(), stored.ID).
+		Return(stored, nil)
+	mockRepo.
+		EXPECT().
+		GetByID(gomock.Any(), userId).
+		Return(&model.User{ID: userId, Status: model.UserStatusActive}, nil)
+	mockTokens.
+		EXPECT().
+		RotateRefreshToken(gomock.Any(), stored.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, next *model.RefreshToken, _ *model.Session) error {
+			assert.Equal(t, stored.FamilyID, next.FamilyID, "новый токен должен остаться в той же семье")
+			assert.NotEqual(t, stored.ID, next.ID)
+			return nil
+		})
+
+	resp, err := authService.Refresh(context.Background(), refreshToken)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.NotEmpty(t, resp.AccessToken)
-	assert.Equal(t, refreshToken, resp.RefreshToken)
+	assert.NotEqual(t, refreshToken, resp.RefreshToken, "refresh токен должен быть ротирован")
 	assert.True(t, resp.AccessExpiresAt > time.Now().Unix())
 }
 
+// TestAuthService_Refresh_UsesCurrentRoles refreshes a token issued before
+// refresh tokens were slimmed down, with roles the user has since lost.
+func TestAuthService_Refresh_UsesCurrentRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, privKey := setupRSA(t)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	userId := uuid.New()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub":   userId.String(),
+		"jti":   stored.ID.String(),
+		"typ":   "refresh",
+		"email": "old@example.com",
+		"roles": []string{"admin"},
+		"exp":   time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
+		"iat":   time.Now().Unix(),
+
+		emailVerifiedClaim: true,
+	})
+
+	mockTokens.EXPECT().GetRefreshToken(gomock.Any(), stored.ID).Return(stored, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), userId).Return(&model.User{
+		ID:            userId,
+		Email:         "new@example.com",
+		Status:        model.UserStatusActive,
+		Roles:         []string{"user"},
+		EmailVerified: true,
+	}, nil)
+	mockTokens.EXPECT().RotateRefreshToken(gomock.Any(), stored.ID, gomock.Any(), gomock.Any()).Return(nil)
+
+	resp, err := authService.Refresh(context.Background(), refreshToken)
+	if !assert.NoError(t, err, "refresh токены старого формата должны приниматься") {
+		return
+	}
+	access, err := authService.parseTypedToken(resp.AccessToken, tokenTypeAccess)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"user"}, stringsClaim(access, "roles"), "роли берутся из учетной записи, а не из токена")
+		assert.Equal(t, "new@example.com", access["email"])
+	}
+	refresh, err := authService.parseTypedToken(resp.RefreshToken, tokenTypeRefresh)
+	if assert.NoError(t, err) {
+		for _, name := range []string{"email", "roles", emailVerifiedClaim, sessionClaim} {
+			assert.NotContains(t, refresh, name, "refresh токен не должен нести данные пользователя")
+		}
+		assert.Equal(t, userId.String(), refresh["sub"])
+	}
+}
+
+func TestAuthService_Refresh_DisabledUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, privKey := setupRSA(t)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	userId := uuid.New()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub":   userId.String(),
+		"jti":   stored.ID.String(),
+		"typ":   "refresh",
+		"email": "user@example.com",
+		"roles": []string{"user"},
+		"exp":   time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
+		"iat":   time.Now().Unix(),
+	})
+
+	mockTokens.
+		EXPECT().
+		GetRefreshToken(gomock.Any(), stored.ID).
+		Return(stored, nil)
+	mockRepo.
+		EXPECT().
+		GetByID(gomock.Any(), userId).
+		Return(&model.User{ID: userId, Status: model.UserStatusDisabled}, nil)
+
+	_, err := authService.Refresh(context.Background(), refreshToken)
+
+	assert.ErrorIs(t, err, AppErr.ErrUserDisabled, "refresh для заблокированного пользователя должен отклоняться")
+}
+
+func TestAuthService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, privKey := setupRSA(t)
+	logger := zerolog.Nop()
+	authService := NewAuthService(nil, logger, cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	userId := uuid.New()
+	rotatedAt := time.Now().Add(-time.Minute)
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+		RotatedAt: &rotatedAt,
+	}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub":   userId.String(),
+		"jti":   stored.ID.String(),
+		"typ":   "refresh",
+		"email": "user@example.com",
+		"roles": []string{"user"},
+		"exp":   time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
+		"iat":   time.Now().Unix(),
+	})
+
+	mockTokens.
+		EXPECT().
+		GetRefreshToken(gomock.Any(), stored.ID).
+		Return(stored, nil)
+	mockTokens.
+		EXPECT().
+		RevokeFamily(gomock.Any(), stored.FamilyID).
+		Return(nil)
+
+	_, err := authService.Refresh(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, AppErr.ErrTokenReused)
+}
+
 func TestAuthService_Refresh_ExpiredToken(t *testing.T) {
 	cfg, privKey := setupRSA(t)
 	logger := zerolog.Nop()
-	authService := NewAuthService(nil, logger, cfg, nil)
+	authService := NewAuthService(nil, logger, cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
 
-	claims := jwt.MapClaims{
+	expiredToken := signRefreshToken(t, privKey, jwt.MapClaims{
 		"sub":   uuid.New().String(),
+		"jti":   uuid.New().String(),
+		"typ":   "refresh",
 		"email": "user@example.com",
 		"roles": []string{"user"},
 		"exp":   time.Now().Add(-time.Hour).Unix(),
 		"iat":   time.Now().Add(-2 * time.Hour).Unix(),
+	})
+
+	_, err := authService.Refresh(context.Background(), expiredToken)
+	assert.ErrorIs(t, err, AppErr.ErrInvalidToken)
+}
+
+func TestAuthService_Logout_RevokesFamilyAndAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, privKey := setupRSA(t)
+	logger := zerolog.Nop()
+	authService := NewAuthService(nil, logger, cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}}
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    u.ID,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
 	}
-	tokenObj := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	expiredToken, err := tokenObj.SignedString(privKey)
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub": u.ID.String(),
+		"jti": stored.ID.String(),
+		"typ": "refresh",
+		"exp": time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
+		"iat": time.Now().Unix(),
+	})
+	accessToken, accExp, err := authService.createAccessToken(u, grant{})
 	if err != nil {
-		t.Fatalf("failed to sign token: %v", err)
+		t.Fatalf("failed to create access token: %v", err)
+	}
+
+	mockTokens.
+		EXPECT().
+		GetRefreshToken(gomock.Any(), stored.ID).
+		Return(stored, nil)
+	mockTokens.
+		EXPECT().
+		RevokeFamily(gomock.Any(), stored.FamilyID).
+		Return(nil)
+	mockTokens.
+		EXPECT().
+		RevokeTokenID(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, jti string, exp time.Time) error {
+			assert.NotEmpty(t, jti)
+			assert.Equal(t, accExp.Unix(), exp.Unix(), "запись в denylist должна жить до exp токена")
+			return nil
+		})
+
+	err = authService.Logout(context.Background(), accessToken, refreshToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_LogoutAll_RevokedAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, _ := setupRSA(t)
+	logger := zerolog.Nop()
+	authService := NewAuthService(nil, logger, cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}}
+	accessToken, _, err := authService.createAccessToken(u, grant{})
+	if err != nil {
+		t.Fatalf("failed to create access token: %v", err)
 	}
 
-	_, err = authService.Refresh(expiredToken)
+	mockTokens.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Any()).
+		Return(true, nil)
+
+	err = authService.LogoutAll(context.Background(), accessToken)
 	assert.ErrorIs(t, err, AppErr.ErrInvalidToken)
 }
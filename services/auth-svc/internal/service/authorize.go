@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+)
+
+const (
+	// ResponseTypeCode is the only response type of the authorization
+	// endpoint.
+	ResponseTypeCode = "code"
+	// PKCEMethodS256 is the only supported PKCE code challenge method. The
+	// plain method would expose the verifier along with the challenge.
+	PKCEMethodS256 = "S256"
+
+	authorizationCodeBytes = 32
+)
+
+// AuthorizeRequest holds the parameters of an authorization request: RFC 6749
+// section 4.1.1, the PKCE extension of RFC 7636 and the OpenID Connect nonce.
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// Authorization is an authorization request whose client and redirect URI
+// have been checked, so that the response can be sent to the client.
+type Authorization struct {
+	Client *user.Client
+	// RedirectURI is where the response goes: the requested URI or, if none
+	// was requested, the only one registered.
+	RedirectURI string
+	Scopes      []string
+	State       string
+
+	req AuthorizeRequest
+}
+
+// AuthorizeResult is the outcome of a sign-in on the authorization page: the
+// redirect that carries the code back to the client, or a challenge for the
+// second factor.
+type AuthorizeResult struct {
+	RedirectURL string
+	Challenge   *MFAChallenge
+}
+
+// ValidateAuthorize checks an authorization request. An unknown or disabled
+// client yields ErrInvalidClient and an unregistered redirect URI
+// ErrInvalidRedirectURI; these must be shown to the user, never redirected.
+// Any other problem is returned as an *OAuthError together with the
+// Authorization, so that it can be reported to the client with ErrorRedirect.
+func (s *AuthService) ValidateAuthorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error) {
+	if req.ClientID == "" {
+		return nil, AppErr.ErrInvalidClient
+	}
+	cli, err := s.cliRepo.GetById(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, AppErr.ErrInvalidClient
+		}
+		s.log.Error().Err(err).Msg("get client by id")
+		return nil, err
+	}
+	if cli.Status != user.ClientStatusActive {
+		return nil, AppErr.ErrInvalidClient
+	}
+	redirect, err := redirectURIFor(cli, req.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+	az := &Authorization{Client: cli, RedirectURI: redirect, State: req.State, req: req}
+
+	if req.ResponseType != ResponseTypeCode {
+		return az, &OAuthError{OAuthUnsupportedResponseType, "response_type must be code"}
+	}
+	if !slices.Contains(cli.GrantTypes, GrantAuthorizationCode) {
+		return az, &OAuthError{OAuthUnauthorizedClient, "grant type authorization_code is not allowed for this client"}
+	}
+	requested := ParseScope(req.Scope)
+	if !isSubset(requested, cli.AllowedScopes) {
+		return az, &OAuthError{OAuthInvalidScope, "requested scope exceeds the scope granted to the client"}
+	}
+	if len(requested) == 0 {
+		requested = cli.AllowedScopes
+	}
+	az.Scopes = requested
+
+	switch {
+	case req.CodeChallenge == "" && cli.Public:
+		return az, &OAuthError{OAuthInvalidRequest, "code_challenge is required for public clients"}
+	case req.CodeChallenge == "" && req.CodeChallengeMethod != "":
+		return az, &OAuthError{OAuthInvalidRequest, "code_challenge_method requires code_challenge"}
+	case req.CodeChallenge == "":
+	case req.CodeChallengeMethod != PKCEMethodS256:
+		return az, &OAuthError{OAuthInvalidRequest, "code_challenge_method must be S256"}
+	case !validCodeChallenge(req.CodeChallenge):
+		return az, &OAuthError{OAuthInvalidRequest, "code_challenge is malformed"}
+	}
+	return az, nil
+}
+
+// AuthorizeWithPassword signs the user in on the authorization page. Users
+// with a second factor get a challenge to finish with AuthorizeWithMFA, the
+// others a redirect carrying the authorization code.
+func (s *AuthService) AuthorizeWithPassword(ctx context.Context, az *Authorization, email, password string) (*AuthorizeResult, error) {
+	u, err := s.authenticateUser(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabled || u.MFARequired {
+		challenge, err := s.createMFAChallenge(u)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthorizeResult{Challenge: challenge}, nil
+	}
+	return s.issueCode(ctx, az, u, []string{amrPassword})
+}
+
+// AuthorizeWithMFA finishes a sign-in on the authorization page like
+// VerifyMFA, but answers with an authorization code instead of tokens.
+func (s *AuthService) AuthorizeWithMFA(ctx context.Context, az *Authorization, challenge, code, recoveryCode string) (*AuthorizeResult, error) {
+	u, amr, err := s.passChallenge(ctx, challenge, code, recoveryCode)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueCode(ctx, az, u, amr)
+}
+
+// ErrorRedirect returns the redirect that reports e to the client of az.
+func (s *AuthService) ErrorRedirect(az *Authorization, e *OAuthError) string {
+	return s.redirectURL(az, url.Values{
+		"error":             {e.Code},
+		"error_description": {e.Description},
+	})
+}
+
+// issueCode stores a new authorization code for u and returns the redirect
+// that hands it to the client.
+func (s *AuthService) issueCode(ctx context.Context, az *Authorization, u *user.User, amr []string) (*AuthorizeResult, error) {
+	code, err := utils.GenerateSecret(authorizationCodeBytes)
+	if err != nil {
+		s.log.Error().Err(err).Msg("generate authorization code")
+		return nil, err
+	}
+	if err := s.codes.Create(ctx, &user.AuthorizationCode{
+		CodeHash:            utils.HashToken(code),
+		ClientID:            az.Client.ID,
+		UserID:              u.ID,
+		SessionID:           uuid.New(),
+		RedirectURI:         az.req.RedirectURI,
+		Scopes:              az.Scopes,
+		CodeChallenge:       az.req.CodeChallenge,
+		CodeChallengeMethod: az.req.CodeChallengeMethod,
+		Nonce:               az.req.Nonce,
+		AMR:                 amr,
+		ExpiresAt:           time.Now().Add(s.cfg.OAuth.CodeTTL),
+	}); err != nil {
+		s.log.Error().Err(err).Msg("store authorization code")
+		return nil, err
+	}
+	return &AuthorizeResult{RedirectURL: s.redirectURL(az, url.Values{"code": {code}})}, nil
+}
+
+// redirectURL adds params, the state and the issuer (RFC 9207) to the query
+// of the redirect URI of az.
+func (s *AuthService) redirectURL(az *Authorization, params url.Values) string {
+	if az.State != "" {
+		params.Set("state", az.State)
+	}
+	if s.cfg.JWT.Issuer != "" {
+		params.Set("iss", s.cfg.JWT.Issuer)
+	}
+	u, err := url.Parse(az.RedirectURI)
+	if err != nil {
+		return az.RedirectURI + "?" + params.Encode()
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// exchangeCode implements the authorization code grant. A code can be
+// exchanged once, by the client it was issued to, with the redirect URI it
+// was requested with and with the verifier of its PKCE challenge. Presenting
+// it again revokes the tokens it was exchanged for.
+func (s *AuthService) exchangeCode(ctx context.Context, cli *user.Client, req TokenRequest) (*TokenPair, error) {
+	if req.Code == "" {
+		return nil, &OAuthError{OAuthInvalidRequest, "code is required"}
+	}
+	invalid := &OAuthError{OAuthInvalidGrant, "authorization code is invalid, expired or used"}
+
+	hash := utils.HashToken(req.Code)
+	code, err := s.codes.Get(ctx, hash)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, invalid
+		}
+		s.log.Error().Err(err).Msg("get authorization code")
+		return nil, err
+	}
+	if code.UsedAt != nil {
+		return nil, s.handleCodeReplay(ctx, code, invalid)
+	}
+	if code.ClientID != cli.ID {
+		s.log.Warn().Str("client_id", cli.ID).Msg("authorization code was issued to another client")
+		return nil, invalid
+	}
+	if time.Now().After(code.ExpiresAt) {
+		return nil, invalid
+	}
+	if code.RedirectURI != "" && code.RedirectURI != req.RedirectURI {
+		return nil, &OAuthError{OAuthInvalidGrant, "redirect_uri does not match the authorization request"}
+	}
+	switch {
+	case code.CodeChallenge != "":
+		if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+			return nil, &OAuthError{OAuthInvalidGrant, "code_verifier does not match the code challenge"}
+		}
+	case cli.Public:
+		return nil, invalid
+	case req.CodeVerifier != "":
+		// A verifier for a code without a challenge hints at a downgrade.
+		return nil, &OAuthError{OAuthInvalidGrant, "code_verifier was sent for a code without challenge"}
+	}
+
+	if err := s.codes.Use(ctx, hash); err != nil {
+		if errors.Is(err, AppErr.ErrTokenReused) {
+			return nil, s.handleCodeReplay(ctx, code, invalid)
+		}
+		s.log.Error().Err(err).Msg("use authorization code")
+		return nil, err
+	}
+
+	u, err := s.repo.GetByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, invalid
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return nil, err
+	}
+	if u.Status != user.UserStatusActive {
+		return nil, toOAuthError(AppErr.ErrUserDisabled)
+	}
+	return s.issueTokenPair(ctx, u, code.SessionID, grant{
+		clientID: cli.ID,
+		scopes:   code.Scopes,
+		amr:      code.AMR,
+		authTime: code.CreatedAt.Unix(),
+		nonce:    code.Nonce,
+	})
+}
+
+// handleCodeReplay revokes the session opened with an authorization code
+// that was presented again, since either the client or an attacker holds a
+// copy of it. It returns invalid for the caller to report.
+func (s *AuthService) handleCodeReplay(ctx context.Context, code *user.AuthorizationCode, invalid error) error {
+	s.log.Warn().
+		Str("user_id", code.UserID.String()).
+		Str("client_id", code.ClientID).
+		Str("session_id", code.SessionID.String()).
+		Msg("authorization code reuse detected, revoking session")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditTokenReuse,
+		Outcome: user.AuditFailure,
+		Subject: code.UserID.String(),
+		Details: map[string]string{"session_id": code.SessionID.String(), "client_id": code.ClientID, "grant": GrantAuthorizationCode},
+	})
+	if err := s.tokenRepo.RevokeFamily(ctx, code.SessionID); err != nil {
+		s.log.Error().Err(err).Msg("revoke refresh token family")
+		return err
+	}
+	return invalid
+}
+
+// publicClient looks up a public client, which identifies itself by its id
+// alone. Confidential clients and unknown or disabled ones yield
+// ErrInvalidCredentials.
+func (s *AuthService) publicClient(ctx context.Context, id string) (*user.Client, error) {
+	cli, err := s.cliRepo.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, AppErr.ErrInvalidCredentials
+		}
+		s.log.Error().Err(err).Msg("get client by id")
+		return nil, err
+	}
+	if !cli.Public || cli.Status != user.ClientStatusActive {
+		return nil, AppErr.ErrInvalidCredentials
+	}
+	return cli, nil
+}
+
+// redirectURIFor returns the redirect URI of an authorization request. It
+// must be registered for the client verbatim, and may only be left out if
+// the client has registered exactly one.
+func redirectURIFor(cli *user.Client, requested string) (string, error) {
+	if requested == "" {
+		if len(cli.RedirectURIs) != 1 {
+			return "", AppErr.ErrInvalidRedirectURI
+		}
+		return cli.RedirectURIs[0], nil
+	}
+	if !slices.Contains(cli.RedirectURIs, requested) {
+		return "", AppErr.ErrInvalidRedirectURI
+	}
+	return requested, nil
+}
+
+// validCodeChallenge reports whether c is a base64url-encoded SHA-256 hash.
+func validCodeChallenge(c string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	return err == nil && len(b) == sha256.Size
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against its S256 challenge.
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if !validCodeVerifier(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	got := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(got), []byte(challenge)) == 1
+}
+
+// validCodeVerifier applies the syntax of RFC 7636 section 4.1: 43 to 128
+// unreserved characters.
+func validCodeVerifier(v string) bool {
+	if len(v) < 43 || len(v) > 128 {
+		return false
+	}
+	for _, c := range v {
+		isAlnum := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if !isAlnum && !strings.ContainsRune("-._~", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRedirectURI  = "https://shop.example.com/callback"
+	testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func newPublicClient() *model.Client {
+	return &model.Client{
+		ID:            "storefront-spa",
+		Status:        model.ClientStatusActive,
+		AllowedScopes: []string{ScopeOpenID, ScopeEmail},
+		GrantTypes:    []string{GrantAuthorizationCode, GrantRefreshToken},
+		RedirectURIs:  []string{testRedirectURI},
+		Public:        true,
+	}
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func oauthErrorCode(err error) string {
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Code
+	}
+	return ""
+}
+
+func TestAuthService_ValidateAuthorize(t *testing.T) {
+	valid := AuthorizeRequest{
+		ResponseType:        ResponseTypeCode,
+		ClientID:            "storefront-spa",
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid",
+		CodeChallenge:       codeChallenge(testCodeVerifier),
+		CodeChallengeMethod: PKCEMethodS256,
+	}
+	tests := []struct {
+		name     string
+		modify   func(r *AuthorizeRequest)
+		wantErr  error
+		wantCode string
+	}{
+		{name: "valid", modify: func(r *AuthorizeRequest) {}},
+		{
+			name:    "unregistered redirect uri",
+			modify:  func(r *AuthorizeRequest) { r.RedirectURI = "https://evil.example.com/callback" },
+			wantErr: AppErr.ErrInvalidRedirectURI,
+		},
+		{
+			name:     "public client without pkce",
+			modify:   func(r *AuthorizeRequest) { r.CodeChallenge, r.CodeChallengeMethod = "", "" },
+			wantCode: OAuthInvalidRequest,
+		},
+		{
+			name:     "plain pkce",
+			modify:   func(r *AuthorizeRequest) { r.CodeChallenge, r.CodeChallengeMethod = testCodeVerifier, "plain" },
+			wantCode: OAuthInvalidRequest,
+		},
+		{
+			name:     "unsupported response type",
+			modify:   func(r *AuthorizeRequest) { r.ResponseType = "token" },
+			wantCode: OAuthUnsupportedResponseType,
+		},
+		{
+			name:     "scope not allowed",
+			modify:   func(r *AuthorizeRequest) { r.Scope = "openid roles" },
+			wantCode: OAuthInvalidScope,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg, _ := setupRSA(t)
+			mockClient := mocks.NewMockClientRepository(ctrl)
+			authService := NewAuthService(nil, zerolog.Nop(), cfg, mockClient, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+			mockClient.EXPECT().GetById(gomock.Any(), "storefront-spa").Return(newPublicClient(), nil)
+
+			req := valid
+			tt.modify(&req)
+			az, err := authService.ValidateAuthorize(context.Background(), req)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, az, "на незарегистрированный адрес нельзя перенаправлять")
+			case tt.wantCode != "":
+				assert.Equal(t, tt.wantCode, oauthErrorCode(err))
+				if assert.NotNil(t, az) {
+					assert.Contains(t, authService.ErrorRedirect(az, &OAuthError{tt.wantCode, "x"}), testRedirectURI)
+				}
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthService_AuthorizationCodeFlow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := setupOIDC(t)
+	cfg.OAuth.CodeTTL = time.Minute
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	mockCodes := mocks.NewMockAuthorizationCodeRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, mockClient, mockTokens, mockCodes, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	hash, _ := passhash.New(config.HashConfig{}).Hash("password")
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: hash, Status: model.UserStatusActive, EmailVerified: true}
+	cli := newPublicClient()
+	mockClient.EXPECT().GetById(gomock.Any(), cli.ID).Return(cli, nil).AnyTimes()
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+
+	var stored *model.AuthorizationCode
+	mockCodes.
+		EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, c *model.AuthorizationCode) error {
+			stored = c
+			stored.CreatedAt = time.Now()
+			return nil
+		})
+
+	az, err := authService.ValidateAuthorize(context.Background(), AuthorizeRequest{
+		ResponseType:        ResponseTypeCode,
+		ClientID:            cli.ID,
+		Scope:               "openid email",
+		State:               "xyz",
+		CodeChallenge:       codeChallenge(testCodeVerifier),
+		CodeChallengeMethod: PKCEMethodS256,
+		Nonce:               "n-0S6",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, testRedirectURI, az.RedirectURI, "единственный зарегистрированный адрес используется по умолчанию")
+
+	res, err := authService.AuthorizeWithPassword(context.Background(), az, u.Email, "password")
+	if !assert.NoError(t, err) || !assert.NotNil(t, stored) {
+		return
+	}
+	redirect, err := url.Parse(res.RedirectURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	code := redirect.Query().Get("code")
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+	assert.Equal(t, testIssuer, redirect.Query().Get("iss"))
+	assert.Equal(t, utils.HashToken(code), stored.CodeHash, "в базе должен храниться хеш кода")
+	assert.Equal(t, []string{amrPassword}, stored.AMR)
+
+	mockCodes.EXPECT().Get(gomock.Any(), stored.CodeHash).Return(stored, nil).Times(2)
+
+	_, err = authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     cli.ID,
+		Code:         code,
+		CodeVerifier: "wrong-verifier-wrong-verifier-wrong-verifier",
+	})
+	assert.Equal(t, OAuthInvalidGrant, oauthErrorCode(err), "код не должен обмениваться без верного code_verifier")
+
+	mockCodes.EXPECT().Use(gomock.Any(), stored.CodeHash).Return(nil)
+	mockTokens.
+		EXPECT().
+		CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, s *model.Session, _ *model.RefreshToken) error {
+			assert.Equal(t, stored.SessionID, s.ID, "сессия должна быть заранее закреплена за кодом")
+			return nil
+		})
+	resp, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     cli.ID,
+		Code:         code,
+		CodeVerifier: testCodeVerifier,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "openid email", resp.Scope)
+	id := parseIDToken(t, authService, resp.IDToken, cli.ID)
+	assert.Equal(t, "n-0S6", id["nonce"], "id_token должен содержать nonce запроса")
+	assert.Equal(t, float64(stored.CreatedAt.Unix()), id[authTimeClaim])
+}
+
+func TestAuthService_Token_AuthorizationCodeReplay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	mockCodes := mocks.NewMockAuthorizationCodeRepository(ctrl)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, mockClient, mockTokens, mockCodes, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	cli := newPublicClient()
+	used := time.Now().Add(-10 * time.Second)
+	code := &model.AuthorizationCode{
+		CodeHash:      utils.HashToken("code"),
+		ClientID:      cli.ID,
+		UserID:        uuid.New(),
+		SessionID:     uuid.New(),
+		CodeChallenge: codeChallenge(testCodeVerifier),
+		ExpiresAt:     time.Now().Add(time.Minute),
+		UsedAt:        &used,
+	}
+	mockClient.EXPECT().GetById(gomock.Any(), cli.ID).Return(cli, nil)
+	mockCodes.EXPECT().Get(gomock.Any(), code.CodeHash).Return(code, nil)
+	mockTokens.EXPECT().RevokeFamily(gomock.Any(), code.SessionID).Return(nil)
+
+	_, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     cli.ID,
+		Code:         "code",
+		CodeVerifier: testCodeVerifier,
+	})
+	assert.Equal(t, OAuthInvalidGrant, oauthErrorCode(err), "повторное использование кода должно отзывать выданные по нему токены")
+}
+
+func TestAuthService_Token_ConfidentialClientNeedsSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, mockClient, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	confidential := newTestClient(t, "secret")
+	confidential.GrantTypes = append(confidential.GrantTypes, GrantAuthorizationCode)
+	mockClient.EXPECT().GetById(gomock.Any(), confidential.ID).Return(confidential, nil)
+
+	_, err := authService.Token(context.Background(), TokenRequest{
+		GrantType: GrantAuthorizationCode,
+		ClientID:  confidential.ID,
+		Code:      "code",
+	})
+	assert.Equal(t, OAuthInvalidClient, oauthErrorCode(err), "конфиденциальный клиент обязан передавать секрет")
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const clientSecretBytes = 32
+
+// ClientService manages the OAuth clients (service accounts) that may call
+// the token endpoint. actor is the subject of the admin making a change and
+// is audited with it.
+type ClientService struct {
+	repo   repository.ClientRepository
+	log    zerolog.Logger
+	cfg    *config.Config
+	hasher *passhash.Hasher
+	audit  *audit.Log
+}
+
+func NewClientService(repo repository.ClientRepository, log zerolog.Logger, cfg *config.Config, auditLog *audit.Log) *ClientService {
+	return &ClientService{repo: repo, log: log, cfg: cfg, hasher: passhash.New(cfg.Hash), audit: auditLog}
+}
+
+// Create registers a client with a freshly generated secret. The plaintext
+// secret is returned only here; just its hash is stored. Public clients get
+// no secret: they sign users in with the authorization code flow, so that is
+// their default grant instead of client_credentials.
+func (s *ClientService) Create(ctx context.Context, c *user.Client, actor string) (*user.Client, string, error) {
+	secret, hash, err := s.newClientSecret()
+	if err != nil {
+		s.log.Error().Err(err).Msg("generate client secret")
+		return nil, "", err
+	}
+	// The column is not nullable, so public clients keep the hash of a
+	// secret nobody knows.
+	c.Secret = hash
+	if len(c.GrantTypes) == 0 {
+		c.GrantTypes = []string{GrantClientCredentials}
+		if c.Public {
+			c.GrantTypes = []string{GrantAuthorizationCode, GrantRefreshToken}
+		}
+	}
+	created, err := s.repo.Create(ctx, c)
+	if err != nil {
+		return nil, "", err
+	}
+	s.log.Info().Str("client_id", created.ID).Bool("public", created.Public).Str("actor", actor).Msg("client created")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditClientCreated,
+		Actor:   actor,
+		Subject: created.ID,
+		Details: map[string]string{
+			"grant_types": strings.Join(created.GrantTypes, " "),
+			"scopes":      strings.Join(created.AllowedScopes, " "),
+			"public":      strconv.FormatBool(created.Public),
+
+			"exchange_audiences": strings.Join(created.ExchangeAudiences, " "),
+		},
+	})
+	if created.Public {
+		return created, "", nil
+	}
+	return created, secret, nil
+}
+
+func (s *ClientService) List(ctx context.Context, limit, offset int32) ([]user.Client, error) {
+	return s.repo.List(ctx, limit, offset)
+}
+
+func (s *ClientService) Get(ctx context.Context, id string) (*user.Client, error) {
+	return s.repo.GetById(ctx, id)
+}
+
+// SetRedirectURIs replaces the redirect URIs registered for the client.
+func (s *ClientService) SetRedirectURIs(ctx context.Context, id string, uris []string, actor string) error {
+	if err := s.repo.SetRedirectURIs(ctx, id, uris); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", id).Strs("redirect_uris", uris).Str("actor", actor).Msg("client redirect uris updated")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditClientRedirectURIsSet,
+		Actor:   actor,
+		Subject: id,
+		Details: map[string]string{"redirect_uris": strings.Join(uris, " ")},
+	})
+	return nil
+}
+
+// SetExchangeAudiences replaces the audiences the client may request with the
+// token exchange grant.
+func (s *ClientService) SetExchangeAudiences(ctx context.Context, id string, audiences []string, actor string) error {
+	if err := s.repo.SetExchangeAudiences(ctx, id, audiences); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", id).Strs("exchange_audiences", audiences).Str("actor", actor).Msg("client exchange audiences updated")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditClientExchangeAudiencesSet,
+		Actor:   actor,
+		Subject: id,
+		Details: map[string]string{"exchange_audiences": strings.Join(audiences, " ")},
+	})
+	return nil
+}
+
+func (s *ClientService) Disable(ctx context.Context, id, actor string) error {
+	if err := s.repo.SetStatus(ctx, id, user.ClientStatusDisabled); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", id).Str("actor", actor).Msg("client disabled")
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditClientDisabled, Actor: actor, Subject: id})
+	return nil
+}
+
+func (s *ClientService) Enable(ctx context.Context, id, actor string) error {
+	if err := s.repo.SetStatus(ctx, id, user.ClientStatusActive); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", id).Str("actor", actor).Msg("client enabled")
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditClientEnabled, Actor: actor, Subject: id})
+	return nil
+}
+
+func (s *ClientService) Delete(ctx context.Context, id, actor string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", id).Str("actor", actor).Msg("client deleted")
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditClientDeleted, Actor: actor, Subject: id})
+	return nil
+}
+
+// RotateSecret replaces the client secret. The old secret keeps working for
+// grace (the configured default when zero), so that the client can be
+// redeployed with the new one without downtime. The new plaintext secret is
+// returned together with the time the old one stops working.
+func (s *ClientService) RotateSecret(ctx context.Context, id string, grace time.Duration, actor string) (string, time.Time, error) {
+	if grace <= 0 {
+		grace = s.cfg.OAuth.ClientSecretGrace
+	}
+	secret, hash, err := s.newClientSecret()
+	if err != nil {
+		s.log.Error().Err(err).Msg("generate client secret")
+		return "", time.Time{}, err
+	}
+	previousExpiresAt := time.Now().Add(grace)
+	if err := s.repo.RotateSecret(ctx, id, hash, previousExpiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+	s.log.Info().Str("client_id", id).Time("previous_expires_at", previousExpiresAt).Str("actor", actor).Msg("client secret rotated")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditClientSecretRotated,
+		Actor:   actor,
+		Subject: id,
+		Details: map[string]string{"previous_expires_at": previousExpiresAt.UTC().Format(time.RFC3339)},
+	})
+	return secret, previousExpiresAt, nil
+}
+
+func (s *ClientService) newClientSecret() (string, string, error) {
+	secret, err := utils.GenerateSecret(clientSecretBytes)
+	if err != nil {
+		return "", "", err
+	}
+	hash, err := s.hasher.Hash(secret)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hash, nil
+}
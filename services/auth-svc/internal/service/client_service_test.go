@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientService_Create_ReturnsPlaintextSecretOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	svc := NewClientService(mockClient, zerolog.Nop(), cfg, nil)
+
+	var stored *model.Client
+	mockClient.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, c *model.Client) (*model.Client, error) {
+			stored = c
+			return c, nil
+		})
+
+	cli, secret, err := svc.Create(context.Background(), &model.Client{ID: "order-svc", Roles: []string{"service"}}, "admin-id")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, secret)
+	assert.NotEqual(t, secret, stored.Secret, "в базе должен храниться хеш, а не секрет")
+	match, _ := svc.hasher.Verify(secret, stored.Secret)
+	assert.True(t, match)
+	assert.Equal(t, []string{GrantClientCredentials}, cli.GrantTypes, "по умолчанию должен быть client_credentials")
+}
+
+func TestClientService_RotateSecret_OldSecretValidDuringGrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	clients := NewClientService(mockClient, zerolog.Nop(), cfg, nil)
+	auth := NewAuthService(mocks.NewMockAuthRepository(ctrl), zerolog.Nop(), cfg,
+		mockClient, mocks.NewMockTokenRepository(ctrl), nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	cli := newTestClient(t, "old-secret")
+	mockClient.EXPECT().
+		RotateSecret(gomock.Any(), cli.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, hash string, previousExpiresAt time.Time) error {
+			cli.PreviousSecret, cli.Secret = cli.Secret, hash
+			cli.PreviousSecretExpiresAt = &previousExpiresAt
+			return nil
+		})
+	mockClient.EXPECT().GetById(gomock.Any(), cli.ID).Return(cli, nil).AnyTimes()
+
+	secret, expiresAt, err := clients.RotateSecret(context.Background(), cli.ID, time.Hour, "admin-id")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	_, err = auth.AuthenticateClient(context.Background(), cli.ID, secret)
+	assert.NoError(t, err, "новый секрет должен приниматься")
+	_, err = auth.AuthenticateClient(context.Background(), cli.ID, "old-secret")
+	assert.NoError(t, err, "старый секрет должен приниматься в течение grace-периода")
+
+	past := time.Now().Add(-time.Second)
+	cli.PreviousSecretExpiresAt = &past
+	_, err = auth.AuthenticateClient(context.Background(), cli.ID, "old-secret")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidCredentials, "после grace-периода старый секрет не должен приниматься")
+}
+
+func TestClientService_RotateSecret_UsesConfiguredGrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.OAuth.ClientSecretGrace = 2 * time.Hour
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	svc := NewClientService(mockClient, zerolog.Nop(), cfg, nil)
+
+	mockClient.EXPECT().RotateSecret(gomock.Any(), "cart-svc", gomock.Any(), gomock.Any()).Return(nil)
+
+	_, expiresAt, err := svc.RotateSecret(context.Background(), "cart-svc", 0, "admin-id")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), expiresAt, time.Minute)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// Introspection is the RFC 7662 view of a token. Only Active is meaningful
+// when the token is not active.
+type Introspection struct {
+	Active      bool
+	TokenType   string
+	Subject     string
+	Email       string
+	Roles       []string
+	Permissions []string
+	ClientID    string
+	Scope       string
+	JTI         string
+	ExpiresAt   int64
+	IssuedAt    int64
+}
+
+// Introspect reports whether token is currently usable. Besides the signature
+// and expiry it checks the denylist, the refresh token store and the current
+// status of the user or client the token was issued to. Errors are returned
+// only when that state cannot be read; a bad token is simply inactive.
+func (s *AuthService) Introspect(ctx context.Context, token string) (*Introspection, error) {
+	inactive := &Introspection{}
+
+	claims, err := s.parseToken(token)
+	if errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		audiences, aerr := s.exchangeAudiences(ctx)
+		if aerr != nil {
+			return nil, aerr
+		}
+		claims, err = s.parseToken(token, audiences...)
+	}
+	if err != nil {
+		s.log.Debug().Err(err).Msg("introspect: parse token")
+		return inactive, nil
+	}
+	typ, _ := claims["typ"].(string)
+	sub, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+	if sub == "" || jti == "" {
+		return inactive, nil
+	}
+
+	var active bool
+	switch typ {
+	case tokenTypeAccess:
+		active, err = s.accessTokenActive(ctx, jti, sub)
+	case tokenTypeRefresh:
+		active, err = s.refreshTokenActive(ctx, jti, sub)
+	case tokenTypeClient:
+		active, err = s.clientTokenActive(ctx, jti, sub)
+	default:
+		return inactive, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return inactive, nil
+	}
+
+	res := &Introspection{
+		Active:      true,
+		TokenType:   typ,
+		Subject:     sub,
+		JTI:         jti,
+		Roles:       stringsClaim(claims, "roles"),
+		Permissions: stringsClaim(claims, permissionsClaim),
+	}
+	res.Email, _ = claims["email"].(string)
+	res.ClientID, _ = claims["client_id"].(string)
+	res.Scope, _ = claims["scope"].(string)
+	if typ == tokenTypeClient {
+		res.ClientID = sub
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		res.ExpiresAt = exp.Unix()
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		res.IssuedAt = iat.Unix()
+	}
+	return res, nil
+}
+
+func (s *AuthService) accessTokenActive(ctx context.Context, jti, sub string) (bool, error) {
+	revoked, err := s.IsTokenRevoked(ctx, jti)
+	if err != nil || revoked {
+		return false, err
+	}
+	return s.userActive(ctx, sub)
+}
+
+func (s *AuthService) refreshTokenActive(ctx context.Context, jti, sub string) (bool, error) {
+	id, err := uuid.Parse(jti)
+	if err != nil {
+		return false, nil
+	}
+	stored, err := s.tokenRepo.GetRefreshToken(ctx, id)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return false, nil
+		}
+		s.log.Error().Err(err).Msg("get refresh token")
+		return false, err
+	}
+	if stored.RotatedAt != nil || stored.RevokedAt != nil || stored.UserID.String() != sub {
+		return false, nil
+	}
+	return s.userActive(ctx, sub)
+}
+
+func (s *AuthService) clientTokenActive(ctx context.Context, jti, sub string) (bool, error) {
+	revoked, err := s.IsTokenRevoked(ctx, jti)
+	if err != nil || revoked {
+		return false, err
+	}
+	cli, err := s.cliRepo.GetById(ctx, sub)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return false, nil
+		}
+		s.log.Error().Err(err).Msg("get client by id")
+		return false, err
+	}
+	return cli.Status == user.ClientStatusActive, nil
+}
+
+func (s *AuthService) userActive(ctx context.Context, sub string) (bool, error) {
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return false, nil
+	}
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return false, nil
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return false, err
+	}
+	return u.Status == user.UserStatusActive, nil
+}
+
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	raw, ok := claims[name].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if str, ok := v.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthService_Introspect_ActiveAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, _ := setupRSA(t)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}, Status: model.UserStatusActive}
+	accessToken, accExp, err := authService.createAccessToken(u, grant{})
+	if err != nil {
+		t.Fatalf("failed to create access token: %v", err)
+	}
+
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+
+	res, err := authService.Introspect(context.Background(), accessToken)
+	assert.NoError(t, err)
+	assert.True(t, res.Active)
+	assert.Equal(t, "access", res.TokenType)
+	assert.Equal(t, u.ID.String(), res.Subject)
+	assert.Equal(t, []string{"user"}, res.Roles)
+	assert.Equal(t, accExp.Unix(), res.ExpiresAt)
+}
+
+func TestAuthService_Introspect_DisabledUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, _ := setupRSA(t)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}, Status: model.UserStatusDisabled}
+	accessToken, _, err := authService.createAccessToken(u, grant{})
+	if err != nil {
+		t.Fatalf("failed to create access token: %v", err)
+	}
+
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+
+	res, err := authService.Introspect(context.Background(), accessToken)
+	assert.NoError(t, err)
+	assert.False(t, res.Active, "токен заблокированного пользователя не должен быть активным")
+	assert.Empty(t, res.Subject)
+}
+
+func TestAuthService_Introspect_GarbageToken(t *testing.T) {
+	cfg, _ := setupRSA(t)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	res, err := authService.Introspect(context.Background(), "not-a-jwt")
+	assert.NoError(t, err)
+	assert.False(t, res.Active)
+}
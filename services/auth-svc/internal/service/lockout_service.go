@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"github.com/rs/zerolog"
+)
+
+// LockoutService lets administrators lift sign-in lockouts and review them.
+type LockoutService struct {
+	guard   *throttle.Guard
+	users   repository.AuthRepository
+	clients repository.ClientRepository
+	events  repository.ThrottleRepository
+	log     zerolog.Logger
+	audit   *audit.Log
+}
+
+func NewLockoutService(guard *throttle.Guard, users repository.AuthRepository, clients repository.ClientRepository,
+	events repository.ThrottleRepository, log zerolog.Logger, auditLog *audit.Log) *LockoutService {
+	return &LockoutService{guard: guard, users: users, clients: clients, events: events, log: log, audit: auditLog}
+}
+
+// RunCleanup periodically deletes the sign-in failure counters kept in
+// Postgres that expired until ctx is cancelled.
+func (s *LockoutService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.events.DeleteExpired(ctx)
+			if err != nil {
+				s.log.Error().Err(err).Msg("delete expired login failures")
+				continue
+			}
+			s.log.Debug().Int64("deleted", n).Msg("expired login failures cleaned up")
+		}
+	}
+}
+
+// UnlockUser lifts the lockout of a user account.
+func (s *LockoutService) UnlockUser(ctx context.Context, id uuid.UUID, actor string) error {
+	u, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.guard.Unlock(ctx, throttle.Account(u.Email), actor); err != nil {
+		s.log.Error().Err(err).Str("user_id", id.String()).Msg("unlock user")
+		return err
+	}
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditUserUnlocked, Actor: actor, Subject: id.String()})
+	return nil
+}
+
+// UnlockClient lifts the lockout of a service client.
+func (s *LockoutService) UnlockClient(ctx context.Context, id, actor string) error {
+	if _, err := s.clients.GetById(ctx, id); err != nil {
+		return err
+	}
+	if err := s.guard.Unlock(ctx, throttle.Client(id), actor); err != nil {
+		s.log.Error().Err(err).Str("client_id", id).Msg("unlock client")
+		return err
+	}
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditClientUnlocked, Actor: actor, Subject: id})
+	return nil
+}
+
+// UnlockIP lifts the lockout of an IP address.
+func (s *LockoutService) UnlockIP(ctx context.Context, ip, actor string) error {
+	if err := s.guard.Unlock(ctx, throttle.IP(ip), actor); err != nil {
+		s.log.Error().Err(err).Str("ip", ip).Msg("unlock ip")
+		return err
+	}
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditIPUnlocked, Actor: actor, Subject: ip})
+	return nil
+}
+
+// Events returns lockouts and unlocks, newest first.
+func (s *LockoutService) Events(ctx context.Context, limit, offset int32) ([]user.LockoutEvent, error) {
+	return s.events.ListLockouts(ctx, limit, offset)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// lockStore reports every key in locked as locked and counts failures.
+type lockStore struct {
+	locked   map[string]time.Time
+	failures map[string]int
+}
+
+func newLockStore() *lockStore {
+	return &lockStore{locked: map[string]time.Time{}, failures: map[string]int{}}
+}
+
+func (s *lockStore) Get(_ context.Context, key string) (throttle.Entry, error) {
+	return throttle.Entry{Failures: s.failures[key], LockedUntil: s.locked[key]}, nil
+}
+
+func (s *lockStore) AddFailure(_ context.Context, key string, _ time.Duration) (int, error) {
+	s.failures[key]++
+	return s.failures[key], nil
+}
+
+func (s *lockStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.locked[key] = until
+	return nil
+}
+
+func (s *lockStore) Reset(_ context.Context, key string) error {
+	delete(s.locked, key)
+	delete(s.failures, key)
+	return nil
+}
+
+func testThrottleConfig() config.ThrottleConfig {
+	return config.ThrottleConfig{
+		AccountThreshold: 2,
+		IPThreshold:      10,
+		BaseLockout:      time.Minute,
+		MaxLockout:       time.Hour,
+		Window:           15 * time.Minute,
+	}
+}
+
+func TestAuthService_Login_LockedAfterFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	store := newLockStore()
+	guard := throttle.NewGuard(store, nil, testThrottleConfig(), zerolog.Nop())
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, guard, nil, nil)
+	ctx := requestinfo.WithClientIP(context.Background(), "10.0.0.1")
+
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "user@example.com").Return(nil, AppErr.ErrNotFound).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := authService.Login(ctx, "user@example.com", "wrong")
+		assert.ErrorIs(t, err, AppErr.ErrInvalidCredentials)
+	}
+
+	// The repository is not consulted any more while the account is locked.
+	_, err := authService.Login(ctx, "user@example.com", "wrong")
+	assert.ErrorIs(t, err, AppErr.ErrAccountLocked)
+	assert.Equal(t, 2, store.failures["ip:10.0.0.1"], "неудачи учитываются и по IP")
+}
+
+func TestAuthService_Token_ClientLocked(t *testing.T) {
+	cfg, _ := setupRSA(t)
+	store := newLockStore()
+	store.locked[throttle.Client("cart-svc").String()] = time.Now().Add(time.Minute)
+	guard := throttle.NewGuard(store, nil, testThrottleConfig(), zerolog.Nop())
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, guard, nil, nil)
+
+	_, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantClientCredentials,
+		ClientID:     "cart-svc",
+		ClientSecret: "secret",
+	})
+	assert.ErrorIs(t, err, AppErr.ErrAccountLocked, "заблокированный клиент не должен проверяться по секрету")
+}
+
+func TestLockoutService_UnlockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	store := newLockStore()
+	guard := throttle.NewGuard(store, nil, testThrottleConfig(), zerolog.Nop())
+	svc := NewLockoutService(guard, mockRepo, nil, nil, zerolog.Nop(), nil)
+
+	u := &model.User{ID: uuid.New(), Email: "User@Example.com"}
+	key := throttle.Account(u.Email).String()
+	store.locked[key] = time.Now().Add(time.Hour)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+
+	assert.NoError(t, svc.UnlockUser(context.Background(), u.ID, "admin-id"))
+	assert.NotContains(t, store.locked, key, "блокировка должна быть снята")
+
+	missing := uuid.New()
+	mockRepo.EXPECT().GetByID(gomock.Any(), missing).Return(nil, AppErr.ErrNotFound)
+	assert.ErrorIs(t, svc.UnlockUser(context.Background(), missing, "admin-id"), AppErr.ErrNotFound)
+}
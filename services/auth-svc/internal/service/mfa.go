@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/totp"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+)
+
+// tokenTypeMFAChallenge marks the signed tokens of the second sign-in step.
+const tokenTypeMFAChallenge = "mfa_challenge"
+
+const (
+	// mfaEnrollClaim marks the challenges of users who have to set up an
+	// authenticator before they can finish signing in.
+	mfaEnrollClaim = "mfa_enroll"
+	// totpSkew is how many time steps a code may be off, for clock drift.
+	totpSkew = 1
+	// recoveryCodeBytes gives ten base32 characters per recovery code.
+	recoveryCodeBytes = 6
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFAChallenge is returned by Login instead of tokens when the user has to
+// present a second factor. Its token is exchanged for tokens by VerifyMFA.
+type MFAChallenge struct {
+	Token     string
+	ExpiresAt int64
+	// EnrollmentRequired is set when a role of the user requires a second
+	// factor the user has not set up yet. The authenticator is then enrolled
+	// with the challenge, see StartChallengeEnrollment.
+	EnrollmentRequired bool
+}
+
+// TOTPEnrollment is a started, not yet confirmed authenticator.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAEnrollmentResult is the outcome of an enrollment confirmed during
+// sign-in: the recovery codes and the tokens the sign-in was waiting for.
+type MFAEnrollmentResult struct {
+	RecoveryCodes []string
+	Tokens        *TokenPair
+}
+
+type MFAStatus struct {
+	Enabled           bool
+	Required          bool
+	RecoveryCodesLeft int
+}
+
+// VerifyMFA finishes a sign-in with a TOTP code or, failing that, a recovery
+// code. Wrong codes are throttled like wrong passwords, and a challenge can
+// be used for one successful sign-in only.
+func (s *AuthService) VerifyMFA(ctx context.Context, challenge, code, recoveryCode string) (*TokenPair, error) {
+	u, amr, err := s.passChallenge(ctx, challenge, code, recoveryCode)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(ctx, u, uuid.New(), grant{amr: amr})
+}
+
+// passChallenge checks the second factor presented for challenge and uses the
+// challenge up. It returns the user and how they authenticated.
+func (s *AuthService) passChallenge(ctx context.Context, challenge, code, recoveryCode string) (*user.User, []string, error) {
+	u, claims, err := s.challengeUser(ctx, challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !u.MFAEnabled {
+		return nil, nil, AppErr.ErrMFANotEnabled
+	}
+
+	ip := requestinfo.ClientIP(ctx)
+	account := throttle.Account(u.Email)
+	if err := s.guard.Check(ctx, account, throttle.IP(ip)); err != nil {
+		s.auditSecondFactor(ctx, u, "locked")
+		return nil, nil, err
+	}
+	amr, err := s.checkSecondFactor(ctx, u.ID, code, recoveryCode)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrInvalidMFACode) {
+			s.guard.Fail(ctx, ip, account, throttle.IP(ip))
+			s.auditSecondFactor(ctx, u, "wrong code")
+		}
+		return nil, nil, err
+	}
+	s.guard.Succeed(ctx, account)
+	s.auditSecondFactor(ctx, u, "")
+
+	if err := s.revokeToken(ctx, claims); err != nil {
+		return nil, nil, err
+	}
+	return u, amr, nil
+}
+
+// auditSecondFactor records an answer of u to a sign-in challenge, wrong for
+// reason unless it is empty.
+func (s *AuthService) auditSecondFactor(ctx context.Context, u *user.User, reason string) {
+	e := user.AuditEvent{Type: user.AuditSecondFactor, Subject: u.ID.String()}
+	if reason != "" {
+		e.Outcome = user.AuditFailure
+		e.Details = map[string]string{"reason": reason}
+	} else {
+		e.Actor = e.Subject
+	}
+	s.audit.Record(ctx, e)
+}
+
+// StartChallengeEnrollment starts the enrollment of an authenticator for a
+// user whose sign-in is waiting for one.
+func (s *AuthService) StartChallengeEnrollment(ctx context.Context, challenge string) (*TOTPEnrollment, error) {
+	u, claims, err := s.challengeUser(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if enroll, _ := claims[mfaEnrollClaim].(bool); !enroll {
+		return nil, AppErr.ErrMFAAlreadyEnabled
+	}
+	return s.startEnrollment(ctx, u)
+}
+
+// ConfirmChallengeEnrollment confirms the authenticator enrolled with
+// StartChallengeEnrollment and finishes the sign-in.
+func (s *AuthService) ConfirmChallengeEnrollment(ctx context.Context, challenge, code string) (*MFAEnrollmentResult, error) {
+	u, claims, err := s.challengeUser(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if enroll, _ := claims[mfaEnrollClaim].(bool); !enroll {
+		return nil, AppErr.ErrMFAAlreadyEnabled
+	}
+	codes, err := s.ConfirmTOTPEnrollment(ctx, u.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.revokeToken(ctx, claims); err != nil {
+		return nil, err
+	}
+	u.MFAEnabled = true
+	tokens, err := s.issueTokenPair(ctx, u, uuid.New(), grant{amr: []string{amrPassword, amrOTP, amrMFA}})
+	if err != nil {
+		return nil, err
+	}
+	return &MFAEnrollmentResult{RecoveryCodes: codes, Tokens: tokens}, nil
+}
+
+// StartTOTPEnrollment generates a new authenticator secret for the user. It
+// replaces any unconfirmed one and fails with ErrMFAAlreadyEnabled if the
+// user already has a confirmed authenticator.
+func (s *AuthService) StartTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startEnrollment(ctx, u)
+}
+
+func (s *AuthService) startEnrollment(ctx context.Context, u *user.User) (*TOTPEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.log.Error().Err(err).Msg("generate totp secret")
+		return nil, err
+	}
+	if err := s.mfaRepo.StartTOTP(ctx, u.ID, secret); err != nil {
+		if !errors.Is(err, AppErr.ErrMFAAlreadyEnabled) {
+			s.log.Error().Err(err).Str("user_id", u.ID.String()).Msg("start totp enrollment")
+		}
+		return nil, err
+	}
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.MFA.Issuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables two-factor authentication once the user
+// proves the authenticator works, and returns a fresh set of recovery codes.
+// The codes are shown only here; just their hashes are stored.
+func (s *AuthService) ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	t, err := s.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, AppErr.ErrMFANotEnabled
+		}
+		s.log.Error().Err(err).Msg("get totp")
+		return nil, err
+	}
+	if t.Confirmed {
+		return nil, AppErr.ErrMFAAlreadyEnabled
+	}
+	step, ok := totp.Validate(t.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, AppErr.ErrInvalidMFACode
+	}
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ConfirmTOTP(ctx, userID, step, hashes); err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, AppErr.ErrInvalidMFACode
+		}
+		s.log.Error().Err(err).Msg("confirm totp")
+		return nil, err
+	}
+	s.log.Info().Str("user_id", userID.String()).Msg("two-factor authentication enabled")
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking a current
+// code or a recovery code. Users whose roles require a second factor cannot
+// turn it off.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID uuid.UUID, code, recoveryCode string) error {
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.MFARequired {
+		return AppErr.ErrMFARequired
+	}
+	if _, err := s.checkSecondFactor(ctx, userID, code, recoveryCode); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Disable(ctx, userID); err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return AppErr.ErrMFANotEnabled
+		}
+		s.log.Error().Err(err).Msg("disable totp")
+		return err
+	}
+	s.log.Info().Str("user_id", userID.String()).Msg("two-factor authentication disabled")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user after
+// checking a current TOTP code.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if _, err := s.checkSecondFactor(ctx, userID, code, ""); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		s.log.Error().Err(err).Msg("replace recovery codes")
+		return nil, err
+	}
+	s.log.Info().Str("user_id", userID.String()).Msg("recovery codes regenerated")
+	return codes, nil
+}
+
+func (s *AuthService) MFAStatus(ctx context.Context, userID uuid.UUID) (*MFAStatus, error) {
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Enabled: u.MFAEnabled, Required: u.MFARequired}
+	if u.MFAEnabled {
+		if status.RecoveryCodesLeft, err = s.mfaRepo.CountRecoveryCodes(ctx, userID); err != nil {
+			s.log.Error().Err(err).Msg("count recovery codes")
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// ResetMFA removes the authenticator and recovery codes of a user who lost
+// them, on behalf of actor.
+func (s *AuthService) ResetMFA(ctx context.Context, userID uuid.UUID, actor string) error {
+	if err := s.mfaRepo.Disable(ctx, userID); err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return AppErr.ErrMFANotEnabled
+		}
+		s.log.Error().Err(err).Msg("reset mfa")
+		return err
+	}
+	s.log.Warn().Str("user_id", userID.String()).Str("actor", actor).Msg("two-factor authentication reset")
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditMFAReset, Actor: actor, Subject: userID.String()})
+	return nil
+}
+
+// checkSecondFactor checks a recovery code if one is given and a TOTP code
+// otherwise, and returns the amr values of the method used. Both kinds of
+// code are accepted once only.
+func (s *AuthService) checkSecondFactor(ctx context.Context, userID uuid.UUID, code, recoveryCode string) ([]string, error) {
+	if recoveryCode != "" {
+		err := s.mfaRepo.UseRecoveryCode(ctx, userID, utils.HashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			if errors.Is(err, AppErr.ErrNotFound) {
+				return nil, AppErr.ErrInvalidMFACode
+			}
+			s.log.Error().Err(err).Msg("use recovery code")
+			return nil, err
+		}
+		s.log.Info().Str("user_id", userID.String()).Msg("recovery code used")
+		return []string{amrPassword, amrMFA}, nil
+	}
+
+	t, err := s.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, AppErr.ErrMFANotEnabled
+		}
+		s.log.Error().Err(err).Msg("get totp")
+		return nil, err
+	}
+	if !t.Confirmed {
+		return nil, AppErr.ErrMFANotEnabled
+	}
+	step, ok := totp.Validate(t.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, AppErr.ErrInvalidMFACode
+	}
+	if err := s.mfaRepo.UseStep(ctx, userID, step); err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Warn().Str("user_id", userID.String()).Msg("totp code replayed")
+			return nil, AppErr.ErrInvalidMFACode
+		}
+		s.log.Error().Err(err).Msg("use totp step")
+		return nil, err
+	}
+	return []string{amrPassword, amrOTP, amrMFA}, nil
+}
+
+// challengeUser returns the active user an unused challenge was issued to.
+func (s *AuthService) challengeUser(ctx context.Context, challenge string) (*user.User, jwt.MapClaims, error) {
+	claims, err := s.parseTypedToken(challenge, tokenTypeMFAChallenge)
+	if err != nil {
+		s.log.Error().Err(err).Msg("parse mfa challenge")
+		return nil, nil, AppErr.ErrInvalidToken
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, nil, AppErr.ErrInvalidToken
+	}
+	used, err := s.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return nil, nil, err
+	}
+	if used {
+		return nil, nil, AppErr.ErrInvalidToken
+	}
+	sub, err := uuidClaim(claims, "sub")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token subject")
+		return nil, nil, AppErr.ErrInvalidToken
+	}
+
+	u, err := s.repo.GetByID(ctx, sub)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, nil, AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return nil, nil, err
+	}
+	if u.Status != user.UserStatusActive {
+		return nil, nil, AppErr.ErrUserDisabled
+	}
+	return u, claims, nil
+}
+
+func (s *AuthService) createMFAChallenge(u *user.User) (*MFAChallenge, error) {
+	now := time.Now()
+	exp := now.Add(s.cfg.MFA.ChallengeTTL)
+	claims := jwt.MapClaims{
+		"sub": u.ID,
+		"jti": uuid.NewString(),
+		"typ": tokenTypeMFAChallenge,
+		"exp": exp.Unix(),
+		"iat": now.Unix(),
+	}
+	enroll := !u.MFAEnabled
+	if enroll {
+		claims[mfaEnrollClaim] = true
+	}
+	jw, err := s.signToken(claims)
+	if err != nil {
+		s.log.Error().Err(err).Msg("create mfa challenge")
+		return nil, err
+	}
+	return &MFAChallenge{Token: jw, ExpiresAt: exp.Unix(), EnrollmentRequired: enroll}, nil
+}
+
+// newRecoveryCodes returns the configured number of recovery codes, formatted
+// as "xxxxx-xxxxx", together with their hashes.
+func (s *AuthService) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, s.cfg.MFA.RecoveryCodes)
+	hashes := make([]string, len(codes))
+	buf := make([]byte, recoveryCodeBytes)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			s.log.Error().Err(err).Msg("generate recovery code")
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = utils.HashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts recovery codes typed in either case and with
+// or without the separator.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/totp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mfaFixture struct {
+	svc     *AuthService
+	repo    *mocks.MockAuthRepository
+	tokens  *mocks.MockTokenRepository
+	mfaRepo *mocks.MockMFARepository
+	user    *model.User
+}
+
+func newMFAFixture(t *testing.T, ctrl *gomock.Controller, u *model.User) *mfaFixture {
+	t.Helper()
+	cfg, _ := setupRSA(t)
+	cfg.MFA = config.MFAConfig{Issuer: "e-commerce", ChallengeTTL: 5 * time.Minute, RecoveryCodes: 3}
+
+	hash, err := passhash.New(config.HashConfig{}).Hash("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	u.Password = hash
+	u.Email = "admin@example.com"
+	u.Status = model.UserStatusActive
+	u.Roles = []string{"admin"}
+
+	f := &mfaFixture{
+		repo:    mocks.NewMockAuthRepository(ctrl),
+		tokens:  mocks.NewMockTokenRepository(ctrl),
+		mfaRepo: mocks.NewMockMFARepository(ctrl),
+		user:    u,
+	}
+	f.svc = NewAuthService(f.repo, zerolog.Nop(), cfg, nil, f.tokens, nil, nil, newKeyRing(t, cfg), nil, nil, f.mfaRepo, nil)
+	f.repo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil).AnyTimes()
+	f.repo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).AnyTimes()
+	return f
+}
+
+// amrOf returns the amr claim of an access token issued by the fixture.
+func (f *mfaFixture) amrOf(t *testing.T, access string) []string {
+	t.Helper()
+	f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+	p, err := f.svc.VerifyAccessToken(context.Background(), access)
+	if err != nil {
+		t.Fatalf("failed to verify access token: %v", err)
+	}
+	return p.AMR
+}
+
+func TestAuthService_Login_MFAChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secret, _ := totp.GenerateSecret()
+	f := newMFAFixture(t, ctrl, &model.User{ID: uuid.New(), MFAEnabled: true})
+
+	res, err := f.svc.Login(context.Background(), f.user.Email, "password")
+	assert.NoError(t, err)
+	assert.Nil(t, res.Tokens, "при включенной 2FA токены не выдаются до проверки кода")
+	if !assert.NotNil(t, res.Challenge) {
+		return
+	}
+	assert.False(t, res.Challenge.EnrollmentRequired)
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+	f.mfaRepo.EXPECT().GetTOTP(gomock.Any(), f.user.ID).
+		Return(&model.TOTP{UserID: f.user.ID, Secret: secret, Confirmed: true}, nil)
+	f.mfaRepo.EXPECT().UseStep(gomock.Any(), f.user.ID, gomock.Any()).Return(nil)
+	f.tokens.EXPECT().RevokeTokenID(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	f.tokens.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	tokens, err := f.svc.VerifyMFA(context.Background(), res.Challenge.Token, code, "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, tokens) {
+		assert.Equal(t, []string{"pwd", "otp", "mfa"}, f.amrOf(t, tokens.AccessToken))
+	}
+}
+
+func TestAuthService_VerifyMFA_Rejections(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secret, _ := totp.GenerateSecret()
+	f := newMFAFixture(t, ctrl, &model.User{ID: uuid.New(), MFAEnabled: true})
+	res, err := f.svc.Login(context.Background(), f.user.Email, "password")
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+
+	t.Run("replayed code", func(t *testing.T) {
+		f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		f.mfaRepo.EXPECT().GetTOTP(gomock.Any(), f.user.ID).
+			Return(&model.TOTP{UserID: f.user.ID, Secret: secret, Confirmed: true}, nil)
+		f.mfaRepo.EXPECT().UseStep(gomock.Any(), f.user.ID, gomock.Any()).Return(AppErr.ErrNotFound)
+
+		_, err := f.svc.VerifyMFA(context.Background(), res.Challenge.Token, code, "")
+		assert.ErrorIs(t, err, AppErr.ErrInvalidMFACode, "повторно использованный код должен отклоняться")
+	})
+
+	t.Run("unknown recovery code", func(t *testing.T) {
+		f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		f.mfaRepo.EXPECT().UseRecoveryCode(gomock.Any(), f.user.ID, gomock.Any()).Return(AppErr.ErrNotFound)
+
+		_, err := f.svc.VerifyMFA(context.Background(), res.Challenge.Token, "", "aaaaa-bbbbb")
+		assert.ErrorIs(t, err, AppErr.ErrInvalidMFACode)
+	})
+
+	t.Run("used challenge", func(t *testing.T) {
+		f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(true, nil)
+
+		_, err := f.svc.VerifyMFA(context.Background(), res.Challenge.Token, code, "")
+		assert.ErrorIs(t, err, AppErr.ErrInvalidToken, "челлендж должен быть одноразовым")
+	})
+
+	t.Run("access token instead of challenge", func(t *testing.T) {
+		f.tokens.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		pair, err := f.svc.issueTokenPair(context.Background(), f.user, uuid.New(), grant{})
+		if err != nil {
+			t.Fatalf("failed to issue tokens: %v", err)
+		}
+
+		_, err = f.svc.VerifyMFA(context.Background(), pair.AccessToken, code, "")
+		assert.ErrorIs(t, err, AppErr.ErrInvalidToken)
+	})
+}
+
+func TestAuthService_VerifyMFA_RecoveryCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	f := newMFAFixture(t, ctrl, &model.User{ID: uuid.New(), MFAEnabled: true})
+	res, err := f.svc.Login(context.Background(), f.user.Email, "password")
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+	f.mfaRepo.EXPECT().
+		UseRecoveryCode(gomock.Any(), f.user.ID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string) error {
+			assert.NotContains(t, hash, "abcde", "в хранилище должен попадать только хеш кода")
+			return nil
+		})
+	f.tokens.EXPECT().RevokeTokenID(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	f.tokens.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	tokens, err := f.svc.VerifyMFA(context.Background(), res.Challenge.Token, "", "ABCDE-fghij")
+	assert.NoError(t, err)
+	if assert.NotNil(t, tokens) {
+		assert.Equal(t, []string{"pwd", "mfa"}, f.amrOf(t, tokens.AccessToken))
+	}
+}
+
+func TestAuthService_MFAEnrollment_RequiredByRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	f := newMFAFixture(t, ctrl, &model.User{ID: uuid.New(), MFARequired: true})
+
+	res, err := f.svc.Login(context.Background(), f.user.Email, "password")
+	assert.NoError(t, err)
+	if !assert.NotNil(t, res.Challenge) {
+		return
+	}
+	assert.True(t, res.Challenge.EnrollmentRequired, "пользователь без 2FA должен настроить ее при входе")
+
+	var secret string
+	f.tokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	f.mfaRepo.EXPECT().
+		StartTOTP(gomock.Any(), f.user.ID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, s string) error {
+			secret = s
+			return nil
+		})
+	enrollment, err := f.svc.StartChallengeEnrollment(context.Background(), res.Challenge.Token)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, secret, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	f.mfaRepo.EXPECT().GetTOTP(gomock.Any(), f.user.ID).
+		Return(&model.TOTP{UserID: f.user.ID, Secret: secret}, nil)
+	f.mfaRepo.EXPECT().
+		ConfirmTOTP(gomock.Any(), f.user.ID, gomock.Any(), gomock.Len(3)).
+		Return(nil)
+	f.tokens.EXPECT().RevokeTokenID(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	f.tokens.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	confirmed, err := f.svc.ConfirmChallengeEnrollment(context.Background(), res.Challenge.Token, code)
+	assert.NoError(t, err)
+	if assert.NotNil(t, confirmed) {
+		assert.Len(t, confirmed.RecoveryCodes, 3)
+		assert.Contains(t, f.amrOf(t, confirmed.Tokens.AccessToken), "mfa")
+	}
+}
+
+func TestAuthService_Refresh_MFARequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, privKey := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	userID := uuid.New()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub":   userID.String(),
+		"jti":   stored.ID.String(),
+		"typ":   "refresh",
+		"email": "admin@example.com",
+		"roles": []string{"admin"},
+		"amr":   []string{"pwd"},
+		"exp":   time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
+		"iat":   time.Now().Unix(),
+	})
+
+	mockTokens.EXPECT().GetRefreshToken(gomock.Any(), stored.ID).Return(stored, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), userID).
+		Return(&model.User{ID: userID, Status: model.UserStatusActive, MFARequired: true}, nil)
+
+	_, err := authService.Refresh(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, AppErr.ErrMFARequired, "сессия без второго фактора не должна продлеваться")
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+	GrantAuthorizationCode = "authorization_code"
+	// GrantTokenExchange is the token exchange grant of RFC 8693.
+	GrantTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// OAuth error codes from RFC 6749 section 5.2.
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthInvalidScope         = "invalid_scope"
+	// Authorization endpoint errors of section 4.1.2.1.
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthAccessDenied            = "access_denied"
+	OAuthServerError             = "server_error"
+	// OAuthTemporarilyUnavailable is borrowed from the authorization endpoint
+	// errors of section 4.1.2.1 for throttled sign-in attempts.
+	OAuthTemporarilyUnavailable = "temporarily_unavailable"
+	// OAuthInvalidTarget is the RFC 8693 error for audiences the client may
+	// not request.
+	OAuthInvalidTarget = "invalid_target"
+)
+
+// OAuthError is returned by Token for failures that have an RFC 6749 error
+// code. Any other error is a server error.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	Username     string
+	Password     string
+	RefreshToken string
+	// Code, RedirectURI and CodeVerifier belong to the authorization code
+	// grant.
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	// SubjectToken, SubjectTokenType, RequestedTokenType, ActorToken and
+	// Audience belong to the token exchange grant.
+	SubjectToken       string
+	SubjectTokenType   string
+	RequestedTokenType string
+	ActorToken         string
+	Audience           []string
+}
+
+// TokenResponse is the RFC 6749 section 5.1 access token response.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// IssuedTokenType is set by the token exchange grant, see RFC 8693
+	// section 2.2.1.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
+
+// Token is the OAuth 2.0 token endpoint. Every grant requires client
+// authentication, except that public clients identify themselves by their
+// id alone for the authorization code and refresh token grants. The grant
+// type must be enabled for the client and the requested scopes must be among
+// the client's allowed scopes.
+func (s *AuthService) Token(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	if req.GrantType == "" {
+		return nil, &OAuthError{OAuthInvalidRequest, "grant_type is required"}
+	}
+	var (
+		cli *user.Client
+		err error
+	)
+	if req.ClientSecret == "" && (req.GrantType == GrantAuthorizationCode || req.GrantType == GrantRefreshToken) {
+		cli, err = s.publicClient(ctx, req.ClientID)
+	} else {
+		cli, err = s.AuthenticateClient(ctx, req.ClientID, req.ClientSecret)
+	}
+	if err != nil {
+		if errors.Is(err, AppErr.ErrInvalidCredentials) {
+			return nil, &OAuthError{OAuthInvalidClient, "client authentication failed"}
+		}
+		return nil, err
+	}
+	switch req.GrantType {
+	case GrantClientCredentials, GrantPassword, GrantRefreshToken, GrantAuthorizationCode, GrantTokenExchange:
+	default:
+		return nil, &OAuthError{OAuthUnsupportedGrantType, "grant type " + req.GrantType + " is not supported"}
+	}
+	if !slices.Contains(cli.GrantTypes, req.GrantType) {
+		return nil, &OAuthError{OAuthUnauthorizedClient, "grant type " + req.GrantType + " is not allowed for this client"}
+	}
+
+	requested := ParseScope(req.Scope)
+	if !isSubset(requested, cli.AllowedScopes) {
+		return nil, &OAuthError{OAuthInvalidScope, "requested scope exceeds the scope granted to the client"}
+	}
+
+	switch req.GrantType {
+	case GrantClientCredentials:
+		return s.IssueClientToken(ctx, cli, requested)
+
+	case GrantPassword:
+		if req.Username == "" || req.Password == "" {
+			return nil, &OAuthError{OAuthInvalidRequest, "username and password are required"}
+		}
+		u, err := s.authenticateUser(ctx, req.Username, req.Password)
+		if err != nil {
+			return nil, toOAuthError(err)
+		}
+		// The password grant has no room for a second step.
+		if u.MFAEnabled || u.MFARequired {
+			return nil, toOAuthError(AppErr.ErrMFARequired)
+		}
+		scopes := requested
+		if len(scopes) == 0 {
+			scopes = cli.AllowedScopes
+		}
+		pair, err := s.issueTokenPair(ctx, u, uuid.New(), grant{clientID: cli.ID, scopes: scopes, amr: []string{amrPassword}})
+		if err != nil {
+			return nil, err
+		}
+		return s.pairResponse(pair), nil
+
+	case GrantAuthorizationCode:
+		pair, err := s.exchangeCode(ctx, cli, req)
+		if err != nil {
+			return nil, err
+		}
+		return s.pairResponse(pair), nil
+
+	case GrantTokenExchange:
+		return s.exchangeToken(ctx, cli, req, requested)
+
+	default:
+		if req.RefreshToken == "" {
+			return nil, &OAuthError{OAuthInvalidRequest, "refresh_token is required"}
+		}
+		pair, err := s.refresh(ctx, req.RefreshToken, cli.ID, requested)
+		if err != nil {
+			return nil, toOAuthError(err)
+		}
+		return s.pairResponse(pair), nil
+	}
+}
+
+// IssueClientToken implements the client_credentials grant for cli, which
+// has authenticated with its secret. All scopes of the client are granted if
+// none are requested.
+func (s *AuthService) IssueClientToken(ctx context.Context, cli *user.Client, requested []string) (*TokenResponse, error) {
+	if !slices.Contains(cli.GrantTypes, GrantClientCredentials) {
+		return nil, &OAuthError{OAuthUnauthorizedClient, "grant type " + GrantClientCredentials + " is not allowed for this client"}
+	}
+	if !isSubset(requested, cli.AllowedScopes) {
+		return nil, &OAuthError{OAuthInvalidScope, "requested scope exceeds the scope granted to the client"}
+	}
+	scopes := requested
+	if len(scopes) == 0 {
+		scopes = cli.AllowedScopes
+	}
+	tok, err := s.ClientToken(cli, scopes)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditClientTokenIssued,
+		Actor:   cli.ID,
+		Subject: cli.ID,
+		Details: map[string]string{"scope": strings.Join(scopes, " ")},
+	})
+	return &TokenResponse{
+		AccessToken: tok.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.JWT.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+func (s *AuthService) pairResponse(pair *TokenPair) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.JWT.AccessTokenTTL.Seconds()),
+		RefreshToken: pair.RefreshToken,
+		Scope:        pair.Scope,
+		IDToken:      pair.IDToken,
+	}
+}
+
+// toOAuthError maps errors of the user-facing flows onto RFC 6749 codes.
+func toOAuthError(err error) error {
+	switch {
+	case errors.Is(err, AppErr.ErrInvalidCredentials):
+		return &OAuthError{OAuthInvalidGrant, "invalid resource owner credentials"}
+	case errors.Is(err, AppErr.ErrUserDisabled):
+		return &OAuthError{OAuthInvalidGrant, "user account is disabled"}
+	case errors.Is(err, AppErr.ErrEmailNotVerified):
+		return &OAuthError{OAuthInvalidGrant, "user email is not verified"}
+	case errors.Is(err, AppErr.ErrMFARequired):
+		return &OAuthError{OAuthInvalidGrant, "user must sign in with a second factor"}
+	case errors.Is(err, AppErr.ErrInvalidToken), errors.Is(err, AppErr.ErrTokenReused):
+		return &OAuthError{OAuthInvalidGrant, "refresh token is invalid, expired or revoked"}
+	case errors.Is(err, AppErr.ErrInvalidScope):
+		return &OAuthError{OAuthInvalidScope, "requested scope exceeds the original grant"}
+	default:
+		return err
+	}
+}
+
+// ParseScope splits a space-delimited scope string, dropping duplicates.
+func ParseScope(scope string) []string {
+	fields := strings.Fields(scope)
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func isSubset(sub, set []string) bool {
+	for _, v := range sub {
+		if !slices.Contains(set, v) {
+			return false
+		}
+	}
+	return true
+}
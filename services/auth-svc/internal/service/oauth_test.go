@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, secret string) *model.Client {
+	t.Helper()
+	hash, err := passhash.New(config.HashConfig{}).Hash(secret)
+	if err != nil {
+		t.Fatalf("failed to hash secret: %v", err)
+	}
+	return &model.Client{
+		ID:            "cart-svc",
+		Secret:        hash,
+		Roles:         []string{"service"},
+		Status:        model.ClientStatusActive,
+		AllowedScopes: []string{"catalog:read", "cart:write"},
+		GrantTypes:    []string{GrantClientCredentials},
+	}
+}
+
+func TestAuthService_Token_ClientCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	cfg, _ := setupRSA(t)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, mockClient, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	mockClient.EXPECT().GetById(gomock.Any(), "cart-svc").Return(newTestClient(t, "secret"), nil)
+
+	resp, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantClientCredentials,
+		ClientID:     "cart-svc",
+		ClientSecret: "secret",
+		Scope:        "catalog:read",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, "catalog:read", resp.Scope)
+	assert.Empty(t, resp.RefreshToken)
+
+	claims, err := authService.parseToken(resp.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "catalog:read", claims["scope"])
+	assert.Equal(t, "client", claims["typ"])
+}
+
+func TestAuthService_Token_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  TokenRequest
+		code string
+	}{
+		{
+			name: "wrong secret",
+			req:  TokenRequest{GrantType: GrantClientCredentials, ClientID: "cart-svc", ClientSecret: "wrong"},
+			code: OAuthInvalidClient,
+		},
+		{
+			name: "grant not allowed for client",
+			req:  TokenRequest{GrantType: GrantPassword, ClientID: "cart-svc", ClientSecret: "secret", Username: "a@b.c", Password: "pwd"},
+			code: OAuthUnauthorizedClient,
+		},
+		{
+			name: "unknown grant",
+			req:  TokenRequest{GrantType: "implicit", ClientID: "cart-svc", ClientSecret: "secret"},
+			code: OAuthUnsupportedGrantType,
+		},
+		{
+			name: "scope outside of client scopes",
+			req:  TokenRequest{GrantType: GrantClientCredentials, ClientID: "cart-svc", ClientSecret: "secret", Scope: "catalog:write"},
+			code: OAuthInvalidScope,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := mocks.NewMockClientRepository(ctrl)
+			cfg, _ := setupRSA(t)
+			authService := NewAuthService(nil, zerolog.Nop(), cfg, mockClient, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+			mockClient.EXPECT().GetById(gomock.Any(), "cart-svc").Return(newTestClient(t, "secret"), nil)
+
+			_, err := authService.Token(context.Background(), tt.req)
+			var oauthErr *OAuthError
+			if assert.ErrorAs(t, err, &oauthErr) {
+				assert.Equal(t, tt.code, oauthErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// OpenID Connect scopes. openid asks for an ID token, the others decide
+// which profile claims the ID token and the userinfo endpoint contain.
+const (
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+	ScopeRoles   = "roles"
+)
+
+// OIDCScopes are the scopes the provider understands.
+var OIDCScopes = []string{ScopeOpenID, ScopeEmail, ScopeProfile, ScopeRoles}
+
+// OIDCClaims are the claims ID tokens and userinfo responses may contain.
+var OIDCClaims = []string{
+	"sub", "iss", "aud", "exp", "iat", authTimeClaim, "nonce", amrClaim, sessionClaim,
+	"email", "email_verified", "preferred_username", "roles",
+}
+
+// UserInfo returns the claims about the signed-in user that the scopes of
+// their access token allow. The token must have been granted the openid
+// scope, otherwise ErrInvalidScope is returned.
+func (s *AuthService) UserInfo(ctx context.Context, p *user.Principal) (map[string]any, error) {
+	if !slices.Contains(p.Scopes, ScopeOpenID) {
+		return nil, AppErr.ErrInvalidScope
+	}
+	id, err := uuid.Parse(p.Subject)
+	if err != nil {
+		return nil, AppErr.ErrInvalidToken
+	}
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return nil, err
+	}
+	if u.Status != user.UserStatusActive {
+		return nil, AppErr.ErrUserDisabled
+	}
+	info := profileClaims(u, p.Scopes)
+	info["sub"] = u.ID.String()
+	return info, nil
+}
+
+// createIDTokenFor returns an ID token for u if g grants the openid scope, and
+// "" otherwise. Its audience is the client the tokens are issued to.
+func (s *AuthService) createIDTokenFor(u *user.User, g grant) (string, error) {
+	if g.clientID == "" || !slices.Contains(g.scopes, ScopeOpenID) {
+		return "", nil
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": u.ID.String(),
+		"aud": g.clientID,
+		"exp": now.Add(s.cfg.JWT.AccessTokenTTL).Unix(),
+		"iat": now.Unix(),
+	}
+	for k, v := range profileClaims(u, g.scopes) {
+		claims[k] = v
+	}
+	if g.authTime != 0 {
+		claims[authTimeClaim] = g.authTime
+	}
+	if len(g.amr) > 0 {
+		claims[amrClaim] = g.amr
+	}
+	if g.sessionID != uuid.Nil {
+		claims[sessionClaim] = g.sessionID.String()
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	jw, err := s.signToken(claims)
+	if err != nil {
+		s.log.Error().Err(err).Msg("create id token")
+		return "", err
+	}
+	return jw, nil
+}
+
+// profileClaims returns the claims about u released by scopes. The service
+// keeps no names, so the profile scope only yields preferred_username.
+func profileClaims(u *user.User, scopes []string) map[string]any {
+	claims := map[string]any{}
+	if slices.Contains(scopes, ScopeEmail) {
+		claims["email"] = u.Email
+		claims["email_verified"] = u.EmailVerified
+	}
+	if slices.Contains(scopes, ScopeProfile) {
+		claims["preferred_username"] = u.Email
+	}
+	if slices.Contains(scopes, ScopeRoles) {
+		roles := u.Roles
+		if roles == nil {
+			roles = []string{}
+		}
+		claims["roles"] = roles
+	}
+	return claims
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssuer = "https://auth.example.com"
+
+func setupOIDC(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, _ := setupRSA(t)
+	cfg.JWT.Issuer = testIssuer
+	cfg.JWT.Audience = "e-commerce"
+	return cfg
+}
+
+// parseIDToken verifies an ID token the way a relying party would.
+func parseIDToken(t *testing.T, svc *AuthService, raw, clientID string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return svc.keys.PublicKey(kid, token.Method.Alg())
+	}, jwt.WithIssuer(testIssuer), jwt.WithAudience(clientID), jwt.WithExpirationRequired())
+	if err != nil {
+		t.Fatalf("invalid id token: %v", err)
+	}
+	return claims
+}
+
+func TestAuthService_Token_PasswordGrantIDToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := setupOIDC(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	cli := newTestClient(t, "secret")
+	cli.ID = "storefront"
+	cli.AllowedScopes = []string{ScopeOpenID, ScopeEmail, ScopeRoles}
+	cli.GrantTypes = []string{GrantPassword}
+	hash, _ := passhash.New(config.HashConfig{}).Hash("password")
+	u := &model.User{
+		ID:            uuid.New(),
+		Email:         "user@example.com",
+		Password:      hash,
+		Status:        model.UserStatusActive,
+		Roles:         []string{"user"},
+		EmailVerified: true,
+	}
+	mockClient.EXPECT().GetById(gomock.Any(), "storefront").Return(cli, nil)
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	mockTokens.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	resp, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantPassword,
+		ClientID:     "storefront",
+		ClientSecret: "secret",
+		Scope:        "openid email",
+		Username:     u.Email,
+		Password:     "password",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NotEmpty(t, resp.IDToken, "при scope openid должен выдаваться id_token") {
+		return
+	}
+
+	id := parseIDToken(t, authService, resp.IDToken, "storefront")
+	assert.Equal(t, u.ID.String(), id["sub"])
+	assert.Equal(t, u.Email, id["email"])
+	assert.Equal(t, true, id["email_verified"])
+	assert.NotContains(t, id, "roles", "roles выдаются только при scope roles")
+	assert.NotZero(t, id[authTimeClaim])
+
+	access, err := authService.parseToken(resp.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, testIssuer, access["iss"])
+	assert.Equal(t, "e-commerce", access["aud"])
+
+	_, err = authService.parseToken(resp.IDToken)
+	assert.Error(t, err, "id_token не должен приниматься вместо access токена")
+}
+
+func TestAuthService_Token_NoIDTokenWithoutOpenID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := setupOIDC(t)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, mockClient, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	cli := newTestClient(t, "secret")
+	cli.AllowedScopes = append(cli.AllowedScopes, ScopeOpenID)
+	mockClient.EXPECT().GetById(gomock.Any(), "cart-svc").Return(cli, nil)
+
+	resp, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:    GrantClientCredentials,
+		ClientID:     "cart-svc",
+		ClientSecret: "secret",
+		Scope:        "openid catalog:read",
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.IDToken, "у клиента без пользователя нет id_token")
+}
+
+func TestAuthService_ParseToken_RejectsForeignIssuer(t *testing.T) {
+	cfg := setupOIDC(t)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	now := time.Now()
+	claims := jwt.MapClaims{"sub": uuid.NewString(), "typ": "access", "exp": now.Add(time.Minute).Unix()}
+	raw, err := authService.signToken(claims)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	_, err = authService.parseToken(raw)
+	assert.NoError(t, err)
+
+	other := setupOIDC(t)
+	other.JWT.Issuer = "https://evil.example.com"
+	foreign := NewAuthService(nil, zerolog.Nop(), other, nil, nil, nil, nil, authService.keys, nil, nil, nil, nil)
+	raw, err = foreign.signToken(jwt.MapClaims{"sub": uuid.NewString(), "typ": "access", "exp": now.Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	_, err = authService.parseToken(raw)
+	assert.Error(t, err, "токен другого издателя должен отклоняться")
+}
+
+func TestAuthService_UserInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := setupOIDC(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Status: model.UserStatusActive, Roles: []string{"user"}}
+
+	_, err := authService.UserInfo(context.Background(), &model.Principal{Subject: u.ID.String(), Scopes: []string{ScopeEmail}})
+	assert.ErrorIs(t, err, AppErr.ErrInvalidScope, "userinfo требует scope openid")
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+	info, err := authService.UserInfo(context.Background(), &model.Principal{
+		Subject: u.ID.String(),
+		Scopes:  []string{ScopeOpenID, ScopeProfile, ScopeRoles},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"sub":                u.ID.String(),
+		"preferred_username": u.Email,
+		"roles":              []string{"user"},
+	}, info)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passpolicy"
+	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
+	"github.com/rs/zerolog"
+)
+
+// previousPasswords returns the hashes of the passwords of u the policy
+// forbids to reuse, the current one first.
+func previousPasswords(ctx context.Context, repo repository.AuthRepository, policy *passpolicy.Policy, u *user.User) ([]string, error) {
+	if policy.History() == 0 {
+		return nil, nil
+	}
+	previous := []string{u.Password}
+	if policy.History() == 1 {
+		return previous, nil
+	}
+	older, err := repo.PasswordHistory(ctx, u.ID, policy.History()-1)
+	if err != nil {
+		return nil, err
+	}
+	return append(previous, older...), nil
+}
+
+// rememberPassword records the replaced password hash of u, so that it is
+// not reused. A failure is logged only: the password has already changed.
+func rememberPassword(ctx context.Context, repo repository.AuthRepository, policy *passpolicy.Policy, log zerolog.Logger, u *user.User) {
+	if policy.History() <= 1 {
+		return
+	}
+	if err := repo.AddPasswordHistory(ctx, u.ID, u.Password, policy.History()-1); err != nil {
+		log.Error().Err(err).Str("user_id", u.ID.String()).Msg("record password history")
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passpolicy"
+	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	resetTokenBytes = 32
+	mailSendTimeout = 30 * time.Second
+)
+
+// PasswordService implements the forgotten password flow.
+type PasswordService struct {
+	repo   repository.AuthRepository
+	resets repository.PasswordResetRepository
+	mailer mailer.Mailer
+	guard  *throttle.Guard
+	log    zerolog.Logger
+	cfg    *config.Config
+	hasher *passhash.Hasher
+	policy *passpolicy.Policy
+}
+
+// NewPasswordService returns the service. guard limits reset requests per
+// account and IP address; it should not share counters with the sign-in
+// guard, see throttle.WithPrefix.
+func NewPasswordService(repo repository.AuthRepository, resets repository.PasswordResetRepository,
+	m mailer.Mailer, guard *throttle.Guard, log zerolog.Logger, cfg *config.Config) *PasswordService {
+	hasher := passhash.New(cfg.Hash)
+	return &PasswordService{repo: repo, resets: resets, mailer: m, guard: guard, log: log, cfg: cfg, hasher: hasher,
+		policy: passpolicy.New(cfg.Password, hasher, log)}
+}
+
+// RunCleanup periodically deletes expired reset tokens until ctx is
+// cancelled.
+func (s *PasswordService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.resets.DeleteExpired(ctx)
+			if err != nil {
+				s.log.Error().Err(err).Msg("delete expired password reset tokens")
+				continue
+			}
+			s.log.Debug().Int64("deleted", n).Msg("expired password reset tokens cleaned up")
+		}
+	}
+}
+
+// Forgot mails a single-use reset link to the user. Every request counts
+// against the limits of the account and of the client IP, registered or not,
+// and fails with an *AppErr.LockoutError once one is reached. The account is
+// looked up and mailed in the background, so that neither the answer nor its
+// timing tells whether the address is registered.
+func (s *PasswordService) Forgot(ctx context.Context, email string) error {
+	ip := requestinfo.ClientIP(ctx)
+	keys := []throttle.Key{throttle.Account(email), throttle.IP(ip)}
+	if err := s.guard.Check(ctx, keys...); err != nil {
+		return err
+	}
+	s.guard.Fail(ctx, ip, keys...)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		s.sendResetLink(ctx, email)
+	}()
+	return nil
+}
+
+// sendResetLink issues a reset token for the account with the given email
+// and mails the link. Unknown and disabled accounts are silently ignored.
+func (s *PasswordService) sendResetLink(ctx context.Context, email string) {
+	u, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Err(err).Msg("get user by email")
+		}
+		return
+	}
+	if u.Status != user.UserStatusActive {
+		return
+	}
+
+	token, err := utils.GenerateSecret(resetTokenBytes)
+	if err != nil {
+		s.log.Error().Err(err).Msg("generate reset token")
+		return
+	}
+	expiresAt := time.Now().Add(s.cfg.Password.ResetTTL)
+	if err := s.resets.Create(ctx, u.ID, utils.HashToken(token), expiresAt); err != nil {
+		s.log.Error().Err(err).Msg("store reset token")
+		return
+	}
+
+	msg := mailer.Message{
+		To:      u.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Чтобы задать новый пароль, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует до %s. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+			s.resetLink(token), expiresAt.UTC().Format("02.01.2006 15:04 MST")),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		s.log.Error().Err(err).Str("user_id", u.ID.String()).Msg("send password reset mail")
+		return
+	}
+	s.log.Info().Str("user_id", u.ID.String()).Msg("password reset requested")
+}
+
+// Reset sets a new password using a token from Forgot. The token is consumed
+// and every session of the user is revoked. Unknown, used and expired tokens
+// yield ErrInvalidToken, and passwords breaking the policy a
+// *passpolicy.Error, in which case the token can be used again.
+func (s *PasswordService) Reset(ctx context.Context, token, password string) error {
+	tokenHash := utils.HashToken(token)
+	userID, err := s.resets.Lookup(ctx, tokenHash)
+	if err != nil {
+		if !errors.Is(err, AppErr.ErrInvalidToken) {
+			s.log.Error().Err(err).Msg("look up reset token")
+		}
+		return err
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return err
+	}
+	previous, err := previousPasswords(ctx, s.repo, s.policy, u)
+	if err != nil {
+		s.log.Error().Err(err).Msg("get password history")
+		return err
+	}
+	if err := s.policy.Check(password, u.Email, previous); err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		s.log.Error().Err(err).Msg("hash pwd")
+		return err
+	}
+	if _, err := s.resets.ResetPassword(ctx, tokenHash, hash); err != nil {
+		if !errors.Is(err, AppErr.ErrInvalidToken) {
+			s.log.Error().Err(err).Msg("reset password")
+		}
+		return err
+	}
+	rememberPassword(ctx, s.repo, s.policy, s.log, u)
+	s.log.Info().Str("user_id", userID.String()).Msg("password reset, sessions revoked")
+	return nil
+}
+
+func (s *PasswordService) resetLink(token string) string {
+	u, err := url.Parse(s.cfg.Password.ResetURL)
+	if err != nil {
+		return s.cfg.Password.ResetURL + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passpolicy"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/utils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMailer struct {
+	sent chan mailer.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func TestPasswordService_Forgot_SendsSingleUseLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.Password.ResetTTL = time.Hour
+	cfg.Password.ResetURL = "https://shop.example.com/reset"
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockResets := mocks.NewMockPasswordResetRepository(ctrl)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	svc := NewPasswordService(mockRepo, mockResets, mail, nil, zerolog.Nop(), cfg)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	var storedHash string
+	mockResets.EXPECT().
+		Create(gomock.Any(), u.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string, expiresAt time.Time) error {
+			storedHash = hash
+			assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+			return nil
+		})
+
+	assert.NoError(t, svc.Forgot(context.Background(), u.Email))
+
+	select {
+	case msg := <-mail.sent:
+		assert.Equal(t, u.Email, msg.To)
+		link := regexp.MustCompile(`https://shop\.example\.com/reset\?token=\S+`).FindString(msg.Body)
+		assert.NotEmpty(t, link, "письмо должно содержать ссылку для сброса")
+		parsed, err := url.Parse(link)
+		assert.NoError(t, err)
+		token := parsed.Query().Get("token")
+		assert.Equal(t, utils.HashToken(token), storedHash, "в базе должен храниться хеш токена")
+	case <-time.After(time.Second):
+		t.Fatal("письмо не было отправлено")
+	}
+}
+
+func TestPasswordService_Forgot_UnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	svc := NewPasswordService(mockRepo, mocks.NewMockPasswordResetRepository(ctrl), mail, nil, zerolog.Nop(), cfg)
+
+	looked := make(chan struct{})
+	mockRepo.EXPECT().
+		GetByEmail(gomock.Any(), "nobody@example.com").
+		DoAndReturn(func(context.Context, string) (*model.User, error) {
+			close(looked)
+			return nil, AppErr.ErrNotFound
+		})
+
+	assert.NoError(t, svc.Forgot(context.Background(), "nobody@example.com"),
+		"ответ не должен выдавать, зарегистрирован ли email")
+	select {
+	case <-looked:
+	case <-time.After(time.Second):
+		t.Fatal("адрес не был проверен")
+	}
+	assert.Empty(t, mail.sent)
+}
+
+func TestPasswordService_Forgot_Throttled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, AppErr.ErrNotFound).AnyTimes()
+	guard := throttle.NewGuard(newLockStore(), nil, testThrottleConfig(), zerolog.Nop())
+	svc := NewPasswordService(mockRepo, nil, nil, guard, zerolog.Nop(), cfg)
+	ctx := requestinfo.WithClientIP(context.Background(), "10.0.0.1")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, svc.Forgot(ctx, "nobody@example.com"))
+	}
+	var lockErr *AppErr.LockoutError
+	assert.ErrorAs(t, svc.Forgot(ctx, "nobody@example.com"), &lockErr,
+		"лимит должен действовать и для незарегистрированных адресов")
+	assert.NoError(t, svc.Forgot(requestinfo.WithClientIP(context.Background(), "10.0.0.2"), "other@example.com"))
+}
+
+func TestPasswordService_Reset_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockResets := mocks.NewMockPasswordResetRepository(ctrl)
+	svc := NewPasswordService(nil, mockResets, nil, nil, zerolog.Nop(), cfg)
+
+	mockResets.EXPECT().
+		Lookup(gomock.Any(), utils.HashToken("used-token")).
+		Return(uuid.Nil, AppErr.ErrInvalidToken)
+
+	err := svc.Reset(context.Background(), "used-token", "newPassword1")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidToken)
+}
+
+func TestPasswordService_Reset_AppliesPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.Password.MinLength = 8
+	cfg.Password.History = 3
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockResets := mocks.NewMockPasswordResetRepository(ctrl)
+	svc := NewPasswordService(mockRepo, mockResets, nil, nil, zerolog.Nop(), cfg)
+
+	hasher := passhash.New(cfg.Hash)
+	current, _ := hasher.Hash("currentPassword1")
+	older, _ := hasher.Hash("olderPassword1")
+	u := &model.User{ID: uuid.New(), Email: "buyer@example.com", Password: current, Status: model.UserStatusActive}
+	tokenHash := utils.HashToken("reset-token")
+
+	mockResets.EXPECT().Lookup(gomock.Any(), tokenHash).Return(u.ID, nil).Times(3)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).Times(3)
+	mockRepo.EXPECT().PasswordHistory(gomock.Any(), u.ID, 2).Return([]string{older}, nil).Times(3)
+
+	var pe *passpolicy.Error
+	err := svc.Reset(context.Background(), "reset-token", "olderPassword1")
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, []passpolicy.Violation{{Rule: passpolicy.RuleReused, Limit: 3}}, pe.Violations)
+	assert.ErrorIs(t, err, AppErr.ErrWeakPassword)
+
+	err = svc.Reset(context.Background(), "reset-token", "buyer")
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, []passpolicy.Violation{
+		{Rule: passpolicy.RuleMinLength, Limit: 8},
+		{Rule: passpolicy.RuleContainsEmail},
+	}, pe.Violations)
+
+	mockResets.EXPECT().
+		ResetPassword(gomock.Any(), tokenHash, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, hash string) (uuid.UUID, error) {
+			ok, _ := hasher.Verify("freshPassword1", hash)
+			assert.True(t, ok, "должен сохраняться хеш нового пароля")
+			return u.ID, nil
+		})
+	mockRepo.EXPECT().AddPasswordHistory(gomock.Any(), u.ID, current, 2).Return(nil)
+	assert.NoError(t, svc.Reset(context.Background(), "reset-token", "freshPassword1"))
+}
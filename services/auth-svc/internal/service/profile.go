@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/throttle"
+)
+
+const (
+	// tokenTypeEmailChange marks the signed tokens of links confirming a
+	// new email address.
+	tokenTypeEmailChange = "email_change"
+	newEmailClaim        = "new_email"
+)
+
+// Profile returns the account of the signed-in user.
+func (s *AuthService) Profile(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ChangePassword sets a new password after checking the current one, and
+// signs the user out of every session but sessionID. Access tokens already
+// issued to the other sessions stay valid until they expire. New passwords
+// breaking the policy yield a *passpolicy.Error.
+func (s *AuthService) ChangePassword(ctx context.Context, id, sessionID uuid.UUID, current, next string) error {
+	u, err := s.confirmPassword(ctx, id, current)
+	if err != nil {
+		return err
+	}
+	previous, err := previousPasswords(ctx, s.repo, s.policy, u)
+	if err != nil {
+		s.log.Error().Err(err).Msg("get password history")
+		return err
+	}
+	if err := s.policy.Check(next, u.Email, previous); err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(next)
+	if err != nil {
+		s.log.Error().Err(err).Msg("hash pwd")
+		return err
+	}
+	if err := s.repo.ChangePassword(ctx, id, u.Password, hash, sessionID); err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			// The password changed since it was checked.
+			return AppErr.ErrInvalidCredentials
+		}
+		s.log.Error().Err(err).Msg("change password")
+		return err
+	}
+	rememberPassword(ctx, s.repo, s.policy, s.log, u)
+	s.log.Info().Str("user_id", id.String()).Msg("password changed, other sessions revoked")
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditPasswordChanged, Actor: id.String(), Subject: id.String()})
+	return nil
+}
+
+// ChangeEmail mails a link confirming newEmail to that address, after
+// checking the password of the user. The email only changes once the link
+// is followed, see VerifyEmail. The current address is told about the
+// request, so that a hijacked session cannot take the account over unnoticed.
+func (s *AuthService) ChangeEmail(ctx context.Context, id uuid.UUID, password, newEmail string) error {
+	u, err := s.confirmPassword(ctx, id, password)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(u.Email, newEmail) {
+		return AppErr.ErrEmailUnchanged
+	}
+	if _, err := s.repo.GetByEmail(ctx, newEmail); err == nil {
+		return AppErr.ErrUserAlreadyExists
+	} else if !errors.Is(err, AppErr.ErrNotFound) {
+		s.log.Error().Err(err).Msg("get user by email")
+		return err
+	}
+
+	token, expiresAt, err := s.createEmailChangeToken(u, newEmail)
+	if err != nil {
+		return err
+	}
+	s.sendInBackground(u.ID, mailer.Message{
+		To:      newEmail,
+		Subject: "Подтверждение нового email",
+		Body: fmt.Sprintf("Чтобы сменить адрес электронной почты учетной записи на этот, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует до %s. Если вы не запрашивали смену адреса, просто проигнорируйте это письмо.\n",
+			s.verificationLink(token), expiresAt.UTC().Format("02.01.2006 15:04 MST")),
+	})
+	s.sendInBackground(u.ID, mailer.Message{
+		To:      u.Email,
+		Subject: "Смена email",
+		Body: fmt.Sprintf("Запрошена смена адреса электронной почты вашей учетной записи на %s. "+
+			"Адрес изменится только после перехода по ссылке из письма, отправленного на новый адрес.\n\n"+
+			"Если это были не вы, смените пароль и завершите все сеансы.\n", newEmail),
+	})
+	s.log.Info().Str("user_id", u.ID.String()).Msg("email change requested")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditEmailChangeRequested,
+		Actor:   u.ID.String(),
+		Subject: u.ID.String(),
+		Details: map[string]string{"email": u.Email, newEmailClaim: newEmail},
+	})
+	return nil
+}
+
+// confirmPassword returns the active user id after checking its password.
+// Wrong passwords are throttled like failed sign-ins, so that a stolen
+// access token cannot be used to guess the password.
+func (s *AuthService) confirmPassword(ctx context.Context, id uuid.UUID, password string) (*user.User, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if !errors.Is(err, AppErr.ErrNotFound) {
+			s.log.Error().Err(err).Msg("get user by id")
+		}
+		return nil, err
+	}
+	if u.Status != user.UserStatusActive {
+		return nil, AppErr.ErrUserDisabled
+	}
+
+	ip := requestinfo.ClientIP(ctx)
+	account := throttle.Account(u.Email)
+	if err := s.guard.Check(ctx, account, throttle.IP(ip)); err != nil {
+		return nil, err
+	}
+	if match, _ := s.hasher.Verify(password, u.Password); !match {
+		s.guard.Fail(ctx, ip, account, throttle.IP(ip))
+		return nil, AppErr.ErrInvalidCredentials
+	}
+	s.guard.Succeed(ctx, account)
+	return u, nil
+}
+
+// confirmEmailChange applies an email change from a token signed by
+// createEmailChangeToken. Tokens for an address the user no longer has, or
+// for an address taken in the meantime, yield ErrInvalidToken.
+func (s *AuthService) confirmEmailChange(ctx context.Context, sub uuid.UUID, claims jwt.MapClaims) error {
+	oldEmail, newEmail := stringClaim(claims, "email"), stringClaim(claims, newEmailClaim)
+	if oldEmail == "" || newEmail == "" {
+		return AppErr.ErrInvalidToken
+	}
+	if err := s.repo.ChangeEmail(ctx, sub, oldEmail, newEmail); err != nil {
+		switch {
+		case errors.Is(err, AppErr.ErrNotFound):
+			return AppErr.ErrInvalidToken
+		case errors.Is(err, AppErr.ErrUserAlreadyExists):
+			s.log.Warn().Str("user_id", sub.String()).Msg("new email taken before confirmation")
+			return AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("change email")
+		return err
+	}
+	s.log.Info().Str("user_id", sub.String()).Msg("email changed")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditEmailChanged,
+		Actor:   sub.String(),
+		Subject: sub.String(),
+		Details: map[string]string{"email": oldEmail, newEmailClaim: newEmail},
+	})
+	return nil
+}
+
+// createEmailChangeToken signs a token binding the user to both its current
+// and its new email, so that a link stops working once the address changes.
+func (s *AuthService) createEmailChangeToken(u *user.User, newEmail string) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(s.cfg.Verify.TTL)
+	claims := jwt.MapClaims{
+		"sub":         u.ID,
+		"jti":         uuid.NewString(),
+		"typ":         tokenTypeEmailChange,
+		"email":       u.Email,
+		newEmailClaim: newEmail,
+		"exp":         exp.Unix(),
+		"iat":         now.Unix(),
+	}
+	jw, err := s.signToken(claims)
+	if err != nil {
+		s.log.Error().Err(err).Msg("create email change token")
+		return "", time.Time{}, err
+	}
+	return jw, exp, nil
+}
+
+func (s *AuthService) sendInBackground(userID uuid.UUID, msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.log.Error().Err(err).Str("user_id", userID.String()).Str("subject", msg.Subject).Msg("send mail")
+		}
+	}()
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	hasher := passhash.New(config.HashConfig{})
+	hash, _ := hasher.Hash("password")
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: hash, Status: model.UserStatusActive}
+	session := uuid.New()
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).Times(2)
+
+	err := authService.ChangePassword(context.Background(), u.ID, session, "wrong", "new-password")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidCredentials)
+
+	mockRepo.
+		EXPECT().
+		ChangePassword(gomock.Any(), u.ID, hash, gomock.Any(), session).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _, newHash string, _ uuid.UUID) error {
+			ok, _ := hasher.Verify("new-password", newHash)
+			assert.True(t, ok, "должен сохраняться хеш нового пароля")
+			return nil
+		})
+	assert.NoError(t, authService.ChangePassword(context.Background(), u.ID, session, "password", "new-password"))
+}
+
+func TestAuthService_ChangePassword_ReusedPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.Password.History = 1
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	hash, _ := passhash.New(cfg.Hash).Hash("password")
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: hash, Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+
+	err := authService.ChangePassword(context.Background(), u.ID, uuid.New(), "password", "password")
+	assert.ErrorIs(t, err, AppErr.ErrWeakPassword, "текущий пароль нельзя установить повторно")
+}
+
+func TestAuthService_ChangeEmail_ConfirmedByLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.Verify.TTL = time.Hour
+	cfg.Verify.URL = "https://shop.example.com/verify"
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 2)}
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), mail, nil, nil, nil)
+
+	hash, _ := passhash.New(config.HashConfig{}).Hash("password")
+	u := &model.User{ID: uuid.New(), Email: "old@example.com", Password: hash, Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).Times(3)
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "taken@example.com").Return(&model.User{ID: uuid.New()}, nil)
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(nil, AppErr.ErrNotFound)
+
+	err := authService.ChangeEmail(context.Background(), u.ID, "password", "OLD@example.com")
+	assert.ErrorIs(t, err, AppErr.ErrEmailUnchanged)
+	err = authService.ChangeEmail(context.Background(), u.ID, "password", "taken@example.com")
+	assert.ErrorIs(t, err, AppErr.ErrUserAlreadyExists)
+	if !assert.NoError(t, authService.ChangeEmail(context.Background(), u.ID, "password", "new@example.com")) {
+		return
+	}
+
+	var token string
+	notified := false
+	for range 2 {
+		select {
+		case msg := <-mail.sent:
+			switch msg.To {
+			case "new@example.com":
+				link := regexp.MustCompile(`https://shop\.example\.com/verify\?token=\S+`).FindString(msg.Body)
+				parsed, err := url.Parse(link)
+				assert.NoError(t, err)
+				token = parsed.Query().Get("token")
+			case u.Email:
+				notified = true
+				assert.Contains(t, msg.Body, "new@example.com")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("письмо не было отправлено")
+		}
+	}
+	assert.True(t, notified, "текущий адрес должен получить уведомление")
+	if !assert.NotEmpty(t, token, "письмо на новый адрес должно содержать ссылку") {
+		return
+	}
+
+	mockRepo.EXPECT().ChangeEmail(gomock.Any(), u.ID, u.Email, "new@example.com").Return(nil)
+	assert.NoError(t, authService.VerifyEmail(context.Background(), token))
+
+	// The email changed again after the link was sent.
+	mockRepo.EXPECT().ChangeEmail(gomock.Any(), u.ID, u.Email, "new@example.com").Return(AppErr.ErrNotFound)
+	assert.ErrorIs(t, authService.VerifyEmail(context.Background(), token), AppErr.ErrInvalidToken)
+}
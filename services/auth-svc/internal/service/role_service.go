@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	repository "github.com/oidiral/e-commerce/services/auth-svc/internal/repository"
+	"github.com/rs/zerolog"
+)
+
+var (
+	roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	// permissionPattern accepts colon-separated segments such as
+	// catalog:write or cart:read:any.
+	permissionPattern = regexp.MustCompile(`^[a-z0-9_-]+(:[a-z0-9_*-]+){0,3}$`)
+)
+
+// protectedRoles cannot be deleted: registration assigns "user" and the
+// admin API itself requires "admin".
+var protectedRoles = map[string]bool{
+	"admin": true,
+	"user":  true,
+}
+
+// RoleService manages roles, their permissions and who holds them. actor is
+// the subject of the admin making a change and is recorded with it.
+type RoleService struct {
+	repo  repository.RoleRepository
+	log   zerolog.Logger
+	audit *audit.Log
+}
+
+func NewRoleService(repo repository.RoleRepository, log zerolog.Logger, auditLog *audit.Log) *RoleService {
+	return &RoleService{repo: repo, log: log, audit: auditLog}
+}
+
+func (s *RoleService) List(ctx context.Context) ([]user.Role, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *RoleService) Get(ctx context.Context, name string) (*user.Role, error) {
+	return s.repo.Get(ctx, name)
+}
+
+// Create adds a role with the given initial permissions.
+func (s *RoleService) Create(ctx context.Context, name string, permissions []string, actor string) (*user.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, AppErr.ErrInvalidRoleName
+	}
+	for _, p := range permissions {
+		if !permissionPattern.MatchString(p) {
+			return nil, AppErr.ErrInvalidPermission
+		}
+	}
+	role, err := s.repo.Create(ctx, name, permissions, actor)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info().Str("role", name).Str("actor", actor).Msg("role created")
+	s.record(ctx, actor, name, "create", name, map[string]string{"permissions": strings.Join(permissions, " ")})
+	return role, nil
+}
+
+func (s *RoleService) Delete(ctx context.Context, name, actor string) error {
+	if protectedRoles[name] {
+		return AppErr.ErrRoleProtected
+	}
+	if err := s.repo.Delete(ctx, name, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("role", name).Str("actor", actor).Msg("role deleted")
+	s.record(ctx, actor, name, "delete", name, nil)
+	return nil
+}
+
+func (s *RoleService) AddPermission(ctx context.Context, role, permission, actor string) error {
+	if !permissionPattern.MatchString(permission) {
+		return AppErr.ErrInvalidPermission
+	}
+	if err := s.repo.AddPermission(ctx, role, permission, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("role", role).Str("permission", permission).Str("actor", actor).Msg("permission added")
+	s.record(ctx, actor, role, "add_permission", role, map[string]string{"permission": permission})
+	return nil
+}
+
+func (s *RoleService) RemovePermission(ctx context.Context, role, permission, actor string) error {
+	if err := s.repo.RemovePermission(ctx, role, permission, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("role", role).Str("permission", permission).Str("actor", actor).Msg("permission removed")
+	s.record(ctx, actor, role, "remove_permission", role, map[string]string{"permission": permission})
+	return nil
+}
+
+func (s *RoleService) GrantToUser(ctx context.Context, userID uuid.UUID, role, actor string) error {
+	if err := s.repo.GrantToUser(ctx, userID, role, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("user_id", userID.String()).Str("role", role).Str("actor", actor).Msg("role granted")
+	s.record(ctx, actor, userID.String(), "grant", role, nil)
+	return nil
+}
+
+func (s *RoleService) RevokeFromUser(ctx context.Context, userID uuid.UUID, role, actor string) error {
+	if err := s.repo.RevokeFromUser(ctx, userID, role, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("user_id", userID.String()).Str("role", role).Str("actor", actor).Msg("role revoked")
+	s.record(ctx, actor, userID.String(), "revoke", role, nil)
+	return nil
+}
+
+func (s *RoleService) GrantToClient(ctx context.Context, clientID, role, actor string) error {
+	if err := s.repo.GrantToClient(ctx, clientID, role, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", clientID).Str("role", role).Str("actor", actor).Msg("role granted")
+	s.record(ctx, actor, clientID, "grant", role, nil)
+	return nil
+}
+
+func (s *RoleService) RevokeFromClient(ctx context.Context, clientID, role, actor string) error {
+	if err := s.repo.RevokeFromClient(ctx, clientID, role, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", clientID).Str("role", role).Str("actor", actor).Msg("role revoked")
+	s.record(ctx, actor, clientID, "revoke", role, nil)
+	return nil
+}
+
+// SetClientRoles replaces all roles of a client.
+func (s *RoleService) SetClientRoles(ctx context.Context, clientID string, roles []string, actor string) error {
+	if err := s.repo.SetClientRoles(ctx, clientID, roles, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("client_id", clientID).Strs("roles", roles).Str("actor", actor).Msg("client roles updated")
+	s.record(ctx, actor, clientID, "set_roles", "", map[string]string{"roles": strings.Join(roles, " ")})
+	return nil
+}
+
+// SetMFARequired requires or waives a second factor for every user holding
+// the role.
+func (s *RoleService) SetMFARequired(ctx context.Context, role string, required bool, actor string) error {
+	if err := s.repo.SetMFARequired(ctx, role, required, actor); err != nil {
+		return err
+	}
+	s.log.Info().Str("role", role).Bool("required", required).Str("actor", actor).Msg("role mfa requirement updated")
+	s.record(ctx, actor, role, "set_mfa_required", role, map[string]string{"required": strconv.FormatBool(required)})
+	return nil
+}
+
+// record audits a change made by actor to subject, which is the role itself
+// or the user or client it was granted to.
+func (s *RoleService) record(ctx context.Context, actor, subject, action, role string, details map[string]string) {
+	if details == nil {
+		details = map[string]string{}
+	}
+	details["action"] = action
+	if role != "" {
+		details["role"] = role
+	}
+	s.audit.Record(ctx, user.AuditEvent{Type: user.AuditRoleChanged, Actor: actor, Subject: subject, Details: details})
+}
+
+func (s *RoleService) Changes(ctx context.Context, limit, offset int32) ([]user.RoleChange, error) {
+	return s.repo.ListChanges(ctx, limit, offset)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleService_Create_WithPermissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoles := mocks.NewMockRoleRepository(ctrl)
+	svc := NewRoleService(mockRoles, zerolog.Nop(), nil)
+
+	role := &model.Role{ID: 3, Name: "support", Permissions: []string{"cart:read:any"}}
+	mockRoles.EXPECT().Create(gomock.Any(), "support", []string{"cart:read:any"}, "admin-id").Return(role, nil)
+
+	got, err := svc.Create(context.Background(), "support", []string{"cart:read:any"}, "admin-id")
+	assert.NoError(t, err)
+	assert.Equal(t, role, got)
+}
+
+func TestRoleService_Create_InvalidInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewRoleService(mocks.NewMockRoleRepository(ctrl), zerolog.Nop(), nil)
+
+	_, err := svc.Create(context.Background(), "bad role", nil, "admin-id")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidRoleName)
+
+	_, err = svc.Create(context.Background(), "support", []string{"Cart Read"}, "admin-id")
+	assert.ErrorIs(t, err, AppErr.ErrInvalidPermission, "роль не должна создаваться с некорректным разрешением")
+}
+
+func TestRoleService_Delete_ProtectedRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewRoleService(mocks.NewMockRoleRepository(ctrl), zerolog.Nop(), nil)
+
+	for _, name := range []string{"admin", "user"} {
+		err := svc.Delete(context.Background(), name, "admin-id")
+		assert.ErrorIs(t, err, AppErr.ErrRoleProtected, "встроенная роль %s не должна удаляться", name)
+	}
+}
+
+func TestAuthService_TokensCarryPermissions(t *testing.T) {
+	cfg, _ := setupRSA(t)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: [16]byte{1}, Email: "user@example.com", Roles: []string{"user"}, Permissions: []string{"cart:write"}}
+	access, _, err := authService.createAccessToken(u, grant{})
+	assert.NoError(t, err)
+	claims, err := authService.parseToken(access)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cart:write"}, stringsClaim(claims, permissionsClaim))
+
+	cli := &model.Client{ID: "cart-svc", Roles: []string{"service"}, Permissions: []string{"catalog:read"}}
+	tok, err := authService.ClientToken(cli, nil)
+	assert.NoError(t, err)
+	claims, err = authService.parseToken(tok.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"catalog:read"}, stringsClaim(claims, permissionsClaim), "клиентский токен должен содержать разрешения")
+}
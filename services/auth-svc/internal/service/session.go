@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+)
+
+// ListSessions returns the sessions of the user that can still be refreshed,
+// most recently used first.
+func (s *AuthService) ListSessions(ctx context.Context, userID uuid.UUID) ([]user.Session, error) {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	sessions, err := s.tokenRepo.ListSessions(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Msg("list sessions")
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of one session on behalf of actor. The
+// refresh tokens of the session stop working at once, access tokens already
+// issued in it run until they expire.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, actor string) error {
+	if err := s.tokenRepo.RevokeSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.log.Info().Str("user_id", userID.String()).Str("session_id", sessionID.String()).
+		Str("actor", actor).Msg("session revoked")
+	s.audit.Record(ctx, user.AuditEvent{
+		Type:    user.AuditSessionRevoked,
+		Actor:   actor,
+		Subject: userID.String(),
+		Details: map[string]string{"session_id": sessionID.String()},
+	})
+	return nil
+}
+
+// maxUserAgentLen bounds the user agent stored with a session.
+const maxUserAgentLen = 512
+
+// seenSession describes the device making the current request.
+func seenSession(ctx context.Context) *user.Session {
+	ua := requestinfo.UserAgent(ctx)
+	if len(ua) > maxUserAgentLen {
+		ua = strings.ToValidUTF8(ua[:maxUserAgentLen], "")
+	}
+	return &user.Session{UserAgent: ua, IP: requestinfo.ClientIP(ctx)}
+}
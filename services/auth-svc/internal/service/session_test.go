@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/passhash"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/requestinfo"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func deviceContext(ip, userAgent string) context.Context {
+	return requestinfo.WithUserAgent(requestinfo.WithClientIP(context.Background(), ip), userAgent)
+}
+
+func TestAuthService_Login_RecordsSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	hash, err := passhash.New(config.HashConfig{}).Hash("password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: hash, Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+
+	var sessionID uuid.UUID
+	mockTokens.
+		EXPECT().
+		CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, s *model.Session, first *model.RefreshToken) error {
+			assert.Equal(t, u.ID, s.UserID)
+			assert.Equal(t, "10.0.0.7", s.IP)
+			assert.Equal(t, "Firefox", s.UserAgent)
+			assert.Equal(t, s.ID, first.FamilyID, "сессия должна совпадать с семьей refresh токенов")
+			sessionID = s.ID
+			return nil
+		})
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+
+	res, err := authService.Login(deviceContext("10.0.0.7", "Firefox"), u.Email, "password")
+	if !assert.NoError(t, err) {
+		return
+	}
+	p, err := authService.VerifyAccessToken(context.Background(), res.Tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, sessionID.String(), p.SessionID, "access токен должен ссылаться на свою сессию")
+}
+
+func TestAuthService_Refresh_TouchesSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, privKey := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	userID := uuid.New()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	}
+	refreshToken := signRefreshToken(t, privKey, jwt.MapClaims{
+		"sub":   userID.String(),
+		"jti":   stored.ID.String(),
+		"typ":   "refresh",
+		"email": "user@example.com",
+		"roles": []string{"user"},
+		"exp":   time.Now().Add(cfg.JWT.RefreshTokenTTL).Unix(),
+		"iat":   time.Now().Unix(),
+	})
+
+	mockTokens.EXPECT().GetRefreshToken(gomock.Any(), stored.ID).Return(stored, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), userID).
+		Return(&model.User{ID: userID, Status: model.UserStatusActive}, nil)
+	mockTokens.
+		EXPECT().
+		RotateRefreshToken(gomock.Any(), stored.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, next *model.RefreshToken, seen *model.Session) error {
+			assert.Equal(t, stored.FamilyID, next.FamilyID)
+			assert.Equal(t, "192.168.1.5", seen.IP, "сессия должна запоминать адрес последнего обновления")
+			assert.Equal(t, "curl/8.0", seen.UserAgent)
+			return nil
+		})
+
+	_, err := authService.Refresh(deviceContext("192.168.1.5", "curl/8.0"), refreshToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_RevokeSession_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	userID, sessionID := uuid.New(), uuid.New()
+	mockTokens.EXPECT().RevokeSession(gomock.Any(), userID, sessionID).Return(AppErr.ErrNotFound)
+
+	err := authService.RevokeSession(context.Background(), userID, sessionID, userID.String())
+	assert.ErrorIs(t, err, AppErr.ErrNotFound, "чужую или завершенную сессию отозвать нельзя")
+}
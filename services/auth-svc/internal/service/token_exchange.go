@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+)
+
+// TokenTypeAccessToken is the only token type the token exchange grant takes
+// and issues, see RFC 8693 section 3.
+const TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+// actClaim names the party acting on behalf of the subject (RFC 8693 section
+// 4.1). When a delegated token is exchanged again, the previous actor is
+// nested inside.
+const actClaim = "act"
+
+// exchangeToken implements the token exchange grant: cli, a service acting
+// on behalf of a user, swaps the user's access token for a short-lived one
+// that names cli as the actor. The token is issued for audiences cli has been
+// allowed to request, all of them if none are requested, and carries no more
+// scopes than both the subject token and the client have. It carries none of
+// the user's roles and permissions: the downstream service gets the scopes
+// only.
+func (s *AuthService) exchangeToken(ctx context.Context, cli *user.Client, req TokenRequest, requested []string) (resp *TokenResponse, err error) {
+	var (
+		subject   string
+		audiences []string
+		scopes    []string
+	)
+	defer func() { s.auditExchange(ctx, cli.ID, subject, audiences, scopes, err) }()
+
+	switch {
+	case req.SubjectToken == "" || req.SubjectTokenType == "":
+		return nil, &OAuthError{OAuthInvalidRequest, "subject_token and subject_token_type are required"}
+	case req.SubjectTokenType != TokenTypeAccessToken:
+		return nil, &OAuthError{OAuthInvalidRequest, "unsupported subject_token_type"}
+	case req.RequestedTokenType != "" && req.RequestedTokenType != TokenTypeAccessToken:
+		return nil, &OAuthError{OAuthInvalidRequest, "unsupported requested_token_type"}
+	case req.ActorToken != "":
+		return nil, &OAuthError{OAuthInvalidRequest, "actor tokens are not supported, the authenticated client is the actor"}
+	}
+
+	audiences = req.Audience
+	if len(audiences) == 0 {
+		audiences = cli.ExchangeAudiences
+	}
+	if len(audiences) == 0 || !isSubset(audiences, cli.ExchangeAudiences) {
+		return nil, &OAuthError{OAuthInvalidTarget, "requested audience is not allowed for this client"}
+	}
+
+	claims, err := s.verifyAccessToken(ctx, req.SubjectToken)
+	if errors.Is(err, AppErr.ErrInvalidToken) {
+		// The subject token may itself have been exchanged, for the
+		// service that is now passing it on.
+		exchangeable, aerr := s.exchangeAudiences(ctx)
+		if aerr != nil {
+			return nil, aerr
+		}
+		claims, err = s.verifyAccessToken(ctx, req.SubjectToken, exchangeable...)
+	}
+	if err != nil {
+		if errors.Is(err, AppErr.ErrInvalidToken) {
+			return nil, &OAuthError{OAuthInvalidGrant, "subject token is invalid, expired or revoked"}
+		}
+		return nil, err
+	}
+	userID, err := uuidClaim(claims, "sub")
+	if err != nil {
+		return nil, &OAuthError{OAuthInvalidGrant, "subject token is invalid, expired or revoked"}
+	}
+	subject = userID.String()
+
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil, &OAuthError{OAuthInvalidGrant, "subject token is invalid, expired or revoked"}
+		}
+		s.log.Error().Err(err).Msg("get user by id")
+		return nil, err
+	}
+	if u.Status != user.UserStatusActive {
+		return nil, toOAuthError(AppErr.ErrUserDisabled)
+	}
+
+	scopes, err = exchangeScopes(ParseScope(stringClaim(claims, "scope")), cli.AllowedScopes, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	exp := now.Add(s.cfg.OAuth.ExchangeTokenTTL)
+	if subjectExp, err := claims.GetExpirationTime(); err == nil && subjectExp != nil && subjectExp.Before(exp) {
+		exp = subjectExp.Time
+	}
+	authTime, _ := claims[authTimeClaim].(float64)
+	g := grant{clientID: cli.ID, scopes: scopes, amr: stringsClaim(claims, amrClaim), authTime: int64(authTime)}
+	if sid, err := uuidClaim(claims, sessionClaim); err == nil {
+		g.sessionID = sid
+	}
+
+	out := accessClaims(unprivileged(u), g, now, exp)
+	out["aud"] = audiences
+	out[actClaim] = actor(cli.ID, claims[actClaim])
+	jw, err := s.signToken(out)
+	if err != nil {
+		s.log.Error().Err(err).Msg("create exchanged token")
+		return nil, err
+	}
+	s.log.Info().
+		Str("client_id", cli.ID).
+		Str("user_id", subject).
+		Strs("audience", audiences).
+		Msg("token exchanged")
+	return &TokenResponse{
+		AccessToken:     jw,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(exp.Sub(now).Seconds()),
+		Scope:           strings.Join(scopes, " "),
+		IssuedTokenType: TokenTypeAccessToken,
+	}, nil
+}
+
+// exchangeScopes returns the scopes of an exchanged token. Requested scopes,
+// already known to be allowed for the client, must also have been granted to
+// the subject token. Without a request the scopes both have are issued. A
+// subject token without scopes, as issued by the first-party sign-in, is not
+// limited by anything, so the scopes must then be requested explicitly.
+func exchangeScopes(subject, client, requested []string) ([]string, error) {
+	if len(requested) > 0 {
+		if len(subject) > 0 && !isSubset(requested, subject) {
+			return nil, &OAuthError{OAuthInvalidScope, "requested scope exceeds the scope of the subject token"}
+		}
+		return requested, nil
+	}
+	if len(subject) == 0 {
+		return nil, &OAuthError{OAuthInvalidScope, "scope is required when the subject token has none"}
+	}
+	scopes := make([]string, 0, len(subject))
+	for _, sc := range subject {
+		if slices.Contains(client, sc) {
+			scopes = append(scopes, sc)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, &OAuthError{OAuthInvalidScope, "the subject token and the client have no scope in common"}
+	}
+	return scopes, nil
+}
+
+// actor returns the act claim naming clientID, with the actor of the subject
+// token, if any, nested as the prior one.
+func actor(clientID string, prior any) jwt.MapClaims {
+	act := jwt.MapClaims{"sub": clientID}
+	if p, ok := prior.(map[string]any); ok {
+		act[actClaim] = p
+	}
+	return act
+}
+
+func (s *AuthService) auditExchange(ctx context.Context, clientID, subject string, audiences, scopes []string, err error) {
+	e := user.AuditEvent{
+		Type:    user.AuditTokenExchanged,
+		Actor:   clientID,
+		Subject: subject,
+		Details: map[string]string{"audience": strings.Join(audiences, " ")},
+	}
+	if len(scopes) > 0 {
+		e.Details["scope"] = strings.Join(scopes, " ")
+	}
+	if err != nil {
+		e.Outcome = user.AuditFailure
+		e.Details["reason"] = err.Error()
+	}
+	s.audit.Record(ctx, e)
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/audit"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExchangeClient(t *testing.T) *model.Client {
+	t.Helper()
+	cli := newTestClient(t, "secret")
+	cli.GrantTypes = []string{GrantTokenExchange}
+	cli.ExchangeAudiences = []string{"catalog-svc", "payment-svc"}
+	return cli
+}
+
+func setupExchange(t *testing.T) (*config.Config, *model.User) {
+	t.Helper()
+	cfg, _ := setupRSA(t)
+	cfg.OAuth.ExchangeTokenTTL = 5 * time.Minute
+	u := &model.User{
+		ID:            uuid.New(),
+		Email:         "user@example.com",
+		Status:        model.UserStatusActive,
+		Roles:         []string{"customer"},
+		EmailVerified: true,
+	}
+	return cfg, u
+}
+
+func TestAuthService_Token_TokenExchange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, u := setupExchange(t)
+	recorder := &auditRecorder{}
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil,
+		audit.New(recorder, zerolog.Nop()))
+
+	sid := uuid.New()
+	subjectToken, subjectExp, err := authService.createAccessToken(u, grant{
+		clientID:  "storefront",
+		scopes:    []string{"catalog:read", "profile"},
+		amr:       []string{"pwd"},
+		sessionID: sid,
+	})
+	require.NoError(t, err)
+
+	mockClient.EXPECT().GetById(gomock.Any(), "cart-svc").Return(newExchangeClient(t), nil)
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+
+	resp, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:        GrantTokenExchange,
+		ClientID:         "cart-svc",
+		ClientSecret:     "secret",
+		SubjectToken:     subjectToken,
+		SubjectTokenType: TokenTypeAccessToken,
+		Audience:         []string{"catalog-svc"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TokenTypeAccessToken, resp.IssuedTokenType)
+	assert.Equal(t, "catalog:read", resp.Scope, "выдаются только общие для токена и клиента scope")
+	assert.Empty(t, resp.RefreshToken)
+	assert.LessOrEqual(t, resp.ExpiresIn, int64((5 * time.Minute).Seconds()))
+
+	claims, err := authService.parseToken(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID.String(), claims["sub"], "субъектом остается пользователь")
+	assert.Equal(t, tokenTypeAccess, claims["typ"])
+	assert.Equal(t, map[string]any{"sub": "cart-svc"}, claims[actClaim])
+	aud, err := claims.GetAudience()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"catalog-svc"}, []string(aud))
+	assert.Equal(t, sid.String(), claims[sessionClaim])
+	assert.Empty(t, claims["roles"], "делегированный токен не несет роли пользователя")
+	assert.NotContains(t, claims, permissionsClaim)
+	assert.Equal(t, []any{"pwd"}, claims[amrClaim])
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.False(t, exp.After(subjectExp), "обменянный токен не живет дольше исходного")
+
+	if assert.Len(t, recorder.events, 1) {
+		e := recorder.events[0]
+		assert.Equal(t, model.AuditTokenExchanged, e.Type)
+		assert.Equal(t, "cart-svc", e.Actor)
+		assert.Equal(t, u.ID.String(), e.Subject)
+		assert.Equal(t, model.AuditSuccess, e.Outcome)
+		assert.Equal(t, "catalog-svc", e.Details["audience"])
+	}
+}
+
+func TestAuthService_Token_TokenExchangeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// subjectScopes replace the scopes of the subject token, if set.
+		subjectScopes []string
+		req           func(subject string) TokenRequest
+		verifies      bool
+		code          string
+	}{
+		{
+			name: "audience not allowed for client",
+			req: func(subject string) TokenRequest {
+				return TokenRequest{SubjectToken: subject, SubjectTokenType: TokenTypeAccessToken, Audience: []string{"user-svc"}}
+			},
+			code: OAuthInvalidTarget,
+		},
+		{
+			name: "unsupported subject token type",
+			req: func(subject string) TokenRequest {
+				return TokenRequest{SubjectToken: subject, SubjectTokenType: "urn:ietf:params:oauth:token-type:refresh_token"}
+			},
+			code: OAuthInvalidRequest,
+		},
+		{
+			name: "subject token is missing",
+			req: func(string) TokenRequest {
+				return TokenRequest{SubjectTokenType: TokenTypeAccessToken}
+			},
+			code: OAuthInvalidRequest,
+		},
+		{
+			name: "invalid subject token",
+			req: func(string) TokenRequest {
+				return TokenRequest{SubjectToken: "garbage", SubjectTokenType: TokenTypeAccessToken}
+			},
+			code: OAuthInvalidGrant,
+		},
+		{
+			name:          "no scope for a subject token without scopes",
+			subjectScopes: []string{},
+			req: func(subject string) TokenRequest {
+				return TokenRequest{SubjectToken: subject, SubjectTokenType: TokenTypeAccessToken}
+			},
+			verifies: true,
+			code:     OAuthInvalidScope,
+		},
+		{
+			name: "scope beyond the subject token",
+			req: func(subject string) TokenRequest {
+				return TokenRequest{SubjectToken: subject, SubjectTokenType: TokenTypeAccessToken, Scope: "cart:write"}
+			},
+			verifies: true,
+			code:     OAuthInvalidScope,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockAuthRepository(ctrl)
+			mockClient := mocks.NewMockClientRepository(ctrl)
+			mockTokens := mocks.NewMockTokenRepository(ctrl)
+			cfg, u := setupExchange(t)
+			authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+			g := grant{clientID: "storefront", scopes: []string{"catalog:read"}}
+			if tt.subjectScopes != nil {
+				g.scopes = tt.subjectScopes
+			}
+			subjectToken, _, err := authService.createAccessToken(u, g)
+			require.NoError(t, err)
+
+			mockClient.EXPECT().GetById(gomock.Any(), "cart-svc").Return(newExchangeClient(t), nil)
+			mockClient.EXPECT().ExchangeAudiences(gomock.Any()).Return([]string{"catalog-svc", "payment-svc"}, nil).AnyTimes()
+			if tt.verifies {
+				mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil)
+			}
+
+			req := tt.req(subjectToken)
+			req.GrantType = GrantTokenExchange
+			req.ClientID = "cart-svc"
+			req.ClientSecret = "secret"
+			_, err = authService.Token(context.Background(), req)
+			var oauthErr *OAuthError
+			if assert.ErrorAs(t, err, &oauthErr) {
+				assert.Equal(t, tt.code, oauthErr.Code)
+			}
+		})
+	}
+}
+
+func TestAuthService_ExchangedToken_IntrospectedAndExchangedAgain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockClient := mocks.NewMockClientRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	cfg, u := setupExchange(t)
+	cfg.JWT.Audience = "e-commerce"
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, mockClient, mockTokens, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	subjectToken, _, err := authService.createAccessToken(u, grant{clientID: "storefront", scopes: []string{"catalog:read"}})
+	require.NoError(t, err)
+
+	cartSvc := newExchangeClient(t)
+	catalogSvc := newTestClient(t, "secret")
+	catalogSvc.ID = "catalog-svc"
+	catalogSvc.GrantTypes = []string{GrantTokenExchange}
+	catalogSvc.AllowedScopes = []string{"catalog:read"}
+	catalogSvc.ExchangeAudiences = []string{"pricing-svc"}
+	mockClient.EXPECT().GetById(gomock.Any(), "cart-svc").Return(cartSvc, nil)
+	mockClient.EXPECT().GetById(gomock.Any(), "catalog-svc").Return(catalogSvc, nil)
+	mockClient.EXPECT().ExchangeAudiences(gomock.Any()).
+		Return([]string{"catalog-svc", "payment-svc", "pricing-svc"}, nil).AnyTimes()
+	mockTokens.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	mockRepo.EXPECT().GetByID(gomock.Any(), u.ID).Return(u, nil).AnyTimes()
+
+	first, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:        GrantTokenExchange,
+		ClientID:         "cart-svc",
+		ClientSecret:     "secret",
+		SubjectToken:     subjectToken,
+		SubjectTokenType: TokenTypeAccessToken,
+		Audience:         []string{"catalog-svc"},
+	})
+	require.NoError(t, err)
+
+	res, err := authService.Introspect(context.Background(), first.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, res.Active, "обменянный токен должен проходить интроспекцию")
+	assert.Equal(t, u.ID.String(), res.Subject)
+
+	_, err = authService.VerifyAccessToken(context.Background(), first.AccessToken)
+	assert.Error(t, err, "токен для другой аудитории не принимается API самого сервиса")
+
+	second, err := authService.Token(context.Background(), TokenRequest{
+		GrantType:        GrantTokenExchange,
+		ClientID:         "catalog-svc",
+		ClientSecret:     "secret",
+		SubjectToken:     first.AccessToken,
+		SubjectTokenType: TokenTypeAccessToken,
+		Audience:         []string{"pricing-svc"},
+	})
+	require.NoError(t, err, "обменянный токен можно обменять повторно")
+
+	claims, err := authService.parseToken(second.AccessToken, "pricing-svc")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sub": "catalog-svc", actClaim: map[string]any{"sub": "cart-svc"}}, claims[actClaim],
+		"предыдущий участник вкладывается в act")
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserService_Disable_RevokesRefreshTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	svc := NewUserService(mockRepo, mockTokens, zerolog.Nop(), nil)
+
+	id := uuid.New()
+	gomock.InOrder(
+		mockRepo.EXPECT().SetStatus(gomock.Any(), id, model.UserStatusDisabled).Return(nil),
+		mockTokens.EXPECT().RevokeUserTokens(gomock.Any(), id).Return(nil),
+	)
+
+	assert.NoError(t, svc.Disable(context.Background(), id, "admin-id"))
+}
+
+func TestUserService_Disable_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mockTokens := mocks.NewMockTokenRepository(ctrl)
+	svc := NewUserService(mockRepo, mockTokens, zerolog.Nop(), nil)
+
+	id := uuid.New()
+	mockRepo.EXPECT().SetStatus(gomock.Any(), id, model.UserStatusDisabled).Return(AppErr.ErrNotFound)
+
+	err := svc.Disable(context.Background(), id, "admin-id")
+	assert.ErrorIs(t, err, AppErr.ErrNotFound, "токены не должны отзываться для несуществующего пользователя")
+}
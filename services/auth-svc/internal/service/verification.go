@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	user "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+)
+
+// tokenTypeEmailVerify marks the signed tokens of email verification links.
+const tokenTypeEmailVerify = "email_verify"
+
+// VerifyEmail confirms the email address a verification token was issued
+// for, or applies the email change a token from ChangeEmail was issued for.
+// Tokens for an address the user no longer has yield ErrInvalidToken.
+// Verifying an already verified address succeeds.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.parseToken(token)
+	if err != nil {
+		s.log.Error().Err(err).Msg("parse verification token")
+		return AppErr.ErrInvalidToken
+	}
+	typ := stringClaim(claims, "typ")
+	if typ != tokenTypeEmailVerify && typ != tokenTypeEmailChange {
+		s.log.Error().Str("typ", typ).Msg("unexpected verification token type")
+		return AppErr.ErrInvalidToken
+	}
+	sub, err := uuidClaim(claims, "sub")
+	if err != nil {
+		s.log.Error().Err(err).Msg("invalid token subject")
+		return AppErr.ErrInvalidToken
+	}
+	if typ == tokenTypeEmailChange {
+		return s.confirmEmailChange(ctx, sub, claims)
+	}
+	email := stringClaim(claims, "email")
+	if email == "" {
+		return AppErr.ErrInvalidToken
+	}
+
+	if err := s.repo.MarkEmailVerified(ctx, sub, email); err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return AppErr.ErrInvalidToken
+		}
+		s.log.Error().Err(err).Msg("mark email verified")
+		return err
+	}
+	s.log.Info().Str("user_id", sub.String()).Msg("email verified")
+	return nil
+}
+
+// ResendVerification mails a new verification link to an unverified user.
+// Unknown, verified and disabled accounts are silently ignored, and so are
+// requests made sooner than the configured resend interval after the last
+// mail, so that the caller cannot tell whether the address is registered.
+func (s *AuthService) ResendVerification(ctx context.Context, email string) error {
+	u, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, AppErr.ErrNotFound) {
+			return nil
+		}
+		s.log.Error().Err(err).Msg("get user by email")
+		return err
+	}
+	if u.EmailVerified || u.Status != user.UserStatusActive {
+		return nil
+	}
+	return s.sendVerification(ctx, u)
+}
+
+// sendVerification mails a verification link to u in the background. Nothing
+// is sent if u is verified or was sent a link less than the resend interval
+// ago.
+func (s *AuthService) sendVerification(ctx context.Context, u *user.User) error {
+	if u.EmailVerified {
+		return nil
+	}
+	ok, err := s.repo.MarkVerificationSent(ctx, u.ID, time.Now().Add(-s.cfg.Verify.ResendInterval))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.log.Debug().Str("user_id", u.ID.String()).Msg("verification mail throttled")
+		return nil
+	}
+
+	token, expiresAt, err := s.createVerificationToken(u)
+	if err != nil {
+		return err
+	}
+	msg := mailer.Message{
+		To:      u.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Чтобы подтвердить адрес электронной почты, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует до %s. Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			s.verificationLink(token), expiresAt.UTC().Format("02.01.2006 15:04 MST")),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.log.Error().Err(err).Str("user_id", u.ID.String()).Msg("send verification mail")
+		}
+	}()
+	return nil
+}
+
+// createVerificationToken signs a token binding the user to its current
+// email, so that a link stops working once the address changes.
+func (s *AuthService) createVerificationToken(u *user.User) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(s.cfg.Verify.TTL)
+	claims := jwt.MapClaims{
+		"sub":   u.ID,
+		"jti":   uuid.NewString(),
+		"typ":   tokenTypeEmailVerify,
+		"email": u.Email,
+		"exp":   exp.Unix(),
+		"iat":   now.Unix(),
+	}
+	jw, err := s.signToken(claims)
+	if err != nil {
+		s.log.Error().Err(err).Msg("create verification token")
+		return "", time.Time{}, err
+	}
+	return jw, exp, nil
+}
+
+func (s *AuthService) verificationLink(token string) string {
+	u, err := url.Parse(s.cfg.Verify.URL)
+	if err != nil {
+		return s.cfg.Verify.URL + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// restrictUnverified applies the limited policy: a user whose email is not
+// verified gets tokens without roles and permissions.
+func (s *AuthService) restrictUnverified(u *user.User) *user.User {
+	if u.EmailVerified || s.cfg.Verify.UnverifiedPolicy != config.UnverifiedLimited {
+		return u
+	}
+	return unprivileged(u)
+}
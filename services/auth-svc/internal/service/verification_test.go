@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/mailer"
+	"github.com/oidiral/e-commerce/services/auth-svc/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthService_VerifyEmail_LinkFromResend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.Verify.TTL = time.Hour
+	cfg.Verify.URL = "https://shop.example.com/verify"
+	cfg.Verify.ResendInterval = time.Minute
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), mail, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	mockRepo.EXPECT().
+		MarkVerificationSent(gomock.Any(), u.ID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, notBefore time.Time) (bool, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Minute), notBefore, 5*time.Second)
+			return true, nil
+		})
+
+	assert.NoError(t, authService.ResendVerification(context.Background(), u.Email))
+
+	var token string
+	select {
+	case msg := <-mail.sent:
+		assert.Equal(t, u.Email, msg.To)
+		link := regexp.MustCompile(`https://shop\.example\.com/verify\?token=\S+`).FindString(msg.Body)
+		assert.NotEmpty(t, link, "письмо должно содержать ссылку для подтверждения")
+		parsed, err := url.Parse(link)
+		assert.NoError(t, err)
+		token = parsed.Query().Get("token")
+	case <-time.After(time.Second):
+		t.Fatal("письмо не было отправлено")
+	}
+
+	mockRepo.EXPECT().MarkEmailVerified(gomock.Any(), u.ID, u.Email).Return(nil)
+	assert.NoError(t, authService.VerifyEmail(context.Background(), token))
+
+	// The address changed after the link was sent.
+	mockRepo.EXPECT().MarkEmailVerified(gomock.Any(), u.ID, u.Email).Return(AppErr.ErrNotFound)
+	assert.ErrorIs(t, authService.VerifyEmail(context.Background(), token), AppErr.ErrInvalidToken)
+}
+
+func TestAuthService_VerifyEmail_RejectsOtherTokens(t *testing.T) {
+	cfg, _ := setupRSA(t)
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"user"}}
+	access, _, err := authService.createAccessToken(u, grant{})
+	assert.NoError(t, err)
+
+	err = authService.VerifyEmail(context.Background(), access)
+	assert.ErrorIs(t, err, AppErr.ErrInvalidToken, "access-токен не должен подтверждать email")
+}
+
+func TestAuthService_ResendVerification_Throttled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), mail, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Status: model.UserStatusActive}
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	mockRepo.EXPECT().MarkVerificationSent(gomock.Any(), u.ID, gomock.Any()).Return(false, nil)
+
+	assert.NoError(t, authService.ResendVerification(context.Background(), u.Email))
+
+	select {
+	case <-mail.sent:
+		t.Fatal("повторное письмо не должно отправляться чаще заданного интервала")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAuthService_UnverifiedPolicy_Deny(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg, _ := setupRSA(t)
+	cfg.Verify.UnverifiedPolicy = config.UnverifiedDeny
+	mockRepo := mocks.NewMockAuthRepository(ctrl)
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	authService := NewAuthService(mockRepo, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), mail, nil, nil, nil)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Password: string(hash),
+		Status: model.UserStatusActive, Roles: []string{"user"}}
+
+	mockRepo.EXPECT().CreateIfNotExists(gomock.Any(), u.Email, gomock.Any()).Return(u, nil)
+	mockRepo.EXPECT().MarkVerificationSent(gomock.Any(), u.ID, gomock.Any()).Return(true, nil)
+	tokens, err := authService.RegisterUser(context.Background(), u.Email, "password")
+	assert.NoError(t, err)
+	assert.Nil(t, tokens, "до подтверждения email токены не выдаются")
+	<-mail.sent
+
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), u.Email).Return(u, nil)
+	_, err = authService.Login(context.Background(), u.Email, "password")
+	assert.ErrorIs(t, err, AppErr.ErrEmailNotVerified)
+}
+
+func TestAuthService_UnverifiedPolicy_Limited(t *testing.T) {
+	cfg, _ := setupRSA(t)
+	cfg.Verify.UnverifiedPolicy = config.UnverifiedLimited
+	authService := NewAuthService(nil, zerolog.Nop(), cfg, nil, nil, nil, nil, newKeyRing(t, cfg), nil, nil, nil, nil)
+
+	u := &model.User{ID: uuid.New(), Email: "user@example.com", Roles: []string{"admin"}, Permissions: []string{"users:write"}}
+	access, _, err := authService.createAccessToken(authService.restrictUnverified(u), grant{})
+	assert.NoError(t, err)
+	claims, err := authService.parseToken(access)
+	assert.NoError(t, err)
+	assert.Empty(t, stringsClaim(claims, "roles"), "неподтвержденный пользователь не получает роли")
+	assert.Empty(t, stringsClaim(claims, permissionsClaim))
+	assert.Equal(t, false, claims[emailVerifiedClaim])
+
+	u.EmailVerified = true
+	access, _, err = authService.createAccessToken(authService.restrictUnverified(u), grant{})
+	assert.NoError(t, err)
+	claims, err = authService.parseToken(access)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, stringsClaim(claims, "roles"))
+	assert.Equal(t, true, claims[emailVerifiedClaim])
+}
@@ -0,0 +1,68 @@
+package throttle
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "auth:throttle:"
+
+// RedisStore keeps failure counters in Redis hashes that expire on their own.
+type RedisStore struct {
+	rds *redis.Client
+}
+
+func NewRedisStore(rds *redis.Client) *RedisStore {
+	return &RedisStore{rds: rds}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (Entry, error) {
+	vals, err := s.rds.HMGet(ctx, redisKeyPrefix+key, "failures", "locked_until").Result()
+	if err != nil {
+		return Entry{}, err
+	}
+	var e Entry
+	if v, ok := vals[0].(string); ok {
+		e.Failures, _ = strconv.Atoi(v)
+	}
+	if v, ok := vals[1].(string); ok {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			e.LockedUntil = time.UnixMilli(ms)
+		}
+	}
+	return e, nil
+}
+
+func (s *RedisStore) AddFailure(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	k := redisKeyPrefix + key
+	var incr *redis.IntCmd
+	_, err := s.rds.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.HIncrBy(ctx, k, "failures", 1)
+		// NX starts the window of a new key, GT extends it without ever
+		// shortening the expiry set by Lock.
+		p.ExpireNX(ctx, k, ttl)
+		p.ExpireGT(ctx, k, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, until time.Time) error {
+	k := redisKeyPrefix + key
+	_, err := s.rds.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, k, "locked_until", until.UnixMilli())
+		p.ExpireGT(ctx, k, time.Until(until))
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.rds.Del(ctx, redisKeyPrefix+key).Err()
+}
@@ -0,0 +1,259 @@
+// Package throttle limits failed attempts, such as sign-ins and password
+// resets, per account, client and IP address with exponential backoff and
+// temporary lockouts.
+package throttle
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/rs/zerolog"
+)
+
+// Key identifies a throttled subject.
+type Key struct {
+	Kind string
+	ID   string
+}
+
+// Account is the key of the user account with the given email.
+func Account(email string) Key {
+	return Key{Kind: model.LockoutKindAccount, ID: strings.ToLower(strings.TrimSpace(email))}
+}
+
+// Client is the key of a service client.
+func Client(id string) Key {
+	return Key{Kind: model.LockoutKindClient, ID: id}
+}
+
+// IP is the key of a client address. An empty ip yields a key that is never
+// throttled.
+func IP(ip string) Key {
+	return Key{Kind: model.LockoutKindIP, ID: ip}
+}
+
+func (k Key) String() string {
+	return k.Kind + ":" + k.ID
+}
+
+// Entry is the state of a key in a Store.
+type Entry struct {
+	Failures    int
+	LockedUntil time.Time
+}
+
+// Store keeps failure counters. Implementations must make AddFailure atomic,
+// since attempts for the same key arrive concurrently.
+type Store interface {
+	// Get returns the state of key, or a zero Entry if it has none.
+	Get(ctx context.Context, key string) (Entry, error)
+	// AddFailure increments the failure counter of key and returns the new
+	// value. The counter is forgotten once ttl passes without failures.
+	AddFailure(ctx context.Context, key string, ttl time.Duration) (int, error)
+	// Lock locks key until the given time.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets key.
+	Reset(ctx context.Context, key string) error
+}
+
+// WithPrefix returns a view of store that prefixes every key, so that a
+// Guard for another kind of attempt can share the store with the sign-in
+// one without sharing its counters.
+func WithPrefix(store Store, prefix string) Store {
+	return prefixStore{store: store, prefix: prefix}
+}
+
+type prefixStore struct {
+	store  Store
+	prefix string
+}
+
+func (s prefixStore) Get(ctx context.Context, key string) (Entry, error) {
+	return s.store.Get(ctx, s.prefix+key)
+}
+
+func (s prefixStore) AddFailure(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	return s.store.AddFailure(ctx, s.prefix+key, ttl)
+}
+
+func (s prefixStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.store.Lock(ctx, s.prefix+key, until)
+}
+
+func (s prefixStore) Reset(ctx context.Context, key string) error {
+	return s.store.Reset(ctx, s.prefix+key)
+}
+
+// Auditor records lockouts and unlocks.
+type Auditor interface {
+	RecordLockout(ctx context.Context, e *model.LockoutEvent) error
+}
+
+// Policy decides when and for how long a key is locked.
+type Policy struct {
+	Threshold   int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	Window      time.Duration
+}
+
+// Lockout returns how long a key with the given number of failures is
+// locked: nothing below the threshold, BaseLockout at it and twice as long
+// for every failure after that, up to MaxLockout.
+func (p Policy) Lockout(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	d := p.BaseLockout
+	for i := p.Threshold; i < failures && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxLockout)
+}
+
+// Guard applies the account, client and IP policies on top of a Store. A nil
+// *Guard does not throttle anything.
+type Guard struct {
+	store    Store
+	audit    Auditor
+	policies map[string]Policy
+	log      zerolog.Logger
+}
+
+func NewGuard(store Store, audit Auditor, cfg config.ThrottleConfig, log zerolog.Logger) *Guard {
+	subject := Policy{
+		Threshold:   cfg.AccountThreshold,
+		BaseLockout: cfg.BaseLockout,
+		MaxLockout:  cfg.MaxLockout,
+		Window:      cfg.Window,
+	}
+	ip := subject
+	ip.Threshold = cfg.IPThreshold
+	return &Guard{
+		store: store,
+		audit: audit,
+		policies: map[string]Policy{
+			model.LockoutKindAccount: subject,
+			model.LockoutKindClient:  subject,
+			model.LockoutKindIP:      ip,
+		},
+		log: log,
+	}
+}
+
+// Check fails with an *AppErr.LockoutError if any of keys is locked. Locked
+// accounts and clients take precedence over locked IP addresses.
+func (g *Guard) Check(ctx context.Context, keys ...Key) error {
+	if g == nil {
+		return nil
+	}
+	var ipLock *AppErr.LockoutError
+	now := time.Now()
+	for _, k := range keys {
+		if k.ID == "" {
+			continue
+		}
+		e, err := g.store.Get(ctx, k.String())
+		if err != nil {
+			// Failing open keeps sign-in working while the store is down.
+			g.log.Error().Err(err).Str("key", k.String()).Msg("read throttle state")
+			continue
+		}
+		if !e.LockedUntil.After(now) {
+			continue
+		}
+		if k.Kind != model.LockoutKindIP {
+			return &AppErr.LockoutError{Err: AppErr.ErrAccountLocked, Until: e.LockedUntil}
+		}
+		ipLock = &AppErr.LockoutError{Err: AppErr.ErrTooManyAttempts, Until: e.LockedUntil}
+	}
+	if ipLock != nil {
+		return ipLock
+	}
+	return nil
+}
+
+// Fail records a failed attempt from ip for every key and locks the keys that
+// reached their threshold.
+func (g *Guard) Fail(ctx context.Context, ip string, keys ...Key) {
+	if g == nil {
+		return
+	}
+	for _, k := range keys {
+		if k.ID == "" {
+			continue
+		}
+		p := g.policies[k.Kind]
+		failures, err := g.store.AddFailure(ctx, k.String(), p.Window)
+		if err != nil {
+			g.log.Error().Err(err).Str("key", k.String()).Msg("record failure")
+			continue
+		}
+		d := p.Lockout(failures)
+		if d == 0 {
+			continue
+		}
+		until := time.Now().Add(d)
+		if err := g.store.Lock(ctx, k.String(), until); err != nil {
+			g.log.Error().Err(err).Str("key", k.String()).Msg("lock after failures")
+			continue
+		}
+		g.log.Warn().
+			Str("kind", k.Kind).
+			Str("subject", k.ID).
+			Str("ip", ip).
+			Int("failures", failures).
+			Time("locked_until", until).
+			Msg("locked after repeated failures")
+		g.record(ctx, &model.LockoutEvent{
+			Kind:        k.Kind,
+			Subject:     k.ID,
+			Action:      model.LockoutActionLock,
+			IP:          ip,
+			Failures:    failures,
+			LockedUntil: &until,
+		})
+	}
+}
+
+// Succeed forgets the failures of key after a successful attempt.
+func (g *Guard) Succeed(ctx context.Context, key Key) {
+	if g == nil || key.ID == "" {
+		return
+	}
+	if err := g.store.Reset(ctx, key.String()); err != nil {
+		g.log.Error().Err(err).Str("key", key.String()).Msg("reset failures")
+	}
+}
+
+// Unlock lifts the lockout of key and forgets its failures on behalf of
+// actor.
+func (g *Guard) Unlock(ctx context.Context, key Key, actor string) error {
+	if g == nil {
+		return nil
+	}
+	if err := g.store.Reset(ctx, key.String()); err != nil {
+		return err
+	}
+	g.log.Info().Str("kind", key.Kind).Str("subject", key.ID).Str("actor", actor).Msg("lockout lifted")
+	g.record(ctx, &model.LockoutEvent{
+		Kind:    key.Kind,
+		Subject: key.ID,
+		Action:  model.LockoutActionUnlock,
+		Actor:   actor,
+	})
+	return nil
+}
+
+func (g *Guard) record(ctx context.Context, e *model.LockoutEvent) {
+	if g.audit == nil {
+		return
+	}
+	if err := g.audit.RecordLockout(ctx, e); err != nil {
+		g.log.Error().Err(err).Str("kind", e.Kind).Str("subject", e.Subject).Msg("record lockout event")
+	}
+}
@@ -0,0 +1,173 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oidiral/e-commerce/services/auth-svc/config"
+	model "github.com/oidiral/e-commerce/services/auth-svc/internal/domain/model"
+	AppErr "github.com/oidiral/e-commerce/services/auth-svc/internal/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+func newMemStore() *memStore {
+	return &memStore{entries: map[string]Entry{}}
+}
+
+func (m *memStore) Get(_ context.Context, key string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[key], nil
+}
+
+func (m *memStore) AddFailure(_ context.Context, key string, _ time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entries[key]
+	e.Failures++
+	m.entries[key] = e
+	return e.Failures, nil
+}
+
+func (m *memStore) Lock(_ context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entries[key]
+	e.LockedUntil = until
+	m.entries[key] = e
+	return nil
+}
+
+func (m *memStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+type memAuditor struct {
+	events []model.LockoutEvent
+}
+
+func (a *memAuditor) RecordLockout(_ context.Context, e *model.LockoutEvent) error {
+	a.events = append(a.events, *e)
+	return nil
+}
+
+func testConfig() config.ThrottleConfig {
+	return config.ThrottleConfig{
+		AccountThreshold: 3,
+		IPThreshold:      5,
+		BaseLockout:      time.Minute,
+		MaxLockout:       10 * time.Minute,
+		Window:           15 * time.Minute,
+	}
+}
+
+func TestPolicy_Lockout(t *testing.T) {
+	p := Policy{Threshold: 3, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	assert.Zero(t, p.Lockout(2), "до порога блокировки нет")
+	assert.Equal(t, time.Minute, p.Lockout(3))
+	assert.Equal(t, 2*time.Minute, p.Lockout(4))
+	assert.Equal(t, 8*time.Minute, p.Lockout(6))
+	assert.Equal(t, 10*time.Minute, p.Lockout(50), "блокировка ограничена MaxLockout")
+}
+
+func TestGuard_LocksAccountAfterThreshold(t *testing.T) {
+	audit := &memAuditor{}
+	g := NewGuard(newMemStore(), audit, testConfig(), zerolog.Nop())
+	ctx := context.Background()
+	keys := []Key{Account("User@Example.com"), IP("10.0.0.1")}
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, g.Check(ctx, keys...))
+		g.Fail(ctx, "10.0.0.1", keys...)
+	}
+	assert.NoError(t, g.Check(ctx, keys...), "две неудачи не должны блокировать")
+
+	g.Fail(ctx, "10.0.0.1", keys...)
+	err := g.Check(ctx, Account("user@example.com"))
+	var lockErr *AppErr.LockoutError
+	assert.True(t, errors.As(err, &lockErr))
+	assert.ErrorIs(t, err, AppErr.ErrAccountLocked)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), lockErr.Until, 5*time.Second)
+
+	if assert.Len(t, audit.events, 1, "блокировка должна попасть в журнал") {
+		assert.Equal(t, model.LockoutActionLock, audit.events[0].Action)
+		assert.Equal(t, "user@example.com", audit.events[0].Subject)
+		assert.Equal(t, "10.0.0.1", audit.events[0].IP)
+		assert.Equal(t, 3, audit.events[0].Failures)
+	}
+}
+
+func TestGuard_IPLockout(t *testing.T) {
+	g := NewGuard(newMemStore(), nil, testConfig(), zerolog.Nop())
+	ctx := context.Background()
+
+	// Spraying different accounts from one address.
+	for i := 0; i < 5; i++ {
+		g.Fail(ctx, "10.0.0.2", IP("10.0.0.2"))
+	}
+	err := g.Check(ctx, Account("fresh@example.com"), IP("10.0.0.2"))
+	assert.ErrorIs(t, err, AppErr.ErrTooManyAttempts)
+	assert.NoError(t, g.Check(ctx, Account("fresh@example.com"), IP("10.0.0.3")))
+}
+
+func TestGuard_UnlockAndSucceed(t *testing.T) {
+	audit := &memAuditor{}
+	g := NewGuard(newMemStore(), audit, testConfig(), zerolog.Nop())
+	ctx := context.Background()
+	key := Client("cart-svc")
+
+	for i := 0; i < 3; i++ {
+		g.Fail(ctx, "", key)
+	}
+	assert.ErrorIs(t, g.Check(ctx, key), AppErr.ErrAccountLocked)
+
+	assert.NoError(t, g.Unlock(ctx, key, "admin-id"))
+	assert.NoError(t, g.Check(ctx, key))
+	if assert.Len(t, audit.events, 2) {
+		assert.Equal(t, model.LockoutActionUnlock, audit.events[1].Action)
+		assert.Equal(t, "admin-id", audit.events[1].Actor)
+	}
+
+	g.Fail(ctx, "", key)
+	g.Fail(ctx, "", key)
+	g.Succeed(ctx, key)
+	g.Fail(ctx, "", key)
+	assert.NoError(t, g.Check(ctx, key), "успешный вход сбрасывает счетчик")
+}
+
+func TestGuard_Nil(t *testing.T) {
+	var g *Guard
+	ctx := context.Background()
+	g.Fail(ctx, "10.0.0.1", Account("user@example.com"))
+	g.Succeed(ctx, Account("user@example.com"))
+	assert.NoError(t, g.Check(ctx, Account("user@example.com")))
+	assert.NoError(t, g.Unlock(ctx, Account("user@example.com"), "admin-id"))
+}
+
+func TestWithPrefix_KeepsCountersApart(t *testing.T) {
+	store := newMemStore()
+	signIn := NewGuard(store, nil, testConfig(), zerolog.Nop())
+	resets := NewGuard(WithPrefix(store, "password_reset:"), nil, testConfig(), zerolog.Nop())
+	ctx := context.Background()
+	key := Account("user@example.com")
+
+	for i := 0; i < 3; i++ {
+		resets.Fail(ctx, "10.0.0.1", key)
+	}
+	assert.ErrorIs(t, resets.Check(ctx, key), AppErr.ErrAccountLocked)
+	assert.NoError(t, signIn.Check(ctx, key), "запросы сброса пароля не должны блокировать вход")
+	assert.Contains(t, store.entries, "password_reset:"+key.String())
+}
@@ -1,11 +1,11 @@
 package authclient
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -59,13 +59,14 @@ func (c *Client) Token(ctx context.Context) (string, error) {
 }
 
 func (c *Client) fetch(ctx context.Context) (string, time.Time, error) {
-	body, _ := json.Marshal(map[string]string{
-		"clientId":     c.id,
-		"clientSecret": c.secret,
-	})
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPost,
-		c.baseURL+"/api/v1/auth/token", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/api/v1/auth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.id), url.QueryEscape(c.secret))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -74,14 +75,21 @@ func (c *Client) fetch(ctx context.Context) (string, time.Time, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		var oe struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&oe) == nil && oe.Error != "" {
+			return "", time.Time{}, fmt.Errorf("status %s: %s: %s", resp.Status, oe.Error, oe.Description)
+		}
 		return "", time.Time{}, fmt.Errorf("status %s", resp.Status)
 	}
 	var tr struct {
-		AccessToken     string `json:"accessToken"`
-		AccessExpiresAt int64  `json:"accessExpiresAt"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
 		return "", time.Time{}, err
 	}
-	return tr.AccessToken, time.Unix(tr.AccessExpiresAt, 0), nil
+	return tr.AccessToken, time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second), nil
 }